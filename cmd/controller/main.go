@@ -31,7 +31,7 @@ func main() {
 	ctx, op := operator.NewOperator(koreoperator.NewOperator())
 
 	undecoratedCloudProvider := cloudprovider.NewCloudProvider(ctx, op.GetClient(), op.MachineProvider,
-		op.InstanceTypeProvider, op.ZoneProvider)
+		op.InstanceTypeProvider, op.ZoneProvider, op.VersionProvider)
 	cloudProvider := metrics.Decorate(undecoratedCloudProvider)
 	clusterState := state.NewCluster(op.Clock, op.GetClient(), cloudProvider)
 	instanceTypeStore := nodeoverlay.NewInstanceTypeStore()
@@ -58,6 +58,12 @@ func main() {
 			op.ZoneProvider,
 			op.VPCProvider,
 			op.SSHKeyProvider,
+			op.ImageProvider,
+			op.HPCClusterProvider,
+			op.EIPProvider,
+			op.InstanceProvider,
+			op.MachineProvider,
+			op.ValidationProvider,
 		)...).
 		Start(ctx)
 }
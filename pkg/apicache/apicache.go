@@ -0,0 +1,69 @@
+/*
+Copyright (C) 2012-2025 Tencent. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package apicache caches the responses of the tencent cloud describe APIs for the providers, so the
+// TKEMachineNodeClasses selecting the same resources share the calls.
+package apicache
+
+import (
+	"time"
+
+	"github.com/patrickmn/go-cache"
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	// DefaultTTL keeps the responses as fresh as the status of the TKEMachineNodeClasses, which is resolved every minute.
+	DefaultTTL = time.Minute
+	// DefaultCleanupInterval triggers the cleanup of the expired responses.
+	DefaultCleanupInterval = 5 * time.Minute
+)
+
+// Cache is a TTL cache of the API responses, the concurrent loads of the same key are de-duplicated.
+type Cache struct {
+	cache *cache.Cache
+	group singleflight.Group
+}
+
+func New(ttl, cleanupInterval time.Duration) *Cache {
+	return &Cache{
+		cache: cache.New(ttl, cleanupInterval),
+	}
+}
+
+// GetOrLoad returns the cached response of the action for the key, or loads it if it's not cached.
+// The failed loads are not cached.
+func GetOrLoad[T any](c *Cache, action, key string, load func() (T, error)) (T, error) {
+	key = action + "/" + key
+	if v, ok := c.cache.Get(key); ok {
+		cacheHits.WithLabelValues(action).Inc()
+		return v.(T), nil
+	}
+	cacheMisses.WithLabelValues(action).Inc()
+	v, err, _ := c.group.Do(key, func() (any, error) {
+		v, err := load()
+		if err != nil {
+			return nil, err
+		}
+		c.cache.SetDefault(key, v)
+		return v, nil
+	})
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return v.(T), nil
+}
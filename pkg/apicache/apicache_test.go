@@ -0,0 +1,96 @@
+package apicache
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestGetOrLoad_Cached(t *testing.T) {
+	c := New(time.Minute, time.Minute)
+	loads := 0
+	load := func() ([]string, error) {
+		loads++
+		return []string{"subnet-1"}, nil
+	}
+	for i := 0; i < 3; i++ {
+		v, err := GetOrLoad(c, "DescribeSubnets", "key", load)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(v) != 1 || v[0] != "subnet-1" {
+			t.Errorf("unexpected value %v", v)
+		}
+	}
+	if loads != 1 {
+		t.Errorf("expected 1 load, got %d", loads)
+	}
+	if _, err := GetOrLoad(c, "DescribeSecurityGroups", "key", load); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if loads != 2 {
+		t.Errorf("expected the same key of another action to be loaded, got %d loads", loads)
+	}
+}
+
+func TestGetOrLoad_Expired(t *testing.T) {
+	c := New(10*time.Millisecond, time.Minute)
+	loads := 0
+	load := func() (int, error) {
+		loads++
+		return loads, nil
+	}
+	if _, err := GetOrLoad(c, "DescribeSubnets", "key", load); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	time.Sleep(20 * time.Millisecond)
+	v, err := GetOrLoad(c, "DescribeSubnets", "key", load)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if v != 2 {
+		t.Errorf("expected the expired response to be loaded again, got %d", v)
+	}
+}
+
+func TestGetOrLoad_ErrorNotCached(t *testing.T) {
+	c := New(time.Minute, time.Minute)
+	_, err := GetOrLoad(c, "DescribeSubnets", "key", func() (int, error) {
+		return 0, errors.New("request limit exceeded")
+	})
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	v, err := GetOrLoad(c, "DescribeSubnets", "key", func() (int, error) {
+		return 1, nil
+	})
+	if err != nil || v != 1 {
+		t.Errorf("expected the failed load to be retried, got %d, %v", v, err)
+	}
+}
+
+func TestGetOrLoad_Deduplicated(t *testing.T) {
+	c := New(time.Minute, time.Minute)
+	var loads atomic.Int32
+	release := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _ = GetOrLoad(c, "DescribeSubnets", "key", func() (int, error) {
+				loads.Add(1)
+				<-release
+				return 1, nil
+			})
+		}()
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	if loads.Load() != 1 {
+		t.Errorf("expected the concurrent loads to be de-duplicated, got %d loads", loads.Load())
+	}
+}
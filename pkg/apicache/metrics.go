@@ -0,0 +1,71 @@
+/*
+Copyright (C) 2012-2025 Tencent. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apicache
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	crmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	"sigs.k8s.io/karpenter/pkg/metrics"
+)
+
+const (
+	cloudProviderSubsystem = "cloudprovider"
+	actionLabel            = "action"
+)
+
+var (
+	cacheHits = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: cloudProviderSubsystem,
+			Name:      "api_cache_hits_total",
+			Help:      "Number of tencent cloud API responses served from the cache, labeled by the API action",
+		},
+		[]string{
+			actionLabel,
+		})
+	cacheMisses = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: cloudProviderSubsystem,
+			Name:      "api_cache_misses_total",
+			Help:      "Number of tencent cloud API responses not found in the cache, labeled by the API action",
+		},
+		[]string{
+			actionLabel,
+		})
+	apiCalls = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: cloudProviderSubsystem,
+			Name:      "api_calls_total",
+			Help:      "Number of tencent cloud API calls made to load the cached responses, labeled by the API action",
+		},
+		[]string{
+			actionLabel,
+		})
+)
+
+func init() {
+	crmetrics.Registry.MustRegister(cacheHits, cacheMisses, apiCalls)
+}
+
+// RecordAPICall counts a call of the API action.
+func RecordAPICall(action string) {
+	apiCalls.WithLabelValues(action).Inc()
+}
@@ -21,6 +21,7 @@ import (
 )
 
 func init() {
+	v1.WellKnownValuesForRequirements[v1.CapacityTypeLabelKey].Insert(CapacityTypePrepaid)
 	v1.RestrictedLabelDomains = v1.RestrictedLabelDomains.Insert(RestrictedLabelDomains...)
 	v1.WellKnownLabels = v1.WellKnownLabels.Insert(
 		// CapacityGroup+AnnotationCPU,
@@ -73,11 +74,17 @@ func init() {
 		LabelNodeClaim,
 
 		LabelInstanceFamily,
+		LabelInstanceCategory,
 		LabelInstanceCPU,
 		LabelInstanceMemoryGB,
+		LabelInstanceGPUCount,
+		LabelInstanceGPUName,
+		LabelInstanceGPUMemory,
 
 		LabelCBSToplogy,
 
+		LabelRDMA,
+
 		TKELabelENIIP,
 		TKELabelDirectENI,
 		TKELabelENI,
@@ -86,6 +93,10 @@ func init() {
 	)
 }
 
+// CapacityTypePrepaid is the capacity type of the prepaid (or underwrite) instances, which are billed for the whole
+// period of the TKEMachineNodeClass up front.
+const CapacityTypePrepaid = "prepaid"
+
 var (
 	CapacityGroup          = "capacity." + Group
 	KubeReservedGroup      = "kube-reserved." + Group
@@ -97,12 +108,23 @@ var (
 	LabelNodeClass = Group + "/tkemachinenodeclass"
 	LabelNodeClaim = Group + "/nodeclaim"
 
-	LabelInstanceFamily   = Group + "/instance-family"
+	LabelInstanceFamily = Group + "/instance-family"
+	// LabelInstanceCategory is one of the InstanceCategory values, the exotic categories are only launched when asked for
+	LabelInstanceCategory = Group + "/instance-category"
 	LabelInstanceCPU      = Group + "/instance-cpu"
 	LabelInstanceMemoryGB = Group + "/instance-memory-gb"
+	// LabelInstanceGPUCount, LabelInstanceGPUName and LabelInstanceGPUMemory (MiB of a GPU) only exist on the GPU instance types
+	LabelInstanceGPUCount  = Group + "/instance-gpu-count"
+	LabelInstanceGPUName   = Group + "/instance-gpu-name"
+	LabelInstanceGPUMemory = Group + "/instance-gpu-memory"
 
 	LabelCBSToplogy = "topology.com.tencent.cloud.csi.cbs/zone"
 
+	// LabelRDMA is "true" on the nodes launched into a HPC cluster, which are RDMA-connected
+	LabelRDMA = Group + "/rdma"
+	// LabelLifecycleScript is "true" on the Secrets and ConfigMaps which the lifecycle script fragments may read
+	LabelLifecycleScript = Group + "/lifecycle-script"
+
 	TKELabelENIIP     = "tke.cloud.tencent.com/eni-ip"
 	TKELabelDirectENI = "tke.cloud.tencent.com/direct-eni"
 	TKELabelENI       = "tke.cloud.tencent.com/eni"
@@ -131,34 +153,71 @@ var (
 	AnnotationOwnedMachine = Group + "/owned-machine"
 	AnnotationManagedBy    = Group + "/managed-by"
 	AnnotationUnitPrice    = Group + "/unit-price"
+	// AnnotationPrepaidExpiration is the RFC3339 expiration time of the prepaid instance of the node, the node isn't
+	// disrupted voluntarily before it
+	AnnotationPrepaidExpiration = Group + "/prepaid-expiration"
+
+	// the ownership tags applied on the instances to attribute them to the cluster, NodePool, NodeClaim and
+	// TKEMachineNodeClass, they take precedence over the tags of the TKEMachineNodeClass
+	TagClusterID = Group + "/cluster-id"
+	TagNodePool  = v1.NodePoolLabelKey
+	TagNodeClaim = "karpenter.sh/nodeclaim"
+	TagNodeClass = LabelNodeClass
+
+	AnnotationTKEMachineNodeClassHash        = Group + "/tkemachinenodeclass-hash"
+	AnnotationTKEMachineNodeClassHashVersion = Group + "/tkemachinenodeclass-hash-version"
+
+	// Deprecated: use spec.management.kubeletArgs of the TKEMachineNodeClass instead.
+	AnnotationKubeletArgPrefix = "beta." + Group + ".kubelet.arg/"
+	// Deprecated: use spec.management.kernelArgs of the TKEMachineNodeClass instead.
+	AnnotationKernelArgPrefix = "beta." + Group + ".kernel.arg/"
+	// Deprecated: use spec.management.hosts of the TKEMachineNodeClass instead.
+	AnnotationHostsPrefix = "beta." + Group + ".hosts.ip/"
+	// Deprecated: use spec.management.runtimeRootDir of the TKEMachineNodeClass instead.
+	AnnotationRuntimeRootKey = "beta." + Group + ".machine/runtime-root"
+	// Deprecated: use spec.management.hostName of the TKEMachineNodeClass instead.
+	AnnotationHostnameKey = "beta." + Group + ".machine/hostname"
+	// Deprecated: use spec.management.nameservers of the TKEMachineNodeClass instead.
+	AnnotationNameserversKey = "beta." + Group + ".machine/nameservers"
 
-	AnnotationKubeletArgPrefix          = "beta." + Group + ".kubelet.arg/"
-	AnnotationKernelArgPrefix           = "beta." + Group + ".kernel.arg/"
-	AnnotationHostsPrefix               = "beta." + Group + ".hosts.ip/"
 	AnnotationGPUDriverKey              = "beta." + Group + ".gpu/driver"
 	AnnotationGPUCUDAKey                = "beta." + Group + ".gpu/cuda"
 	AnnotationGPUCUDNNKey               = "beta." + Group + ".gpu/cudnn"
 	AnnotationGPUMIGEnableKey           = "beta." + Group + ".gpu/mig-enable"
 	AnnotationFabricKey                 = "beta." + Group + ".gpu/fabric"
-	AnnotationRuntimeRootKey            = "beta." + Group + ".machine/runtime-root"
-	AnnotationHostnameKey               = "beta." + Group + ".machine/hostname"
-	AnnotationNameserversKey            = "beta." + Group + ".machine/nameservers"
 	AnnotationMachineSpecAnnotationsKey = "beta." + Group + ".machine.spec/annotations"
 	AnnotationMachineMetaAnnotationsKey = "beta." + Group + ".machine.meta/annotations"
-	AnnotationDataDisksThroughputKey    = "beta." + Group + ".datadisks/throughput"
-	AnnotationDataDisksEncryptKey       = "beta." + Group + ".datadisks/encrypt"
-	AnnotationDataDisksKMSID            = "beta." + Group + ".datadisks/kms-id"
-	AnnotationDataDisksSnapshotID       = "beta." + Group + ".datadisks/snapshot-id"
-	AnnotationDataDisksImageCacheID     = "beta." + Group + ".datadisks/image-cache-id"
 
+	// Deprecated: use spec.dataDisks[].throughputPerformance of the TKEMachineNodeClass instead.
+	AnnotationDataDisksThroughputKey = "beta." + Group + ".datadisks/throughput"
+	// Deprecated: use spec.dataDisks[].encrypted of the TKEMachineNodeClass instead.
+	AnnotationDataDisksEncryptKey = "beta." + Group + ".datadisks/encrypt"
+	// Deprecated: use spec.dataDisks[].kmsKeyID of the TKEMachineNodeClass instead.
+	AnnotationDataDisksKMSID = "beta." + Group + ".datadisks/kms-id"
+	// Deprecated: use spec.dataDisks[].snapshotID of the TKEMachineNodeClass instead.
+	AnnotationDataDisksSnapshotID = "beta." + Group + ".datadisks/snapshot-id"
+	// Deprecated: use spec.dataDisks[].imageCacheID of the TKEMachineNodeClass instead.
+	AnnotationDataDisksImageCacheID = "beta." + Group + ".datadisks/image-cache-id"
+
+	// Deprecated: use spec.systemDisk.encrypted of the TKEMachineNodeClass instead.
 	AnnotationSystemDiskEncryptKey = "beta." + Group + ".systemdisk/encrypt"
-	AnnotationSystemDiskKMSID      = "beta." + Group + ".systemdisk/kms-id"
+	// Deprecated: use spec.systemDisk.kmsKeyID of the TKEMachineNodeClass instead.
+	AnnotationSystemDiskKMSID = "beta." + Group + ".systemdisk/kms-id"
 )
 
 var (
 	TerminationFinalizer = Group + "/termination"
 )
 
+// The values of LabelInstanceCategory, the instance types of the categories other than InstanceCategoryGeneral are exotic
+const (
+	InstanceCategoryGeneral    = "general"
+	InstanceCategoryGPU        = "gpu"
+	InstanceCategoryFPGA       = "fpga"
+	InstanceCategoryBareMetal  = "bare-metal"
+	InstanceCategoryHighMemory = "high-memory"
+)
+
 var (
 	ResourceNVIDIAGPU = "nvidia.com/gpu"
 )
@@ -17,6 +17,10 @@ limitations under the License.
 package v1beta1
 
 import (
+	"fmt"
+
+	"github.com/mitchellh/hashstructure/v2"
+	"github.com/samber/lo"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -24,25 +28,41 @@ import (
 type TKEMachineNodeClassSpec struct {
 	// SubnetSelectorTerms is a list of or subnet selector terms. The terms are ORed.
 	// +kubebuilder:validation:XValidation:message="subnetSelectorTerms cannot be empty",rule="self.size() != 0"
-	// +kubebuilder:validation:XValidation:message="expected at least one, got none, ['tags', 'id']",rule="self.all(x, has(x.tags) || has(x.id))"
-	// +kubebuilder:validation:XValidation:message="'id' is mutually exclusive, cannot be set with a combination of other fields in subnetSelectorTerms",rule="!self.all(x, has(x.id) && has(x.tags))"
+	// +kubebuilder:validation:XValidation:message="expected at least one, got none, ['tags', 'id', 'name', 'zone', 'cidr']",rule="self.all(x, has(x.tags) || has(x.id) || has(x.name) || has(x.zone) || has(x.cidr))"
+	// +kubebuilder:validation:XValidation:message="'id' is mutually exclusive, cannot be set with a combination of other fields in subnetSelectorTerms",rule="!self.exists(x, has(x.id) && (has(x.tags) || has(x.name) || has(x.zone) || has(x.cidr) || has(x.excludeTags)))"
 	// +kubebuilder:validation:MaxItems:=30
 	// +required
 	SubnetSelectorTerms []SubnetSelectorTerm `json:"subnetSelectorTerms" hash:"ignore"`
 	// SecurityGroupSelectorTerms is a list of or security group selector terms. The terms are ORed.
 	// +kubebuilder:validation:XValidation:message="securityGroupSelectorTerms cannot be empty",rule="self.size() != 0"
-	// +kubebuilder:validation:XValidation:message="expected at least one, got none, ['tags', 'id']",rule="self.all(x, has(x.tags) || has(x.id))"
-	// +kubebuilder:validation:XValidation:message="'id' is mutually exclusive, cannot be set with a combination of other fields in securityGroupSelectorTerms",rule="!self.all(x, has(x.id) && has(x.tags))"
+	// +kubebuilder:validation:XValidation:message="expected at least one, got none, ['tags', 'id', 'name']",rule="self.all(x, has(x.tags) || has(x.id) || has(x.name))"
+	// +kubebuilder:validation:XValidation:message="'id' is mutually exclusive, cannot be set with a combination of other fields in securityGroupSelectorTerms",rule="!self.exists(x, has(x.id) && (has(x.tags) || has(x.name) || has(x.excludeTags)))"
 	// +kubebuilder:validation:MaxItems:=5
 	// +required
 	SecurityGroupSelectorTerms []SecurityGroupSelectorTerm `json:"securityGroupSelectorTerms" hash:"ignore"`
 	// SSHKeySelectorTerms is a list of or SSH key selector terms. The terms are ORed.
 	// +kubebuilder:validation:XValidation:message="sshKeySelectorTerms cannot be empty",rule="self.size() != 0"
-	// +kubebuilder:validation:XValidation:message="expected at least one, got none, ['tags', 'id']",rule="self.all(x, has(x.tags) || has(x.id))"
-	// +kubebuilder:validation:XValidation:message="'id' is mutually exclusive, cannot be set with a combination of other fields in sshKeySelectorTerms",rule="!self.all(x, has(x.id) && has(x.tags))"
+	// +kubebuilder:validation:XValidation:message="expected at least one, got none, ['tags', 'id', 'name']",rule="self.all(x, has(x.tags) || has(x.id) || has(x.name))"
+	// +kubebuilder:validation:XValidation:message="'id' is mutually exclusive, cannot be set with a combination of other fields in sshKeySelectorTerms",rule="!self.exists(x, has(x.id) && (has(x.tags) || has(x.name) || has(x.excludeTags)))"
 	// +kubebuilder:validation:MaxItems:=30
 	// +optional
 	SSHKeySelectorTerms []SSHKeySelectorTerm `json:"sshKeySelectorTerms" hash:"ignore"`
+	// ImageSelectorTerms is a list of or image selector terms. The terms are ORed.
+	// The newest matching image of each architecture is used to launch nodes of that architecture.
+	// If not specified, the default image of the TKE native node is used.
+	// +kubebuilder:validation:XValidation:message="expected at least one, got none, ['tags', 'id', 'name']",rule="self.all(x, has(x.tags) || has(x.id) || has(x.name))"
+	// +kubebuilder:validation:XValidation:message="'id' is mutually exclusive, cannot be set with a combination of other fields in imageSelectorTerms",rule="!self.exists(x, has(x.id) && (has(x.tags) || has(x.name)))"
+	// +kubebuilder:validation:MaxItems:=30
+	// +optional
+	ImageSelectorTerms []ImageSelectorTerm `json:"imageSelectorTerms,omitempty" hash:"ignore"`
+	// HPCClusterSelectorTerms is a list of or HPC cluster selector terms. The terms are ORed.
+	// The nodes are launched into the HPC cluster in the zone of the node to be RDMA-connected, the zones
+	// without a selected HPC cluster are not used. At most one HPC cluster can be selected in a zone.
+	// +kubebuilder:validation:XValidation:message="expected at least one, got none, ['tags', 'id']",rule="self.all(x, has(x.tags) || has(x.id))"
+	// +kubebuilder:validation:XValidation:message="'id' is mutually exclusive, cannot be set with a combination of other fields in hpcClusterSelectorTerms",rule="!self.exists(x, has(x.id) && has(x.tags))"
+	// +kubebuilder:validation:MaxItems:=30
+	// +optional
+	HPCClusterSelectorTerms []HPCClusterSelectorTerm `json:"hpcClusterSelectorTerms,omitempty" hash:"ignore"`
 	// SystemDisk defines the system disk of the instance.
 	// if not specified, a default system disk (CloudPremium, 50GB) will be used.
 	// +optional
@@ -50,18 +70,59 @@ type TKEMachineNodeClassSpec struct {
 	// DataDisks defines the data disks of the instance.
 	// +optional
 	DataDisks []DataDisk `json:"dataDisks,omitempty"`
+	// InstanceStorePolicy specifies how to use the local disks (LOCAL_NVME, LOCAL_SSD) of the instance types with
+	// instance store. If not specified, the local disks are left untouched.
+	// RAID0: all local disks are combined into a RAID0 array which is used by the container runtime and kubelet
+	// root directories, the ephemeral-storage of the node is computed from the local disks.
+	// +optional
+	InstanceStorePolicy *InstanceStorePolicy `json:"instanceStorePolicy,omitempty"`
+	// HostMaintenancePolicy specifies what karpenter does with the nodes under maintenance.
+	// Migrate: the nodes are kept while the platform live migrates their instances, it is the default.
+	// Terminate: karpenter replaces the nodes whose Machine reports the Repairing maintenance status proactively.
+	// +optional
+	HostMaintenancePolicy *HostMaintenancePolicy `json:"hostMaintenancePolicy,omitempty" hash:"ignore"`
 	// InternetAccessible is the network configuration used to create network interface for the node.
 	// +optional
 	InternetAccessible *InternetAccessible `json:"internetAccessible,omitempty"`
 	// LifecycleScript allow users to operations on the node before/after the node initialization.
 	// +optional
 	LifecycleScript *LifecycleScript `json:"lifecycleScript,omitempty"`
+	// Management defines the kubelet, kernel and name resolution settings of the node.
+	// The deprecated NodeClaim annotations (e.g. beta.karpenter.k8s.tke.kubelet.arg/) still take precedence
+	// over the values defined here.
+	// +optional
+	Management *ManagementConfig `json:"management,omitempty"`
+	// Kubelet defines the kubelet configuration of the node. These values are used both for the scheduling
+	// overhead calculation of karpenter and the kubelet arguments of the node.
+	// +optional
+	Kubelet *KubeletConfiguration `json:"kubelet,omitempty"`
+	// KubeletVersion pins the kubelet version of the nodes, e.g. 1.30.0. It must not be newer than the control plane
+	// and can be at most 3 minor versions older. If not specified, the nodes follow the version of the control plane
+	// and are replaced once the minor version of the control plane is upgraded.
+	// +kubebuilder:validation:Pattern:="^[0-9]+[.][0-9]+[.][0-9]+(-[0-9a-zA-Z.-]+)?$"
+	// +optional
+	KubeletVersion *string `json:"kubeletVersion,omitempty"`
+	// RuntimeVersion pins the container runtime version of the nodes, e.g. 1.6.9.
+	// If not specified, the version corresponding to the control plane is used.
+	// +optional
+	RuntimeVersion *string `json:"runtimeVersion,omitempty"`
+	// Prepaid configures the billing of the nodes launched with the prepaid capacity type
+	// (karpenter.sh/capacity-type: prepaid). The prepaid offerings are only available when it is specified.
+	// +optional
+	Prepaid *Prepaid `json:"prepaid,omitempty"`
 	// Tags to be applied on tke machine resources like instances.
 	// The tags must be already created in tencentcloud
 	// (https://console.cloud.tencent.com/tag)
+	// The changes are synchronized to the existing instances unless the Machine is annotated with
+	// node.tke.cloud.tencent.com/disable-sync-machine-tags: "true". The ownership tags karpenter.k8s.tke/cluster-id,
+	// karpenter.sh/nodepool, karpenter.sh/nodeclaim and karpenter.k8s.tke/tkemachinenodeclass are applied automatically.
 	// +kubebuilder:validation:XValidation:message="empty tag keys aren't supported",rule="self.all(k, k != '')"
+	// +kubebuilder:validation:XValidation:message="tag contains a restricted tag matching karpenter.k8s.tke/cluster-id",rule="self.all(k, k != 'karpenter.k8s.tke/cluster-id')"
+	// +kubebuilder:validation:XValidation:message="tag contains a restricted tag matching karpenter.sh/nodepool",rule="self.all(k, k != 'karpenter.sh/nodepool')"
+	// +kubebuilder:validation:XValidation:message="tag contains a restricted tag matching karpenter.sh/nodeclaim",rule="self.all(k, k != 'karpenter.sh/nodeclaim')"
+	// +kubebuilder:validation:XValidation:message="tag contains a restricted tag matching karpenter.k8s.tke/tkemachinenodeclass",rule="self.all(k, k != 'karpenter.k8s.tke/tkemachinenodeclass')"
 	// +optional
-	Tags map[string]string `json:"tags,omitempty"`
+	Tags map[string]string `json:"tags,omitempty" hash:"ignore"`
 }
 
 // SubnetSelectorTerm defines selection logic for a subnet used by Karpenter to launch nodes.
@@ -79,6 +140,22 @@ type SubnetSelectorTerm struct {
 	// +kubebuilder:validation:Pattern="subnet-[0-9a-z]+"
 	// +optional
 	ID string `json:"id,omitempty"`
+	// Name is the subnet name
+	// +optional
+	Name string `json:"name,omitempty"`
+	// Zone is the zone of the subnet, e.g. ap-guangzhou-3
+	// +optional
+	Zone string `json:"zone,omitempty"`
+	// CIDR is an IPv4 CIDR, the subnets whose CIDR block is contained in it are selected.
+	// +kubebuilder:validation:Pattern:=`^([0-9]{1,3}\.){3}[0-9]{1,3}/([0-9]|[12][0-9]|3[0-2])$`
+	// +optional
+	CIDR string `json:"cidr,omitempty"`
+	// ExcludeTags is a map of key/value tags, the subnets having any of the tags aren't selected.
+	// Specifying '*' for a value excludes all values for a given tag key.
+	// +kubebuilder:validation:XValidation:message="empty tag keys or values aren't supported",rule="self.all(k, k != '' && self[k] != '')"
+	// +kubebuilder:validation:MaxProperties:=20
+	// +optional
+	ExcludeTags map[string]string `json:"excludeTags,omitempty"`
 }
 
 // SecurityGroupSelectorTerm defines selection logic for a security group used by Karpenter to launch nodes.
@@ -96,6 +173,21 @@ type SecurityGroupSelectorTerm struct {
 	// +kubebuilder:validation:Pattern:="sg-[0-9a-z]+"
 	// +optional
 	ID string `json:"id,omitempty"`
+	// Name is the security group name
+	// +optional
+	Name string `json:"name,omitempty"`
+	// Priority orders the security groups applied on the instances, the security groups selected by the terms
+	// with a lower priority come first, followed by the security groups of the terms without a priority.
+	// The security groups of the same priority are ordered by id. Only the first 5 security groups are applied.
+	// +kubebuilder:validation:Minimum:=0
+	// +optional
+	Priority *int32 `json:"priority,omitempty"`
+	// ExcludeTags is a map of key/value tags, the security groups having any of the tags aren't selected.
+	// Specifying '*' for a value excludes all values for a given tag key.
+	// +kubebuilder:validation:XValidation:message="empty tag keys or values aren't supported",rule="self.all(k, k != '' && self[k] != '')"
+	// +kubebuilder:validation:MaxProperties:=20
+	// +optional
+	ExcludeTags map[string]string `json:"excludeTags,omitempty"`
 }
 
 // SSHKeysSelectorTerm defines selection logic for a security group used by Karpenter to launch nodes.
@@ -113,6 +205,90 @@ type SSHKeySelectorTerm struct {
 	// +kubebuilder:validation:Pattern:="skey-[0-9a-z]+"
 	// +optional
 	ID string `json:"id,omitempty"`
+	// Name is the SSH key name
+	// +optional
+	Name string `json:"name,omitempty"`
+	// ExcludeTags is a map of key/value tags, the SSH keys having any of the tags aren't selected.
+	// Specifying '*' for a value excludes all values for a given tag key.
+	// +kubebuilder:validation:XValidation:message="empty tag keys or values aren't supported",rule="self.all(k, k != '' && self[k] != '')"
+	// +kubebuilder:validation:MaxProperties:=20
+	// +optional
+	ExcludeTags map[string]string `json:"excludeTags,omitempty"`
+}
+
+// ImageSelectorTerm defines selection logic for an image used by Karpenter to launch nodes.
+// If multiple fields are used for selection, the requirements are ANDed.
+type ImageSelectorTerm struct {
+	// Tags is a map of key/value tags used to select images
+	// Specifying '*' for a value selects all values for a given tag key.
+	// The tags must be already created in tencentcloud
+	// (https://console.cloud.tencent.com/tag)
+	// +kubebuilder:validation:XValidation:message="empty tag keys or values aren't supported",rule="self.all(k, k != '' && self[k] != '')"
+	// +kubebuilder:validation:MaxProperties:=20
+	// +optional
+	Tags map[string]string `json:"tags,omitempty"`
+	// ID is the image id
+	// +kubebuilder:validation:Pattern:="img-[0-9a-z]+"
+	// +optional
+	ID string `json:"id,omitempty"`
+	// Name is the image name
+	// +optional
+	Name string `json:"name,omitempty"`
+}
+
+// HPCClusterSelectorTerm defines selection logic for a HPC cluster used by Karpenter to launch nodes.
+// If multiple fields are used for selection, the requirements are ANDed.
+type HPCClusterSelectorTerm struct {
+	// Tags is a map of key/value tags used to select HPC clusters
+	// Specifying '*' for a value selects all values for a given tag key.
+	// The tags must be already created in tencentcloud
+	// (https://console.cloud.tencent.com/tag)
+	// +kubebuilder:validation:XValidation:message="empty tag keys or values aren't supported",rule="self.all(k, k != '' && self[k] != '')"
+	// +kubebuilder:validation:MaxProperties:=20
+	// +optional
+	Tags map[string]string `json:"tags,omitempty"`
+	// ID is the HPC cluster id
+	// +kubebuilder:validation:Pattern:="hpc-[0-9a-z]+"
+	// +optional
+	ID string `json:"id,omitempty"`
+}
+
+// +kubebuilder:validation:Enum:={Prepaid,Underwrite}
+type PrepaidChargeType string
+
+// +kubebuilder:validation:Enum:={NotifyAndAutoRenew,NotifyAndManualRenew,DisableNotifyAndManualRenew}
+type RenewFlag string
+
+const (
+	PrepaidChargeTypePrepaid    PrepaidChargeType = "Prepaid"
+	PrepaidChargeTypeUnderwrite PrepaidChargeType = "Underwrite"
+
+	RenewFlagNotifyAndAutoRenew          RenewFlag = "NotifyAndAutoRenew"
+	RenewFlagNotifyAndManualRenew        RenewFlag = "NotifyAndManualRenew"
+	RenewFlagDisableNotifyAndManualRenew RenewFlag = "DisableNotifyAndManualRenew"
+)
+
+// Prepaid instances are billed for the whole period up front. Their offerings are priced at the hourly cost of the
+// monthly price, so they are launched whenever the NodePool allows the prepaid capacity type and they are the cheapest;
+// only allow it on the NodePools of the long-lived baseline capacity. The nodes aren't consolidated or replaced for
+// drift before the expiration time of their instances, which renewals extend.
+type Prepaid struct {
+	// ChargeType is the billing plan of the prepaid instances.
+	// Supported type: {Prepaid, Underwrite}.
+	// Underwrite is only available for the instance types that support it with the specified period.
+	// +kubebuilder:default:=Prepaid
+	// +optional
+	ChargeType PrepaidChargeType `json:"chargeType,omitempty"`
+	// Period is the subscription period of the prepaid instances in months.
+	// +kubebuilder:validation:Enum:={1,2,3,4,5,6,7,8,9,10,11,12,24,36}
+	// +kubebuilder:default:=1
+	// +optional
+	Period int32 `json:"period,omitempty"`
+	// RenewFlag is the auto renewal flag of the prepaid instances.
+	// Supported flag: {NotifyAndAutoRenew, NotifyAndManualRenew, DisableNotifyAndManualRenew}.
+	// +kubebuilder:default:=NotifyAndManualRenew
+	// +optional
+	RenewFlag RenewFlag `json:"renewFlag,omitempty"`
 }
 
 // +kubebuilder:validation:Enum:={CloudPremium,CloudSSD,CloudHSSD,CloudTSSD,CloudBSSD}
@@ -124,6 +300,15 @@ type FileSystem string
 // +kubebuilder:validation:Enum:={TrafficPostpaidByHour,BandwidthPackage,BandwidthPostpaidByHour}
 type InternetChargeType string
 
+// +kubebuilder:validation:Enum:={RAID0}
+type InstanceStorePolicy string
+
+// +kubebuilder:validation:Enum:={EIP,HighQualityEIP,PublicIP}
+type AddressType string
+
+// +kubebuilder:validation:Enum:={Migrate,Terminate}
+type HostMaintenancePolicy string
+
 const (
 	DiskTypeCloudPremium DiskType = "CloudPremium"
 	DiskTypeCloudSSD     DiskType = "CloudSSD"
@@ -138,8 +323,18 @@ const (
 	TrafficPostpaidByHour   InternetChargeType = "TrafficPostpaidByHour"
 	BandwidthPackage        InternetChargeType = "BandwidthPackage"
 	BandwidthPostpaidByHour InternetChargeType = "BandwidthPostpaidByHour"
+
+	InstanceStorePolicyRAID0 InstanceStorePolicy = "RAID0"
+
+	AddressTypeEIP            AddressType = "EIP"
+	AddressTypeHighQualityEIP AddressType = "HighQualityEIP"
+	AddressTypePublicIP       AddressType = "PublicIP"
+
+	HostMaintenancePolicyMigrate   HostMaintenancePolicy = "Migrate"
+	HostMaintenancePolicyTerminate HostMaintenancePolicy = "Terminate"
 )
 
+// +kubebuilder:validation:XValidation:message="kmsKeyID requires encrypted to be true",rule="has(self.kmsKeyID) ? has(self.encrypted) && self.encrypted : true"
 type SystemDisk struct {
 	// Size of disk in GB.
 	// Supported size: 20-2048, step size is 1.
@@ -150,8 +345,19 @@ type SystemDisk struct {
 	// Type of disk, supported type: {CloudPremium, CloudSSD, CloudHSSD, CloudTSSD, CloudBSSD}.
 	// +optional
 	Type DiskType `json:"type,omitempty"`
+	// Encrypted specify whether to encrypt the disk.
+	// +optional
+	Encrypted *bool `json:"encrypted,omitempty"`
+	// KMSKeyID is the KMS key used to encrypt the disk, the default key of CBS is used if not specified.
+	// The key should be already created in tencentcloud
+	// (https://console.cloud.tencent.com/kms2)
+	// +kubebuilder:validation:MinLength=1
+	// +optional
+	KMSKeyID *string `json:"kmsKeyID,omitempty"`
 }
 
+// +kubebuilder:validation:XValidation:message="kmsKeyID requires encrypted to be true",rule="has(self.kmsKeyID) ? has(self.encrypted) && self.encrypted : true"
+// +kubebuilder:validation:XValidation:message="throughputPerformance is only supported by CloudHSSD and CloudTSSD",rule="has(self.throughputPerformance) ? has(self.type) && (self.type == 'CloudHSSD' || self.type == 'CloudTSSD') : true"
 type DataDisk struct {
 	// Size of disk in GB.
 	// Supported size: 20-32000, step size is 10.
@@ -171,9 +377,33 @@ type DataDisk struct {
 	// If not specified, default etx4 will be used.
 	// +optional
 	FileSystem *FileSystem `json:"fileSystem,omitempty"`
+	// Encrypted specify whether to encrypt the disk.
+	// +optional
+	Encrypted *bool `json:"encrypted,omitempty"`
+	// KMSKeyID is the KMS key used to encrypt the disk, the default key of CBS is used if not specified.
+	// The key should be already created in tencentcloud
+	// (https://console.cloud.tencent.com/kms2)
+	// +kubebuilder:validation:MinLength=1
+	// +optional
+	KMSKeyID *string `json:"kmsKeyID,omitempty"`
+	// SnapshotID is the snapshot used to create the disk.
+	// +kubebuilder:validation:Pattern="^snap-[0-9a-z]+$"
+	// +optional
+	SnapshotID *string `json:"snapshotID,omitempty"`
+	// ThroughputPerformance is the extra throughput of the disk in MB/s.
+	// It is only supported by the disk type {CloudHSSD, CloudTSSD}.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	ThroughputPerformance *int32 `json:"throughputPerformance,omitempty"`
+	// ImageCacheID is the image cache used to create the disk, the disk is used to store the container images.
+	// More details, please check https://cloud.tencent.com/document/product/457/65908
+	// +kubebuilder:validation:Pattern="^imc-[0-9a-z]+$"
+	// +optional
+	ImageCacheID *string `json:"imageCacheID,omitempty"`
 }
 
 // +kubebuilder:validation:XValidation:message="bandwidthPackageID should be specified when chargeType is BandwidthPostpaidByHour",rule="has(self.chargeType) && self.chargeType == 'BandwidthPackage' ? has(self.bandwidthPackageID) : true"
+// +kubebuilder:validation:XValidation:message="addressPoolTags requires addressType to be EIP or HighQualityEIP",rule="has(self.addressPoolTags) ? has(self.addressType) && self.addressType != 'PublicIP' : true"
 type InternetAccessible struct {
 	// The maximum outbound bandwidth of the public network, in Mbps.
 	// Valid Range: Minimum value of 1. Maximum value of 100.
@@ -193,6 +423,18 @@ type InternetAccessible struct {
 	// +kubebuilder:validation:Pattern="bwp-[0-9a-z]+"
 	// +optional
 	BandwidthPackageID *string `json:"bandwidthPackageID,omitempty"`
+	// AddressType is the type of the public IP of the instance.
+	// PublicIP: a public IP released together with the instance. EIP, HighQualityEIP: an elastic IP.
+	// If not specified, PublicIP is used for the spot instances and the platform default for the others.
+	// +optional
+	AddressType *AddressType `json:"addressType,omitempty"`
+	// AddressPoolTags selects a pool of pre-created EIPs by tags. Each node is associated with an unassociated EIP
+	// of the pool, and the EIP returns to the pool when the node is garbage collected. The nodes can't be launched
+	// while all the EIPs of the pool are associated.
+	// +kubebuilder:validation:XValidation:message="empty tag keys or values aren't supported",rule="self.all(k, k != '' && self[k] != '')"
+	// +kubebuilder:validation:MaxProperties:=9
+	// +optional
+	AddressPoolTags map[string]string `json:"addressPoolTags,omitempty" hash:"ignore"`
 }
 
 type LifecycleScript struct {
@@ -202,6 +444,127 @@ type LifecycleScript struct {
 	// PostInitScript will be executed after node initialization.
 	// +optional
 	PostInitScript *string `json:"postInitScript,omitempty"`
+	// PreInitScripts are script fragments appended in order to PreInitScript.
+	// +kubebuilder:validation:MaxItems:=10
+	// +optional
+	PreInitScripts []ScriptSource `json:"preInitScripts,omitempty"`
+	// PostInitScripts are script fragments appended in order to PostInitScript.
+	// +kubebuilder:validation:MaxItems:=10
+	// +optional
+	PostInitScripts []ScriptSource `json:"postInitScripts,omitempty"`
+}
+
+// ScriptSource is a script fragment defined inline or read from a key of a Secret or ConfigMap in the namespace
+// of karpenter, so that the credentials used by the scripts aren't exposed in the TKEMachineNodeClass.
+// Only the Secrets and ConfigMaps labeled with karpenter.k8s.tke/lifecycle-script=true are read, and never the
+// Secret of the credentials of karpenter. The rendered scripts are stored in plaintext in the Machine and in the
+// userdata of the instance, so they are readable by anyone who can read the Machines.
+// The fragment is rendered as a Go template when the node is launched with the variables {{ .ClusterID }},
+// {{ .NodePool }}, {{ .NodeClaim }}, {{ .Zone }}, {{ .InstanceType }} and {{ .CapacityType }}.
+// Changes to the content of the Secret or ConfigMap only apply to the nodes launched afterwards.
+// +kubebuilder:validation:XValidation:message="exactly one of inline, secretKeyRef and configMapKeyRef must be specified",rule="[has(self.inline), has(self.secretKeyRef), has(self.configMapKeyRef)].filter(x, x).size() == 1"
+type ScriptSource struct {
+	// Inline is the content of the fragment.
+	// +optional
+	Inline *string `json:"inline,omitempty"`
+	// SecretKeyRef selects a key of a Secret as the content of the fragment.
+	// +optional
+	SecretKeyRef *ScriptKeySelector `json:"secretKeyRef,omitempty"`
+	// ConfigMapKeyRef selects a key of a ConfigMap as the content of the fragment.
+	// +optional
+	ConfigMapKeyRef *ScriptKeySelector `json:"configMapKeyRef,omitempty"`
+}
+
+type ScriptKeySelector struct {
+	// Name of the Secret or ConfigMap in the namespace of karpenter, labeled with karpenter.k8s.tke/lifecycle-script=true.
+	// +kubebuilder:validation:MinLength:=1
+	// +required
+	Name string `json:"name"`
+	// Key of the Secret or ConfigMap.
+	// +kubebuilder:validation:MinLength:=1
+	// +required
+	Key string `json:"key"`
+}
+
+// KubeletConfiguration defines args to be used when configuring kubelet on provisioned nodes.
+// They are a subset of the upstream types, recognizing not all options may be supported.
+// Wherever possible, the types and names should reflect the upstream kubelet types.
+type KubeletConfiguration struct {
+	// MaxPods is an override for the maximum number of pods that can run on
+	// a worker node instance.
+	// +kubebuilder:validation:Minimum:=0
+	// +optional
+	MaxPods *int32 `json:"maxPods,omitempty"`
+	// PodsPerCore is an override for the number of pods that can run on a worker node
+	// instance based on the number of cpu cores. This value cannot exceed MaxPods, so, if
+	// MaxPods is a lower value, that value will be used.
+	// +kubebuilder:validation:Minimum:=0
+	// +optional
+	PodsPerCore *int32 `json:"podsPerCore,omitempty"`
+	// SystemReserved contains resources reserved for OS system daemons and kernel memory.
+	// +kubebuilder:validation:XValidation:message="valid keys for systemReserved are ['cpu','memory','ephemeral-storage']",rule="self.all(x, x=='cpu' || x=='memory' || x=='ephemeral-storage')"
+	// +kubebuilder:validation:XValidation:message="systemReserved value should be a non-negative resource quantity",rule="self.all(x, self[x].matches('^[0-9]+([.][0-9]+)?(m|k|Ki|M|Mi|G|Gi|T|Ti|P|Pi|E|Ei)?$'))"
+	// +optional
+	SystemReserved map[string]string `json:"systemReserved,omitempty"`
+	// KubeReserved contains resources reserved for Kubernetes system components.
+	// +kubebuilder:validation:XValidation:message="valid keys for kubeReserved are ['cpu','memory','ephemeral-storage']",rule="self.all(x, x=='cpu' || x=='memory' || x=='ephemeral-storage')"
+	// +kubebuilder:validation:XValidation:message="kubeReserved value should be a non-negative resource quantity",rule="self.all(x, self[x].matches('^[0-9]+([.][0-9]+)?(m|k|Ki|M|Mi|G|Gi|T|Ti|P|Pi|E|Ei)?$'))"
+	// +optional
+	KubeReserved map[string]string `json:"kubeReserved,omitempty"`
+	// EvictionHard is the map of signal names to quantities that define hard eviction thresholds
+	// +kubebuilder:validation:XValidation:message="valid keys for evictionHard are ['memory.available','nodefs.available','nodefs.inodesFree','imagefs.available','imagefs.inodesFree','pid.available']",rule="self.all(x, x in ['memory.available','nodefs.available','nodefs.inodesFree','imagefs.available','imagefs.inodesFree','pid.available'])"
+	// +kubebuilder:validation:XValidation:message="evictionHard value should be a percentage or a resource quantity",rule="self.all(x, self[x].matches('^(([0-9]+([.][0-9]+)?%)|([0-9]+([.][0-9]+)?(m|k|Ki|M|Mi|G|Gi|T|Ti|P|Pi|E|Ei)?))$'))"
+	// +optional
+	EvictionHard map[string]string `json:"evictionHard,omitempty"`
+}
+
+type ManagementConfig struct {
+	// KubeletArgs are extra arguments passed to kubelet, keyed by flag name without the leading dashes,
+	// e.g. {"max-pods": "200"}.
+	// +kubebuilder:validation:XValidation:message="empty kubelet arg keys or values aren't supported",rule="self.all(k, k != '' && self[k] != '')"
+	// +kubebuilder:validation:XValidation:message="kubelet arg keys should not start with '-'",rule="self.all(k, !k.startsWith('-'))"
+	// +kubebuilder:validation:XValidation:message="register-with-taints is managed by karpenter",rule="!('register-with-taints' in self)"
+	// +optional
+	KubeletArgs map[string]string `json:"kubeletArgs,omitempty"`
+	// KernelArgs are kernel parameters set on the node, keyed by sysctl name,
+	// e.g. {"net.ipv4.ip_forward": "1"}.
+	// +kubebuilder:validation:XValidation:message="empty kernel arg keys or values aren't supported",rule="self.all(k, k != '' && self[k] != '')"
+	// +optional
+	KernelArgs map[string]string `json:"kernelArgs,omitempty"`
+	// Hosts are entries appended to the /etc/hosts file of the node.
+	// +kubebuilder:validation:XValidation:message="duplicate ip in hosts",rule="self.all(x, self.exists_one(y, y.ip == x.ip))"
+	// +kubebuilder:validation:MaxItems:=50
+	// +optional
+	Hosts []HostAlias `json:"hosts,omitempty"`
+	// Nameservers are the DNS servers written into the /etc/resolv.conf file of the node.
+	// +kubebuilder:validation:XValidation:message="nameservers should be unique",rule="self.all(x, self.exists_one(y, y == x))"
+	// +kubebuilder:validation:MaxItems:=3
+	// +optional
+	Nameservers []Nameserver `json:"nameservers,omitempty"`
+	// HostName is the hostname pattern of the node.
+	// +kubebuilder:validation:MinLength:=1
+	// +kubebuilder:validation:MaxLength:=60
+	// +optional
+	HostName *string `json:"hostName,omitempty"`
+	// RuntimeRootDir is the root directory of the container runtime, e.g. /var/lib/containerd.
+	// +kubebuilder:validation:XValidation:message="runtimeRootDir should be an absolute path",rule="self.startsWith('/')"
+	// +optional
+	RuntimeRootDir *string `json:"runtimeRootDir,omitempty"`
+}
+
+// +kubebuilder:validation:Pattern:="^[0-9a-fA-F.:]+$"
+type Nameserver string
+
+type HostAlias struct {
+	// IP address of the host file entry.
+	// +kubebuilder:validation:Pattern:="^[0-9a-fA-F.:]+$"
+	// +required
+	IP string `json:"ip"`
+	// Hostnames for the above IP address.
+	// +kubebuilder:validation:XValidation:message="empty hostnames aren't supported",rule="self.all(x, x != '')"
+	// +kubebuilder:validation:MinItems:=1
+	// +required
+	Hostnames []string `json:"hostnames"`
 }
 
 // TKEMachineNodeClass is the Schema for the TKEMachineNodeClass API
@@ -218,6 +581,22 @@ type TKEMachineNodeClass struct {
 	Status TKEMachineNodeClassStatus `json:"status,omitempty"`
 }
 
+// We need to bump the TKEMachineNodeClassHashVersion when we make an update to the TKEMachineNodeClass CRD under these conditions:
+// 1. A field changes its default value for an existing field that is already hashed
+// 2. A field is added to the hash calculation with an already-set value
+// 3. A field is removed from the hash calculations
+const TKEMachineNodeClassHashVersion = "v1"
+
+// Hash returns a static hash of the TKEMachineNodeClass spec. Fields tagged with `hash:"ignore"`
+// are resolved dynamically into the status and are checked for drift separately. The slices are hashed in order,
+// since the order of the data disks and of the script fragments decides how the nodes are set up.
+func (in *TKEMachineNodeClass) Hash() string {
+	return fmt.Sprint(lo.Must(hashstructure.Hash(in.Spec, hashstructure.FormatV2, &hashstructure.HashOptions{
+		IgnoreZeroValue: true,
+		ZeroNil:         true,
+	})))
+}
+
 // TKEMachineNodeClassList contains a list of TKEMachineNodeClasses
 // +kubebuilder:object:root=true
 type TKEMachineNodeClassList struct {
@@ -31,6 +31,12 @@ type Subnet struct {
 	// The associated availability zone ID
 	// +optional
 	ZoneID string `json:"zoneID,omitempty"`
+	// The IPv6 CIDR of the subnet, only the subnets with an IPv6 CIDR are utilized in a dual-stack cluster
+	// +optional
+	IPv6CIDR string `json:"ipv6CIDR,omitempty"`
+	// AvailableIPAddressCount is the number of unused IPs of the subnet when it was resolved
+	// +optional
+	AvailableIPAddressCount *int64 `json:"availableIPAddressCount,omitempty"`
 }
 
 // SecurityGroup contains resolved SecurityGroup selector values utilized for node launch
@@ -47,6 +53,42 @@ type SSHKey struct {
 	ID string `json:"id"`
 }
 
+// Image contains resolved image selector values utilized for node launch
+type Image struct {
+	// ID of the image
+	// +required
+	ID string `json:"id"`
+	// Name of the image
+	// +optional
+	Name string `json:"name,omitempty"`
+	// Architecture of the image, amd64 or arm64
+	// +required
+	Architecture string `json:"architecture"`
+}
+
+// HPCCluster contains resolved HPC cluster selector values utilized for node launch
+type HPCCluster struct {
+	// ID of the HPC cluster
+	// +required
+	ID string `json:"id"`
+	// The associated availability zone
+	// +required
+	Zone string `json:"zone"`
+	// The associated availability zone ID
+	// +optional
+	ZoneID string `json:"zoneID,omitempty"`
+}
+
+// Address contains an unassociated EIP of the address pool utilized for node launch
+type Address struct {
+	// ID of the EIP
+	// +required
+	ID string `json:"id"`
+	// IP address of the EIP
+	// +optional
+	IP string `json:"ip,omitempty"`
+}
+
 // TKEMachineNodeClassStatus contains the resolved state of the TKEMachineNodeClass
 type TKEMachineNodeClassStatus struct {
 	// Subnets contains the current Subnet values that are available to the
@@ -54,13 +96,26 @@ type TKEMachineNodeClassStatus struct {
 	// +optional
 	Subnets []Subnet `json:"subnets,omitempty"`
 	// SecurityGroups contains the current Security Groups values that are available to the
-	// cluster under the SecurityGroups selectors.
+	// cluster under the SecurityGroups selectors, in the order they are applied on the instances.
+	// At most 5 security groups are kept, the SecurityGroupsWithinLimit condition tells the ignored ones.
 	// +optional
 	SecurityGroups []SecurityGroup `json:"securityGroups,omitempty"`
 	// SSHKeys contains the current SSH Key values that are available to the
 	// cluster under the SSH Keys selectors.
 	// +optional
 	SSHKeys []SSHKey `json:"sshKeys,omitempty"`
+	// Images contains the newest image of each architecture that is available to the
+	// cluster under the image selectors.
+	// +optional
+	Images []Image `json:"images,omitempty"`
+	// HPCClusters contains the current HPC cluster values that are available to the
+	// cluster under the HPC cluster selectors.
+	// +optional
+	HPCClusters []HPCCluster `json:"hpcClusters,omitempty"`
+	// Addresses contains the unassociated EIPs of the address pool selected by
+	// internetAccessible.addressPoolTags.
+	// +optional
+	Addresses []Address `json:"addresses,omitempty"`
 	// Conditions contains signals for health and readiness
 	// +optional
 	Conditions []op.Condition `json:"conditions,omitempty"`
@@ -69,10 +124,44 @@ type TKEMachineNodeClassStatus struct {
 const (
 	// 	ConditionTypeNodeClassReady = "Ready" condition indicates that subnets, security groups, AMIs and instance profile for nodeClass were resolved
 	ConditionTypeNodeClassReady = "Ready"
+	// ConditionTypeSubnetsReady = "SubnetsReady" condition indicates that the subnet selector terms resolved to at least one subnet
+	ConditionTypeSubnetsReady = "SubnetsReady"
+	// ConditionTypeSecurityGroupsReady = "SecurityGroupsReady" condition indicates that the security group selector terms resolved to at least one security group
+	ConditionTypeSecurityGroupsReady = "SecurityGroupsReady"
+	// ConditionTypeSSHKeysReady = "SSHKeysReady" condition indicates that the ssh key selector terms resolved to at least one ssh key,
+	// or that no ssh key selector term is given
+	ConditionTypeSSHKeysReady = "SSHKeysReady"
+	// ConditionTypeValidationSucceeded = "ValidationSucceeded" condition indicates that the launch parameters of the nodeClass are valid
+	ConditionTypeValidationSucceeded = "ValidationSucceeded"
+	// ConditionTypeSecurityGroupsWithinLimit = "SecurityGroupsWithinLimit" condition indicates that the security group selector terms
+	// resolved no more security groups than an instance can be bound, it doesn't affect the readiness of the nodeClass
+	ConditionTypeSecurityGroupsWithinLimit = "SecurityGroupsWithinLimit"
+)
+
+// Reasons of the NodeClass status conditions
+const (
+	ConditionReasonSubnetsNotFound             = "SubnetsNotFound"
+	ConditionReasonSubnetsResolveFailed        = "SubnetsResolveFailed"
+	ConditionReasonSecurityGroupsNotFound      = "SecurityGroupsNotFound"
+	ConditionReasonSecurityGroupsResolveFailed = "SecurityGroupsResolveFailed"
+	ConditionReasonSecurityGroupsLimitExceeded = "SecurityGroupsLimitExceeded"
+	ConditionReasonSSHKeysNotFound             = "SSHKeysNotFound"
+	ConditionReasonSSHKeysResolveFailed        = "SSHKeysResolveFailed"
+	ConditionReasonImagesNotFound              = "ImagesNotFound"
+	ConditionReasonHPCClustersNotFound         = "HPCClustersNotFound"
+	ConditionReasonLifecycleScriptInvalid      = "LifecycleScriptInvalid"
+	ConditionReasonDiskTypesUnsupported        = "DiskTypesUnsupported"
+	ConditionReasonKMSKeysInvalid              = "KMSKeysInvalid"
+	ConditionReasonBandwidthPackageNotFound    = "BandwidthPackageNotFound"
 )
 
 func (in *TKEMachineNodeClass) StatusConditions() op.ConditionSet {
-	return op.NewReadyConditions(ConditionTypeNodeClassReady).For(in)
+	return op.NewReadyConditions(
+		ConditionTypeSubnetsReady,
+		ConditionTypeSecurityGroupsReady,
+		ConditionTypeSSHKeysReady,
+		ConditionTypeValidationSucceeded,
+	).For(in)
 }
 
 func (in *TKEMachineNodeClass) GetConditions() []op.Condition {
@@ -0,0 +1,105 @@
+/*
+Copyright (C) 2012-2025 Tencent. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"testing"
+
+	"github.com/samber/lo"
+)
+
+func TestHash_Stable(t *testing.T) {
+	nc := &TKEMachineNodeClass{
+		Spec: TKEMachineNodeClassSpec{
+			SystemDisk: &SystemDisk{Size: 50, Type: DiskTypeCloudPremium},
+			Tags:       map[string]string{"env": "test"},
+		},
+	}
+	if nc.Hash() != nc.DeepCopy().Hash() {
+		t.Error("expected hash of identical specs to match")
+	}
+}
+
+func TestHash_IgnoresSelectorTerms(t *testing.T) {
+	nc := &TKEMachineNodeClass{
+		Spec: TKEMachineNodeClassSpec{
+			SubnetSelectorTerms:        []SubnetSelectorTerm{{ID: "subnet-1"}},
+			SecurityGroupSelectorTerms: []SecurityGroupSelectorTerm{{ID: "sg-1"}},
+			SSHKeySelectorTerms:        []SSHKeySelectorTerm{{ID: "skey-1"}},
+		},
+	}
+	updated := nc.DeepCopy()
+	updated.Spec.SubnetSelectorTerms = []SubnetSelectorTerm{{Tags: map[string]string{"k": "v"}}}
+	updated.Spec.SecurityGroupSelectorTerms = []SecurityGroupSelectorTerm{{ID: "sg-2"}}
+	updated.Spec.SSHKeySelectorTerms = nil
+	if nc.Hash() != updated.Hash() {
+		t.Error("expected selector term changes not to affect the hash")
+	}
+}
+
+func TestHash_ChangesWithStaticFields(t *testing.T) {
+	nc := &TKEMachineNodeClass{
+		Spec: TKEMachineNodeClassSpec{
+			SystemDisk: &SystemDisk{Size: 50, Type: DiskTypeCloudPremium},
+		},
+	}
+	for name, mutate := range map[string]func(*TKEMachineNodeClass){
+		"systemDisk": func(n *TKEMachineNodeClass) { n.Spec.SystemDisk.Size = 100 },
+		"dataDisks":  func(n *TKEMachineNodeClass) { n.Spec.DataDisks = []DataDisk{{Size: 100}} },
+		"lifecycleScript": func(n *TKEMachineNodeClass) {
+			n.Spec.LifecycleScript = &LifecycleScript{PreInitScript: lo.ToPtr("echo hello")}
+		},
+		"internetAccessible": func(n *TKEMachineNodeClass) {
+			n.Spec.InternetAccessible = &InternetAccessible{MaxBandwidthOut: lo.ToPtr(int32(10))}
+		},
+	} {
+		updated := nc.DeepCopy()
+		mutate(updated)
+		if nc.Hash() == updated.Hash() {
+			t.Errorf("expected %s change to affect the hash", name)
+		}
+	}
+}
+
+func TestHash_ChangesWithSliceOrder(t *testing.T) {
+	nc := &TKEMachineNodeClass{
+		Spec: TKEMachineNodeClassSpec{
+			DataDisks: []DataDisk{
+				{Size: 100, MountTarget: lo.ToPtr("/var/lib/container")},
+				{Size: 200, MountTarget: lo.ToPtr("/data")},
+			},
+			LifecycleScript: &LifecycleScript{
+				PreInitScripts: []ScriptSource{{Inline: lo.ToPtr("echo first")}, {Inline: lo.ToPtr("echo second")}},
+			},
+		},
+	}
+	for name, mutate := range map[string]func(*TKEMachineNodeClass){
+		"dataDisks": func(n *TKEMachineNodeClass) {
+			n.Spec.DataDisks[0], n.Spec.DataDisks[1] = n.Spec.DataDisks[1], n.Spec.DataDisks[0]
+		},
+		"preInitScripts": func(n *TKEMachineNodeClass) {
+			scripts := n.Spec.LifecycleScript.PreInitScripts
+			scripts[0], scripts[1] = scripts[1], scripts[0]
+		},
+	} {
+		updated := nc.DeepCopy()
+		mutate(updated)
+		if nc.Hash() == updated.Hash() {
+			t.Errorf("expected reordering %s to affect the hash", name)
+		}
+	}
+}
@@ -9,6 +9,21 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Address) DeepCopyInto(out *Address) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Address.
+func (in *Address) DeepCopy() *Address {
+	if in == nil {
+		return nil
+	}
+	out := new(Address)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *DataDisk) DeepCopyInto(out *DataDisk) {
 	*out = *in
@@ -22,6 +37,31 @@ func (in *DataDisk) DeepCopyInto(out *DataDisk) {
 		*out = new(FileSystem)
 		**out = **in
 	}
+	if in.Encrypted != nil {
+		in, out := &in.Encrypted, &out.Encrypted
+		*out = new(bool)
+		**out = **in
+	}
+	if in.KMSKeyID != nil {
+		in, out := &in.KMSKeyID, &out.KMSKeyID
+		*out = new(string)
+		**out = **in
+	}
+	if in.SnapshotID != nil {
+		in, out := &in.SnapshotID, &out.SnapshotID
+		*out = new(string)
+		**out = **in
+	}
+	if in.ThroughputPerformance != nil {
+		in, out := &in.ThroughputPerformance, &out.ThroughputPerformance
+		*out = new(int32)
+		**out = **in
+	}
+	if in.ImageCacheID != nil {
+		in, out := &in.ImageCacheID, &out.ImageCacheID
+		*out = new(string)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DataDisk.
@@ -34,6 +74,100 @@ func (in *DataDisk) DeepCopy() *DataDisk {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HPCCluster) DeepCopyInto(out *HPCCluster) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HPCCluster.
+func (in *HPCCluster) DeepCopy() *HPCCluster {
+	if in == nil {
+		return nil
+	}
+	out := new(HPCCluster)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HPCClusterSelectorTerm) DeepCopyInto(out *HPCClusterSelectorTerm) {
+	*out = *in
+	if in.Tags != nil {
+		in, out := &in.Tags, &out.Tags
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HPCClusterSelectorTerm.
+func (in *HPCClusterSelectorTerm) DeepCopy() *HPCClusterSelectorTerm {
+	if in == nil {
+		return nil
+	}
+	out := new(HPCClusterSelectorTerm)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HostAlias) DeepCopyInto(out *HostAlias) {
+	*out = *in
+	if in.Hostnames != nil {
+		in, out := &in.Hostnames, &out.Hostnames
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HostAlias.
+func (in *HostAlias) DeepCopy() *HostAlias {
+	if in == nil {
+		return nil
+	}
+	out := new(HostAlias)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Image) DeepCopyInto(out *Image) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Image.
+func (in *Image) DeepCopy() *Image {
+	if in == nil {
+		return nil
+	}
+	out := new(Image)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ImageSelectorTerm) DeepCopyInto(out *ImageSelectorTerm) {
+	*out = *in
+	if in.Tags != nil {
+		in, out := &in.Tags, &out.Tags
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ImageSelectorTerm.
+func (in *ImageSelectorTerm) DeepCopy() *ImageSelectorTerm {
+	if in == nil {
+		return nil
+	}
+	out := new(ImageSelectorTerm)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *InternetAccessible) DeepCopyInto(out *InternetAccessible) {
 	*out = *in
@@ -52,6 +186,18 @@ func (in *InternetAccessible) DeepCopyInto(out *InternetAccessible) {
 		*out = new(string)
 		**out = **in
 	}
+	if in.AddressType != nil {
+		in, out := &in.AddressType, &out.AddressType
+		*out = new(AddressType)
+		**out = **in
+	}
+	if in.AddressPoolTags != nil {
+		in, out := &in.AddressPoolTags, &out.AddressPoolTags
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InternetAccessible.
@@ -64,6 +210,52 @@ func (in *InternetAccessible) DeepCopy() *InternetAccessible {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KubeletConfiguration) DeepCopyInto(out *KubeletConfiguration) {
+	*out = *in
+	if in.MaxPods != nil {
+		in, out := &in.MaxPods, &out.MaxPods
+		*out = new(int32)
+		**out = **in
+	}
+	if in.PodsPerCore != nil {
+		in, out := &in.PodsPerCore, &out.PodsPerCore
+		*out = new(int32)
+		**out = **in
+	}
+	if in.SystemReserved != nil {
+		in, out := &in.SystemReserved, &out.SystemReserved
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.KubeReserved != nil {
+		in, out := &in.KubeReserved, &out.KubeReserved
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.EvictionHard != nil {
+		in, out := &in.EvictionHard, &out.EvictionHard
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KubeletConfiguration.
+func (in *KubeletConfiguration) DeepCopy() *KubeletConfiguration {
+	if in == nil {
+		return nil
+	}
+	out := new(KubeletConfiguration)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *LifecycleScript) DeepCopyInto(out *LifecycleScript) {
 	*out = *in
@@ -77,6 +269,20 @@ func (in *LifecycleScript) DeepCopyInto(out *LifecycleScript) {
 		*out = new(string)
 		**out = **in
 	}
+	if in.PreInitScripts != nil {
+		in, out := &in.PreInitScripts, &out.PreInitScripts
+		*out = make([]ScriptSource, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.PostInitScripts != nil {
+		in, out := &in.PostInitScripts, &out.PostInitScripts
+		*out = make([]ScriptSource, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LifecycleScript.
@@ -89,6 +295,72 @@ func (in *LifecycleScript) DeepCopy() *LifecycleScript {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ManagementConfig) DeepCopyInto(out *ManagementConfig) {
+	*out = *in
+	if in.KubeletArgs != nil {
+		in, out := &in.KubeletArgs, &out.KubeletArgs
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.KernelArgs != nil {
+		in, out := &in.KernelArgs, &out.KernelArgs
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Hosts != nil {
+		in, out := &in.Hosts, &out.Hosts
+		*out = make([]HostAlias, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Nameservers != nil {
+		in, out := &in.Nameservers, &out.Nameservers
+		*out = make([]Nameserver, len(*in))
+		copy(*out, *in)
+	}
+	if in.HostName != nil {
+		in, out := &in.HostName, &out.HostName
+		*out = new(string)
+		**out = **in
+	}
+	if in.RuntimeRootDir != nil {
+		in, out := &in.RuntimeRootDir, &out.RuntimeRootDir
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ManagementConfig.
+func (in *ManagementConfig) DeepCopy() *ManagementConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(ManagementConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Prepaid) DeepCopyInto(out *Prepaid) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Prepaid.
+func (in *Prepaid) DeepCopy() *Prepaid {
+	if in == nil {
+		return nil
+	}
+	out := new(Prepaid)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SSHKey) DeepCopyInto(out *SSHKey) {
 	*out = *in
@@ -114,6 +386,13 @@ func (in *SSHKeySelectorTerm) DeepCopyInto(out *SSHKeySelectorTerm) {
 			(*out)[key] = val
 		}
 	}
+	if in.ExcludeTags != nil {
+		in, out := &in.ExcludeTags, &out.ExcludeTags
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SSHKeySelectorTerm.
@@ -126,6 +405,51 @@ func (in *SSHKeySelectorTerm) DeepCopy() *SSHKeySelectorTerm {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ScriptKeySelector) DeepCopyInto(out *ScriptKeySelector) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScriptKeySelector.
+func (in *ScriptKeySelector) DeepCopy() *ScriptKeySelector {
+	if in == nil {
+		return nil
+	}
+	out := new(ScriptKeySelector)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ScriptSource) DeepCopyInto(out *ScriptSource) {
+	*out = *in
+	if in.Inline != nil {
+		in, out := &in.Inline, &out.Inline
+		*out = new(string)
+		**out = **in
+	}
+	if in.SecretKeyRef != nil {
+		in, out := &in.SecretKeyRef, &out.SecretKeyRef
+		*out = new(ScriptKeySelector)
+		**out = **in
+	}
+	if in.ConfigMapKeyRef != nil {
+		in, out := &in.ConfigMapKeyRef, &out.ConfigMapKeyRef
+		*out = new(ScriptKeySelector)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScriptSource.
+func (in *ScriptSource) DeepCopy() *ScriptSource {
+	if in == nil {
+		return nil
+	}
+	out := new(ScriptSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SecurityGroup) DeepCopyInto(out *SecurityGroup) {
 	*out = *in
@@ -151,6 +475,18 @@ func (in *SecurityGroupSelectorTerm) DeepCopyInto(out *SecurityGroupSelectorTerm
 			(*out)[key] = val
 		}
 	}
+	if in.Priority != nil {
+		in, out := &in.Priority, &out.Priority
+		*out = new(int32)
+		**out = **in
+	}
+	if in.ExcludeTags != nil {
+		in, out := &in.ExcludeTags, &out.ExcludeTags
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SecurityGroupSelectorTerm.
@@ -166,6 +502,11 @@ func (in *SecurityGroupSelectorTerm) DeepCopy() *SecurityGroupSelectorTerm {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Subnet) DeepCopyInto(out *Subnet) {
 	*out = *in
+	if in.AvailableIPAddressCount != nil {
+		in, out := &in.AvailableIPAddressCount, &out.AvailableIPAddressCount
+		*out = new(int64)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Subnet.
@@ -188,6 +529,13 @@ func (in *SubnetSelectorTerm) DeepCopyInto(out *SubnetSelectorTerm) {
 			(*out)[key] = val
 		}
 	}
+	if in.ExcludeTags != nil {
+		in, out := &in.ExcludeTags, &out.ExcludeTags
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SubnetSelectorTerm.
@@ -203,6 +551,16 @@ func (in *SubnetSelectorTerm) DeepCopy() *SubnetSelectorTerm {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SystemDisk) DeepCopyInto(out *SystemDisk) {
 	*out = *in
+	if in.Encrypted != nil {
+		in, out := &in.Encrypted, &out.Encrypted
+		*out = new(bool)
+		**out = **in
+	}
+	if in.KMSKeyID != nil {
+		in, out := &in.KMSKeyID, &out.KMSKeyID
+		*out = new(string)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SystemDisk.
@@ -298,10 +656,24 @@ func (in *TKEMachineNodeClassSpec) DeepCopyInto(out *TKEMachineNodeClassSpec) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.ImageSelectorTerms != nil {
+		in, out := &in.ImageSelectorTerms, &out.ImageSelectorTerms
+		*out = make([]ImageSelectorTerm, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.HPCClusterSelectorTerms != nil {
+		in, out := &in.HPCClusterSelectorTerms, &out.HPCClusterSelectorTerms
+		*out = make([]HPCClusterSelectorTerm, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	if in.SystemDisk != nil {
 		in, out := &in.SystemDisk, &out.SystemDisk
 		*out = new(SystemDisk)
-		**out = **in
+		(*in).DeepCopyInto(*out)
 	}
 	if in.DataDisks != nil {
 		in, out := &in.DataDisks, &out.DataDisks
@@ -310,6 +682,16 @@ func (in *TKEMachineNodeClassSpec) DeepCopyInto(out *TKEMachineNodeClassSpec) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.InstanceStorePolicy != nil {
+		in, out := &in.InstanceStorePolicy, &out.InstanceStorePolicy
+		*out = new(InstanceStorePolicy)
+		**out = **in
+	}
+	if in.HostMaintenancePolicy != nil {
+		in, out := &in.HostMaintenancePolicy, &out.HostMaintenancePolicy
+		*out = new(HostMaintenancePolicy)
+		**out = **in
+	}
 	if in.InternetAccessible != nil {
 		in, out := &in.InternetAccessible, &out.InternetAccessible
 		*out = new(InternetAccessible)
@@ -320,6 +702,31 @@ func (in *TKEMachineNodeClassSpec) DeepCopyInto(out *TKEMachineNodeClassSpec) {
 		*out = new(LifecycleScript)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.Management != nil {
+		in, out := &in.Management, &out.Management
+		*out = new(ManagementConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Kubelet != nil {
+		in, out := &in.Kubelet, &out.Kubelet
+		*out = new(KubeletConfiguration)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.KubeletVersion != nil {
+		in, out := &in.KubeletVersion, &out.KubeletVersion
+		*out = new(string)
+		**out = **in
+	}
+	if in.RuntimeVersion != nil {
+		in, out := &in.RuntimeVersion, &out.RuntimeVersion
+		*out = new(string)
+		**out = **in
+	}
+	if in.Prepaid != nil {
+		in, out := &in.Prepaid, &out.Prepaid
+		*out = new(Prepaid)
+		**out = **in
+	}
 	if in.Tags != nil {
 		in, out := &in.Tags, &out.Tags
 		*out = make(map[string]string, len(*in))
@@ -345,7 +752,9 @@ func (in *TKEMachineNodeClassStatus) DeepCopyInto(out *TKEMachineNodeClassStatus
 	if in.Subnets != nil {
 		in, out := &in.Subnets, &out.Subnets
 		*out = make([]Subnet, len(*in))
-		copy(*out, *in)
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
 	}
 	if in.SecurityGroups != nil {
 		in, out := &in.SecurityGroups, &out.SecurityGroups
@@ -357,6 +766,21 @@ func (in *TKEMachineNodeClassStatus) DeepCopyInto(out *TKEMachineNodeClassStatus
 		*out = make([]SSHKey, len(*in))
 		copy(*out, *in)
 	}
+	if in.Images != nil {
+		in, out := &in.Images, &out.Images
+		*out = make([]Image, len(*in))
+		copy(*out, *in)
+	}
+	if in.HPCClusters != nil {
+		in, out := &in.HPCClusters, &out.HPCClusters
+		*out = make([]HPCCluster, len(*in))
+		copy(*out, *in)
+	}
+	if in.Addresses != nil {
+		in, out := &in.Addresses, &out.Addresses
+		*out = make([]Address, len(*in))
+		copy(*out, *in)
+	}
 	if in.Conditions != nil {
 		in, out := &in.Conditions, &out.Conditions
 		*out = make([]status.Condition, len(*in))
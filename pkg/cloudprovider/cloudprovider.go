@@ -44,6 +44,7 @@ import (
 	api "github.com/tencentcloud/karpenter-provider-tke/pkg/apis/v1beta1"
 	"github.com/tencentcloud/karpenter-provider-tke/pkg/providers/instancetype"
 	"github.com/tencentcloud/karpenter-provider-tke/pkg/providers/machine"
+	"github.com/tencentcloud/karpenter-provider-tke/pkg/providers/version"
 	"github.com/tencentcloud/karpenter-provider-tke/pkg/providers/zone"
 	"github.com/tencentcloud/karpenter-provider-tke/staging/nativenode/cxm"
 )
@@ -71,12 +72,13 @@ func init() {
 
 func NewCloudProvider(ctx context.Context,
 	kubeClient client.Client, machineProvider machine.Provider,
-	instanceTypeProvider instancetype.Provider, zoneProvider zone.Provider) *CloudProvider {
+	instanceTypeProvider instancetype.Provider, zoneProvider zone.Provider, versionProvider version.Provider) *CloudProvider {
 	return &CloudProvider{
 		kubeClient:           kubeClient,
 		machineProvider:      machineProvider,
 		instancetypeProvider: instanceTypeProvider,
 		zoneProvider:         zoneProvider,
+		versionProvider:      versionProvider,
 	}
 }
 
@@ -85,6 +87,7 @@ type CloudProvider struct {
 	machineProvider      machine.Provider
 	instancetypeProvider instancetype.Provider
 	zoneProvider         zone.Provider
+	versionProvider      version.Provider
 }
 
 func (c CloudProvider) Create(ctx context.Context, nodeClaim *v1.NodeClaim) (*v1.NodeClaim, error) {
@@ -92,10 +95,17 @@ func (c CloudProvider) Create(ctx context.Context, nodeClaim *v1.NodeClaim) (*v1
 	if err != nil {
 		return nil, cloudprovider.NewInsufficientCapacityError(fmt.Errorf("resolving node class, %w", err))
 	}
+	// the launch parameters rejected by the validation would only fail the machines, refuse them before launching
+	if validation := nodeClass.StatusConditions().Get(api.ConditionTypeValidationSucceeded); validation.IsFalse() {
+		return nil, cloudprovider.NewNodeClassNotReadyError(fmt.Errorf("validating tkemachinenodeclass, %s: %s", validation.Reason, validation.Message))
+	}
 	nodeClassReady := nodeClass.StatusConditions().Get("Ready")
 	if !nodeClassReady.IsTrue() {
 		return nil, fmt.Errorf("resolving tkemachinenodeclass, %s", nodeClassReady.Message)
 	}
+	if err := c.validateKubeletVersion(ctx, nodeClass); err != nil {
+		return nil, fmt.Errorf("validating kubelet version, %w", err)
+	}
 	instanceTypes, err := c.resolveInstanceTypes(ctx, nodeClaim, nodeClass)
 	if err != nil {
 		return nil, fmt.Errorf("resolving instance types, %w", err)
@@ -178,7 +188,22 @@ func (c *CloudProvider) RepairPolicies() []cloudprovider.RepairPolicy {
 }
 
 func (c CloudProvider) IsDrifted(ctx context.Context, nodeClaim *v1.NodeClaim) (cloudprovider.DriftReason, error) {
-	return "", nil
+	if nodeClaim.Spec.NodeClassRef == nil || nodeClaim.Status.ProviderID == "" {
+		return "", nil
+	}
+	nodeClass, err := c.resolveNodeClassFromNodeClaim(ctx, nodeClaim)
+	if err != nil {
+		return "", client.IgnoreNotFound(fmt.Errorf("resolving node class, %w", err))
+	}
+	machine, err := c.machineProvider.Get(ctx, nodeClaim.Status.ProviderID)
+	if err != nil {
+		return "", cloudprovider.IgnoreNodeClaimNotFoundError(fmt.Errorf("getting machine, %w", err))
+	}
+	driftReason, err := c.isNodeClassDrifted(ctx, machine, nodeClass)
+	if err != nil {
+		return "", err
+	}
+	return driftReason, nil
 }
 
 func (c CloudProvider) List(ctx context.Context) ([]*v1.NodeClaim, error) {
@@ -234,6 +259,12 @@ func (c CloudProvider) machineToNodeClaim(ctx context.Context, machine *capiv1be
 	if v, ok := machine.Annotations[api.AnnotationManagedBy]; ok {
 		annotations[api.AnnotationManagedBy] = v
 	}
+	if v, ok := machine.Annotations[api.AnnotationTKEMachineNodeClassHash]; ok {
+		annotations[api.AnnotationTKEMachineNodeClassHash] = v
+	}
+	if v, ok := machine.Annotations[api.AnnotationTKEMachineNodeClassHashVersion]; ok {
+		annotations[api.AnnotationTKEMachineNodeClassHashVersion] = v
+	}
 	annotations[api.AnnotationOwnedMachine] = machine.Name
 
 	nodeClaim.Status.ProviderID = lo.FromPtr(machine.Spec.ProviderID)
@@ -258,7 +289,14 @@ func (c CloudProvider) resolveMachineToInstanceType(ctx context.Context, machine
 	systemreserved := resourceListFromAnnotations(api.SystemReservedGroup, machine.GetAnnotations())
 	evictionthreshold := resourceListFromAnnotations(api.EvictionThresholdGroup, machine.GetAnnotations())
 
-	kubletVersion, err := semver.Make(lo.FromPtr(machine.Spec.KubeletVersion))
+	// the kubelet version is populated by the cloud vendor after the Machine is created
+	var kubeletVersion semver.Version
+	if machine.Spec.KubeletVersion != nil {
+		kubeletVersion, err = version.Parse(lo.FromPtr(machine.Spec.KubeletVersion))
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse machine %s kubelet version %v", machine.GetName(), err)
+		}
+	}
 	cxmInstanceType := cxm.InstanceTypeQuotaItem{}
 	cxmInstanceType.Zone = machine.Spec.Zone
 	cxmInstanceType.Arch = machine.GetLabels()[corev1.LabelArchStable]
@@ -276,7 +314,14 @@ func (c CloudProvider) resolveMachineToInstanceType(ctx context.Context, machine
 	if err != nil {
 		return nil, fmt.Errorf("unable to convert machine %s price %v", machine.GetName(), err)
 	}
-	// TODO gpu resource
+	if gpu, ok := machine.Annotations[api.CapacityGroup+api.AnnotationGPUCount]; ok {
+		gpuCount, err := resource.ParseQuantity(gpu)
+		if err != nil {
+			return nil, fmt.Errorf("unable to convert machine %s gpu count %v", machine.GetName(), err)
+		}
+		cxmInstanceType.Gpu = int(gpuCount.Value())
+		cxmInstanceType.Externals.GpuAttr.Type = machine.Annotations[api.CapacityGroup+api.AnnotationGPUType]
+	}
 
 	var offerings []*cloudprovider.Offering
 	zoneID, _ := c.zoneProvider.IDFromZone(cxmInstanceType.Zone)
@@ -290,10 +335,17 @@ func (c CloudProvider) resolveMachineToInstanceType(ctx context.Context, machine
 		Available: true,
 	}
 	offerings = append(offerings, offering)
-	instanceType := instancetype.NewInstanceType(ctx, "", 50, cxmInstanceType, kubletVersion,
+	instanceType := instancetype.NewInstanceType(ctx, "", 50, nil, cxmInstanceType, kubeletVersion,
 		nil, nil, nil, nil, nil,
 		offerings,
 		nil, nil)
+	// the labels derived from the instance type details which the machine doesn't keep
+	for _, key := range []string{api.LabelInstanceCategory, api.LabelInstanceGPUMemory} {
+		if v, ok := machine.GetLabels()[key]; ok {
+			instanceType.Requirements[key] = scheduling.NewRequirement(key, corev1.NodeSelectorOpIn, v)
+		}
+	}
+	instanceType.Requirements.Add(scheduling.NewRequirement(api.LabelRDMA, corev1.NodeSelectorOpIn, lo.Ternary(machine.GetLabels()[api.LabelRDMA] == "true", "true", "false")))
 	_, found := capacity[corev1.ResourceCPU]
 	if !found {
 		return nil, fmt.Errorf("unable to convert Machine %q to a NodeClaim, no cpu capacity found", machine.GetName())
@@ -422,3 +474,19 @@ func newTerminatingNodeClassError(name string) *errors.StatusError {
 	err.ErrStatus.Message = fmt.Sprintf("%s %q is terminating, treating as not found", qualifiedResource.String(), name)
 	return err
 }
+
+// validateKubeletVersion checks the pinned kubelet version of the TKEMachineNodeClass against the control plane.
+func (c CloudProvider) validateKubeletVersion(ctx context.Context, nodeClass *api.TKEMachineNodeClass) error {
+	if nodeClass.Spec.KubeletVersion == nil {
+		return nil
+	}
+	kubeletVersion, err := version.Parse(lo.FromPtr(nodeClass.Spec.KubeletVersion))
+	if err != nil {
+		return err
+	}
+	controlPlaneVersion, err := c.versionProvider.Get(ctx)
+	if err != nil {
+		return err
+	}
+	return version.ValidateKubeletVersion(kubeletVersion, controlPlaneVersion)
+}
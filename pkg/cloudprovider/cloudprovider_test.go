@@ -7,6 +7,8 @@ import (
 	"time"
 
 	"github.com/awslabs/operatorpkg/status"
+	"github.com/blang/semver/v4"
+	"github.com/samber/lo"
 	api "github.com/tencentcloud/karpenter-provider-tke/pkg/apis/v1beta1"
 	"github.com/tencentcloud/karpenter-provider-tke/pkg/operator/options"
 	capiv1beta1 "github.com/tencentcloud/karpenter-provider-tke/staging/nativenode/v1beta1"
@@ -72,7 +74,7 @@ func TestRepairPolicies(t *testing.T) {
 
 func TestIsDrifted(t *testing.T) {
 	cp := &CloudProvider{}
-	reason, err := cp.IsDrifted(context.Background(), nil)
+	reason, err := cp.IsDrifted(context.Background(), &v1.NodeClaim{})
 	if err != nil {
 		t.Errorf("expected no error, got %v", err)
 	}
@@ -145,9 +147,9 @@ func TestResourceListFromAnnotations_AllResources(t *testing.T) {
 
 func TestResourceListFromAnnotations_DifferentGroups(t *testing.T) {
 	annotations := map[string]string{
-		api.KubeReservedGroup + api.AnnotationCPU:    "100m",
-		api.SystemReservedGroup + api.AnnotationCPU:  "50m",
-		api.CapacityGroup + api.AnnotationCPU:        "4",
+		api.KubeReservedGroup + api.AnnotationCPU:   "100m",
+		api.SystemReservedGroup + api.AnnotationCPU: "50m",
+		api.CapacityGroup + api.AnnotationCPU:       "4",
 	}
 	// Only extract for KubeReservedGroup
 	result := resourceListFromAnnotations(api.KubeReservedGroup, annotations)
@@ -205,6 +207,8 @@ type mockMachineProvider struct {
 	ListFn   func(context.Context) ([]*capiv1beta1.Machine, error)
 	CreateFn func(context.Context, *api.TKEMachineNodeClass, *v1.NodeClaim, []*cloudprovider.InstanceType) (*capiv1beta1.Machine, *capiv1beta1.CXMMachineProviderSpec, error)
 	DeleteFn func(context.Context, *v1.NodeClaim) error
+
+	ValidateLifecycleScriptFn func(context.Context, *api.TKEMachineNodeClass) error
 }
 
 func (m *mockMachineProvider) Get(ctx context.Context, id string) (*capiv1beta1.Machine, error) {
@@ -235,12 +239,19 @@ func (m *mockMachineProvider) Delete(ctx context.Context, claim *v1.NodeClaim) e
 	return fmt.Errorf("Delete not implemented")
 }
 
+func (m *mockMachineProvider) ValidateLifecycleScript(ctx context.Context, nc *api.TKEMachineNodeClass) error {
+	if m.ValidateLifecycleScriptFn != nil {
+		return m.ValidateLifecycleScriptFn(ctx, nc)
+	}
+	return nil
+}
+
 // mockInstanceTypeProvider implements instancetype.Provider with configurable func fields.
 type mockInstanceTypeProvider struct {
-	ListFn                       func(context.Context, *api.TKEMachineNodeClass, bool) ([]*cloudprovider.InstanceType, error)
-	BlockInstanceTypeFn          func(ctx context.Context, instName, capacityType, zone, message string)
+	ListFn                        func(context.Context, *api.TKEMachineNodeClass, bool) ([]*cloudprovider.InstanceType, error)
+	BlockInstanceTypeFn           func(ctx context.Context, instName, capacityType, zone, message string)
 	GetInsufficientFailureCountFn func(ctx context.Context, instName, capacityType, zone string) int
-	AddInsufficientFailureFn     func(ctx context.Context, instName, capacityType, zone string)
+	AddInsufficientFailureFn      func(ctx context.Context, instName, capacityType, zone string)
 }
 
 func (m *mockInstanceTypeProvider) List(ctx context.Context, nc *api.TKEMachineNodeClass, refresh bool) ([]*cloudprovider.InstanceType, error) {
@@ -289,6 +300,18 @@ func (m *mockZoneProvider) IDFromZone(zone string) (string, error) {
 	return "", fmt.Errorf("IDFromZone not implemented")
 }
 
+// mockVersionProvider implements version.Provider with a configurable func field.
+type mockVersionProvider struct {
+	GetFn func(context.Context) (semver.Version, error)
+}
+
+func (m *mockVersionProvider) Get(ctx context.Context) (semver.Version, error) {
+	if m.GetFn != nil {
+		return m.GetFn(ctx)
+	}
+	return semver.MustParse("1.28.0"), nil
+}
+
 // cpFakeClient is a minimal client.Client implementation for testing.
 type cpFakeClient struct {
 	objects       map[string]client.Object
@@ -401,7 +424,7 @@ func (f *cpFakeClient) SubResource(subResource string) client.SubResourceClient
 	return &fakeSubResourceClient{}
 }
 func (f *cpFakeClient) Scheme() *runtime.Scheme     { return runtime.NewScheme() }
-func (f *cpFakeClient) RESTMapper() meta.RESTMapper  { return nil }
+func (f *cpFakeClient) RESTMapper() meta.RESTMapper { return nil }
 func (f *cpFakeClient) GroupVersionKindFor(obj runtime.Object) (schema.GroupVersionKind, error) {
 	return schema.GroupVersionKind{}, nil
 }
@@ -418,8 +441,8 @@ func testCtx() context.Context {
 	ctx := context.Background()
 	ctx = options.ToContext(ctx, &options.Options{
 		VMMemoryOverheadPercent: 0.075,
-		ClusterID:              "cls-test",
-		Region:                 "ap-guangzhou",
+		ClusterID:               "cls-test",
+		Region:                  "ap-guangzhou",
 	})
 	return ctx
 }
@@ -540,7 +563,8 @@ func TestNewCloudProvider(t *testing.T) {
 	mp := &mockMachineProvider{}
 	ip := &mockInstanceTypeProvider{}
 	zp := &mockZoneProvider{}
-	cp := NewCloudProvider(ctx, fc, mp, ip, zp)
+	vp := &mockVersionProvider{}
+	cp := NewCloudProvider(ctx, fc, mp, ip, zp, vp)
 	if cp == nil {
 		t.Fatal("expected non-nil CloudProvider")
 	}
@@ -556,6 +580,9 @@ func TestNewCloudProvider(t *testing.T) {
 	if cp.zoneProvider != zp {
 		t.Error("expected zoneProvider to match")
 	}
+	if cp.versionProvider != vp {
+		t.Error("expected versionProvider to match")
+	}
 }
 
 func TestCreate_NodeClassNotFound(t *testing.T) {
@@ -619,6 +646,37 @@ func TestCreate_NodeClassNotReady(t *testing.T) {
 	}
 }
 
+func TestCreate_NodeClassValidationFailed(t *testing.T) {
+	ctx := testCtx()
+	fc := newCPFakeClient()
+	nc := readyNodeClass("invalid-class")
+	nc.StatusConditions().SetFalse(api.ConditionTypeValidationSucceeded, api.ConditionReasonKMSKeysInvalid, "kms key key-1 is disabled")
+	fc.objects["invalid-class"] = nc
+
+	cp := &CloudProvider{
+		kubeClient:           fc,
+		machineProvider:      &mockMachineProvider{},
+		instancetypeProvider: &mockInstanceTypeProvider{},
+		zoneProvider:         &mockZoneProvider{},
+	}
+	nodeClaim := &v1.NodeClaim{
+		Spec: v1.NodeClaimSpec{
+			NodeClassRef: &v1.NodeClassReference{
+				Name:  "invalid-class",
+				Kind:  "TKEMachineNodeClass",
+				Group: api.Group,
+			},
+		},
+	}
+	_, err := cp.Create(ctx, nodeClaim)
+	if !cloudprovider.IsNodeClassNotReadyError(err) {
+		t.Fatalf("expected NodeClassNotReadyError, got %v", err)
+	}
+	if !containsStr(err.Error(), "KMSKeysInvalid: kms key key-1 is disabled") {
+		t.Errorf("expected the validation reason in the error, got %q", err.Error())
+	}
+}
+
 func TestCreate_ResolveInstanceTypesError(t *testing.T) {
 	ctx := testCtx()
 	fc := newCPFakeClient()
@@ -1340,3 +1398,132 @@ func TestResolveMachineToInstanceType_BadUnitPrice(t *testing.T) {
 		t.Fatal("expected error for bad unit price")
 	}
 }
+
+func TestResolveMachineToInstanceType_BadKubeletVersion(t *testing.T) {
+	ctx := testCtx()
+	mc := validMachine("bad-version", "qcloud:///100003/ins-bv", "ap-guangzhou-3")
+	mc.Spec.KubeletVersion = lo.ToPtr("not-a-version")
+
+	zp := &mockZoneProvider{
+		IDFromZoneFn: func(_ string) (string, error) { return "100003", nil },
+	}
+	cp := &CloudProvider{zoneProvider: zp}
+	_, err := cp.machineToNodeClaim(ctx, mc)
+	if err == nil {
+		t.Fatal("expected error for bad kubelet version")
+	}
+
+	// the kubelet version is not populated yet
+	mc.Spec.KubeletVersion = nil
+	if _, err := cp.machineToNodeClaim(ctx, mc); err != nil {
+		t.Fatalf("expected no error without kubelet version, got %v", err)
+	}
+}
+
+func TestCreate_KubeletVersionSkew(t *testing.T) {
+	ctx := testCtx()
+	fc := newCPFakeClient()
+	nc := readyNodeClass("skew-class")
+	nc.Spec.KubeletVersion = lo.ToPtr("1.29.0")
+	fc.objects["skew-class"] = nc
+
+	cp := &CloudProvider{
+		kubeClient:           fc,
+		machineProvider:      &mockMachineProvider{},
+		instancetypeProvider: &mockInstanceTypeProvider{},
+		zoneProvider:         &mockZoneProvider{},
+		versionProvider:      &mockVersionProvider{},
+	}
+	nodeClaim := &v1.NodeClaim{
+		Spec: v1.NodeClaimSpec{
+			NodeClassRef: &v1.NodeClassReference{
+				Name:  "skew-class",
+				Kind:  "TKEMachineNodeClass",
+				Group: api.Group,
+			},
+		},
+	}
+	_, err := cp.Create(ctx, nodeClaim)
+	if err == nil {
+		t.Fatal("expected error when the kubelet version is newer than the control plane")
+	}
+	if !containsStr(err.Error(), "validating kubelet version") {
+		t.Errorf("expected error message about validating kubelet version, got %q", err.Error())
+	}
+}
+
+func TestMachineToNodeClaim_GPU(t *testing.T) {
+	ctx := testCtx()
+	mc := validMachine("gpu-machine", "qcloud:///100003/ins-gpu", "ap-guangzhou-3")
+	mc.Labels[api.LabelInstanceFamily] = "GN7"
+	mc.Labels[api.LabelInstanceCategory] = api.InstanceCategoryGPU
+	mc.Labels[api.LabelInstanceGPUMemory] = "16384"
+	mc.Annotations[api.CapacityGroup+api.AnnotationGPUCount] = "1"
+	mc.Annotations[api.CapacityGroup+api.AnnotationGPUType] = "t4"
+
+	zp := &mockZoneProvider{
+		IDFromZoneFn: func(_ string) (string, error) { return "100003", nil },
+	}
+	cp := &CloudProvider{zoneProvider: zp}
+	nodeClaim, err := cp.machineToNodeClaim(ctx, mc)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if gpu := nodeClaim.Status.Capacity[corev1.ResourceName(api.ResourceNVIDIAGPU)]; gpu.Value() != 1 {
+		t.Errorf("expected 1 GPU capacity, got %s", gpu.String())
+	}
+	for key, want := range map[string]string{
+		api.LabelInstanceGPUCount:  "1",
+		api.LabelInstanceGPUName:   "t4",
+		api.LabelInstanceGPUMemory: "16384",
+		api.LabelInstanceCategory:  api.InstanceCategoryGPU,
+	} {
+		if got := nodeClaim.Labels[key]; got != want {
+			t.Errorf("expected label %s=%s, got %q", key, want, got)
+		}
+	}
+}
+
+func TestMachineToNodeClaim_NoGPU(t *testing.T) {
+	ctx := testCtx()
+	mc := validMachine("cpu-machine", "qcloud:///100003/ins-cpu", "ap-guangzhou-3")
+
+	zp := &mockZoneProvider{
+		IDFromZoneFn: func(_ string) (string, error) { return "100003", nil },
+	}
+	cp := &CloudProvider{zoneProvider: zp}
+	nodeClaim, err := cp.machineToNodeClaim(ctx, mc)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := nodeClaim.Status.Capacity[corev1.ResourceName(api.ResourceNVIDIAGPU)]; ok {
+		t.Error("expected no GPU capacity")
+	}
+	for _, key := range []string{api.LabelInstanceGPUCount, api.LabelInstanceGPUName, api.LabelInstanceGPUMemory} {
+		if _, ok := nodeClaim.Labels[key]; ok {
+			t.Errorf("expected label %s to be absent", key)
+		}
+	}
+}
+
+func TestMachineToNodeClaim_Prepaid(t *testing.T) {
+	ctx := testCtx()
+	zp := &mockZoneProvider{
+		IDFromZoneFn: func(_ string) (string, error) { return "100003", nil },
+	}
+	cp := &CloudProvider{zoneProvider: zp}
+
+	mc := validMachine("prepaid-machine", "qcloud:///100003/ins-prepaid", "ap-guangzhou-3")
+	mc.Labels[v1.CapacityTypeLabelKey] = api.CapacityTypePrepaid
+	nodeClaim, err := cp.machineToNodeClaim(ctx, mc)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if nodeClaim.Labels[v1.CapacityTypeLabelKey] != api.CapacityTypePrepaid {
+		t.Errorf("expected the prepaid capacity type, got %s", nodeClaim.Labels[v1.CapacityTypeLabelKey])
+	}
+	// the disruption protection is left to the prepaid controller
+	if _, ok := nodeClaim.Annotations[v1.DoNotDisruptAnnotationKey]; ok {
+		t.Error("expected no disruption protection on the nodeclaim")
+	}
+}
@@ -0,0 +1,185 @@
+/*
+Copyright (C) 2012-2025 Tencent. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudprovider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/samber/lo"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+
+	api "github.com/tencentcloud/karpenter-provider-tke/pkg/apis/v1beta1"
+	"github.com/tencentcloud/karpenter-provider-tke/pkg/providers/version"
+	capiv1beta1 "github.com/tencentcloud/karpenter-provider-tke/staging/nativenode/v1beta1"
+)
+
+const (
+	NodeClassDrift       cloudprovider.DriftReason = "NodeClassDrift"
+	SubnetDrift          cloudprovider.DriftReason = "SubnetDrift"
+	SecurityGroupDrift   cloudprovider.DriftReason = "SecurityGroupDrift"
+	SSHKeyDrift          cloudprovider.DriftReason = "SSHKeyDrift"
+	ImageDrift           cloudprovider.DriftReason = "ImageDrift"
+	HPCClusterDrift      cloudprovider.DriftReason = "HPCClusterDrift"
+	HostMaintenanceDrift cloudprovider.DriftReason = "HostMaintenanceDrift"
+	VersionDrift         cloudprovider.DriftReason = "VersionDrift"
+)
+
+// maintenanceStatusRepairing is the value of CXMMachineProviderStatus.MaintenanceStatus while the node is repaired,
+// it's the only one of the documented values (Initializing, InitFailed, Initialized, Upgrading, Repairing,
+// UpgradeFailed) that reports a maintenance of the node.
+const maintenanceStatusRepairing = "Repairing"
+
+func (c CloudProvider) isNodeClassDrifted(ctx context.Context, machine *capiv1beta1.Machine, nodeClass *api.TKEMachineNodeClass) (cloudprovider.DriftReason, error) {
+	if drifted := c.areStaticFieldsDrifted(machine, nodeClass); drifted != "" {
+		return drifted, nil
+	}
+	providerSpec, err := capiv1beta1.ProviderSpecFromRawExtension(machine.Spec.ProviderSpec.Value)
+	if err != nil {
+		return "", fmt.Errorf("unable to get ProviderSpec from Machine %q, %w", machine.GetName(), err)
+	}
+	if drifted := c.isSubnetDrifted(machine, nodeClass); drifted != "" {
+		return drifted, nil
+	}
+	if drifted := c.areSecurityGroupsDrifted(providerSpec, nodeClass); drifted != "" {
+		return drifted, nil
+	}
+	if drifted := c.areSSHKeysDrifted(providerSpec, nodeClass); drifted != "" {
+		return drifted, nil
+	}
+	if drifted := c.isImageDrifted(machine, nodeClass); drifted != "" {
+		return drifted, nil
+	}
+	if drifted := c.isHPCClusterDrifted(providerSpec, nodeClass); drifted != "" {
+		return drifted, nil
+	}
+	drifted, err := c.isHostMaintenanceDrifted(machine, nodeClass)
+	if err != nil || drifted != "" {
+		return drifted, err
+	}
+	return c.isVersionDrifted(ctx, machine, nodeClass)
+}
+
+// areStaticFieldsDrifted compares the hash stamped on the Machine at launch with the current hash of the
+// TKEMachineNodeClass. Machines launched with a different hash version are not considered drifted.
+func (c CloudProvider) areStaticFieldsDrifted(machine *capiv1beta1.Machine, nodeClass *api.TKEMachineNodeClass) cloudprovider.DriftReason {
+	machineHash, foundHash := machine.Annotations[api.AnnotationTKEMachineNodeClassHash]
+	machineHashVersion, foundHashVersion := machine.Annotations[api.AnnotationTKEMachineNodeClassHashVersion]
+	if !foundHash || !foundHashVersion {
+		return ""
+	}
+	if machineHashVersion != api.TKEMachineNodeClassHashVersion {
+		return ""
+	}
+	return lo.Ternary(machineHash != nodeClass.Hash(), NodeClassDrift, "")
+}
+
+func (c CloudProvider) isSubnetDrifted(machine *capiv1beta1.Machine, nodeClass *api.TKEMachineNodeClass) cloudprovider.DriftReason {
+	if machine.Spec.SubnetID == "" {
+		return ""
+	}
+	_, found := lo.Find(nodeClass.Status.Subnets, func(s api.Subnet) bool {
+		return s.ID == machine.Spec.SubnetID
+	})
+	return lo.Ternary(!found, SubnetDrift, "")
+}
+
+func (c CloudProvider) areSecurityGroupsDrifted(providerSpec *capiv1beta1.CXMMachineProviderSpec, nodeClass *api.TKEMachineNodeClass) cloudprovider.DriftReason {
+	securityGroupIDs := sets.New(lo.Map(nodeClass.Status.SecurityGroups, func(sg api.SecurityGroup, _ int) string { return sg.ID })...)
+	return lo.Ternary(!securityGroupIDs.HasAll(providerSpec.SecurityGroupIDs...), SecurityGroupDrift, "")
+}
+
+func (c CloudProvider) areSSHKeysDrifted(providerSpec *capiv1beta1.CXMMachineProviderSpec, nodeClass *api.TKEMachineNodeClass) cloudprovider.DriftReason {
+	sshKeyIDs := sets.New(lo.Map(nodeClass.Status.SSHKeys, func(k api.SSHKey, _ int) string { return k.ID })...)
+	return lo.Ternary(!sshKeyIDs.HasAll(providerSpec.KeyIDs...), SSHKeyDrift, "")
+}
+
+// isImageDrifted checks the image of the Machine against the newest resolved images. Machines launched with
+// the default image are drifted once images are selected, and the other way around.
+func (c CloudProvider) isImageDrifted(machine *capiv1beta1.Machine, nodeClass *api.TKEMachineNodeClass) cloudprovider.DriftReason {
+	imageID := machine.Annotations[capiv1beta1.BetaImageAnnotation]
+	if len(nodeClass.Spec.ImageSelectorTerms) == 0 {
+		return lo.Ternary(imageID != "", ImageDrift, "")
+	}
+	// images are not resolved yet
+	if len(nodeClass.Status.Images) == 0 {
+		return ""
+	}
+	_, found := lo.Find(nodeClass.Status.Images, func(i api.Image) bool {
+		return i.ID == imageID
+	})
+	return lo.Ternary(!found, ImageDrift, "")
+}
+
+// isHPCClusterDrifted checks the HPC cluster of the Machine against the resolved HPC clusters. Machines launched
+// without a HPC cluster are drifted once HPC clusters are selected, and the other way around.
+func (c CloudProvider) isHPCClusterDrifted(providerSpec *capiv1beta1.CXMMachineProviderSpec, nodeClass *api.TKEMachineNodeClass) cloudprovider.DriftReason {
+	if len(nodeClass.Spec.HPCClusterSelectorTerms) == 0 {
+		return lo.Ternary(providerSpec.HpcClusterId != "", HPCClusterDrift, "")
+	}
+	// hpc clusters are not resolved yet
+	if len(nodeClass.Status.HPCClusters) == 0 {
+		return ""
+	}
+	_, found := lo.Find(nodeClass.Status.HPCClusters, func(h api.HPCCluster) bool {
+		return h.ID == providerSpec.HpcClusterId
+	})
+	return lo.Ternary(!found, HPCClusterDrift, "")
+}
+
+// isHostMaintenanceDrifted replaces the Machines reported as repairing when the nodes are not kept through the
+// maintenance of their hosts.
+func (c CloudProvider) isHostMaintenanceDrifted(machine *capiv1beta1.Machine, nodeClass *api.TKEMachineNodeClass) (cloudprovider.DriftReason, error) {
+	if lo.FromPtr(nodeClass.Spec.HostMaintenancePolicy) != api.HostMaintenancePolicyTerminate {
+		return "", nil
+	}
+	providerStatus, err := capiv1beta1.ProviderStatusFromRawExtension(machine.Status.ProviderStatus)
+	if err != nil {
+		return "", fmt.Errorf("unable to get ProviderStatus from Machine %q, %w", machine.GetName(), err)
+	}
+	return lo.Ternary(lo.FromPtr(providerStatus.MaintenanceStatus) == maintenanceStatusRepairing, HostMaintenanceDrift, ""), nil
+}
+
+// isVersionDrifted checks the kubelet and runtime versions of the Machine against the pinned versions of the
+// TKEMachineNodeClass. If the kubelet version isn't pinned, the Machines are drifted once the minor version of the
+// control plane is upgraded. The versions not populated on the Machine yet are not considered drifted.
+func (c CloudProvider) isVersionDrifted(ctx context.Context, machine *capiv1beta1.Machine, nodeClass *api.TKEMachineNodeClass) (cloudprovider.DriftReason, error) {
+	if nodeClass.Spec.RuntimeVersion != nil && machine.Spec.RuntimeVersion != nil &&
+		lo.FromPtr(nodeClass.Spec.RuntimeVersion) != lo.FromPtr(machine.Spec.RuntimeVersion) {
+		return VersionDrift, nil
+	}
+	if machine.Spec.KubeletVersion == nil {
+		return "", nil
+	}
+	kubeletVersion, err := version.Parse(lo.FromPtr(machine.Spec.KubeletVersion))
+	if err != nil {
+		return "", fmt.Errorf("unable to parse Machine %q kubelet version, %w", machine.GetName(), err)
+	}
+	if nodeClass.Spec.KubeletVersion != nil {
+		pinned, err := version.Parse(lo.FromPtr(nodeClass.Spec.KubeletVersion))
+		if err != nil {
+			return "", fmt.Errorf("unable to parse kubelet version, %w", err)
+		}
+		return lo.Ternary(kubeletVersion.FinalizeVersion() != pinned.FinalizeVersion(), VersionDrift, ""), nil
+	}
+	controlPlaneVersion, err := c.versionProvider.Get(ctx)
+	if err != nil {
+		return "", err
+	}
+	return lo.Ternary(kubeletVersion.Major != controlPlaneVersion.Major || kubeletVersion.Minor != controlPlaneVersion.Minor, VersionDrift, ""), nil
+}
@@ -0,0 +1,262 @@
+/*
+Copyright (C) 2012-2025 Tencent. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudprovider
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/blang/semver/v4"
+	"github.com/samber/lo"
+	api "github.com/tencentcloud/karpenter-provider-tke/pkg/apis/v1beta1"
+	capiv1beta1 "github.com/tencentcloud/karpenter-provider-tke/staging/nativenode/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+)
+
+// driftFixture returns a CloudProvider backed by a ready node class and a Machine launched from it.
+func driftFixture(t *testing.T) (*CloudProvider, *cpFakeClient, *capiv1beta1.Machine, *v1.NodeClaim) {
+	t.Helper()
+	nc := readyNodeClass("drift-class")
+	mc := validMachine("drift-machine", "qcloud:///100003/ins-drift", "ap-guangzhou-3")
+	mc.Spec.SubnetID = "subnet-abc"
+	rawExt, err := capiv1beta1.RawExtensionFromProviderSpec(&capiv1beta1.CXMMachineProviderSpec{
+		InstanceType:     "S5.MEDIUM4",
+		SecurityGroupIDs: []string{"sg-001"},
+		KeyIDs:           []string{"skey-001"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	mc.Spec.ProviderSpec.Value = rawExt
+	mc.Annotations[api.AnnotationTKEMachineNodeClassHash] = nc.Hash()
+	mc.Annotations[api.AnnotationTKEMachineNodeClassHashVersion] = api.TKEMachineNodeClassHashVersion
+
+	fc := newCPFakeClient()
+	fc.objects[nc.Name] = nc
+	mp := &mockMachineProvider{
+		GetFn: func(_ context.Context, id string) (*capiv1beta1.Machine, error) {
+			if id != lo.FromPtr(mc.Spec.ProviderID) {
+				return nil, cloudprovider.NewNodeClaimNotFoundError(fmt.Errorf("machine with providerID %s not found", id))
+			}
+			return mc, nil
+		},
+	}
+	nodeClaim := &v1.NodeClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: "drift-claim"},
+		Spec: v1.NodeClaimSpec{
+			NodeClassRef: &v1.NodeClassReference{Name: nc.Name, Kind: "TKEMachineNodeClass", Group: api.Group},
+		},
+		Status: v1.NodeClaimStatus{ProviderID: lo.FromPtr(mc.Spec.ProviderID)},
+	}
+	return &CloudProvider{kubeClient: fc, machineProvider: mp, versionProvider: &mockVersionProvider{}}, fc, mc, nodeClaim
+}
+
+func expectDriftReason(t *testing.T, cp *CloudProvider, nodeClaim *v1.NodeClaim, expected cloudprovider.DriftReason) {
+	t.Helper()
+	reason, err := cp.IsDrifted(testCtx(), nodeClaim)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if reason != expected {
+		t.Errorf("expected drift reason %q, got %q", expected, reason)
+	}
+}
+
+func TestIsDrifted_NotDrifted(t *testing.T) {
+	cp, _, _, nodeClaim := driftFixture(t)
+	expectDriftReason(t, cp, nodeClaim, "")
+}
+
+func TestIsDrifted_StaticFieldsChanged(t *testing.T) {
+	cp, fc, _, nodeClaim := driftFixture(t)
+	nc := fc.objects["drift-class"].(*api.TKEMachineNodeClass)
+	nc.Spec.SystemDisk = &api.SystemDisk{Size: 100, Type: api.DiskTypeCloudSSD}
+	expectDriftReason(t, cp, nodeClaim, NodeClassDrift)
+}
+
+func TestIsDrifted_SelectorTermsIgnored(t *testing.T) {
+	cp, fc, _, nodeClaim := driftFixture(t)
+	nc := fc.objects["drift-class"].(*api.TKEMachineNodeClass)
+	nc.Spec.SubnetSelectorTerms = []api.SubnetSelectorTerm{{ID: "subnet-other"}}
+	nc.Spec.SecurityGroupSelectorTerms = []api.SecurityGroupSelectorTerm{{Tags: map[string]string{"k": "v"}}}
+	expectDriftReason(t, cp, nodeClaim, "")
+}
+
+func TestIsDrifted_HashVersionMismatch(t *testing.T) {
+	cp, fc, mc, nodeClaim := driftFixture(t)
+	mc.Annotations[api.AnnotationTKEMachineNodeClassHashVersion] = "v0"
+	nc := fc.objects["drift-class"].(*api.TKEMachineNodeClass)
+	nc.Spec.SystemDisk = &api.SystemDisk{Size: 100, Type: api.DiskTypeCloudSSD}
+	expectDriftReason(t, cp, nodeClaim, "")
+}
+
+func TestIsDrifted_MissingHashAnnotation(t *testing.T) {
+	cp, fc, mc, nodeClaim := driftFixture(t)
+	delete(mc.Annotations, api.AnnotationTKEMachineNodeClassHash)
+	nc := fc.objects["drift-class"].(*api.TKEMachineNodeClass)
+	nc.Spec.SystemDisk = &api.SystemDisk{Size: 100, Type: api.DiskTypeCloudSSD}
+	expectDriftReason(t, cp, nodeClaim, "")
+}
+
+func TestIsDrifted_SubnetRemoved(t *testing.T) {
+	cp, fc, _, nodeClaim := driftFixture(t)
+	nc := fc.objects["drift-class"].(*api.TKEMachineNodeClass)
+	nc.Status.Subnets = []api.Subnet{{ID: "subnet-new", Zone: "ap-guangzhou-3", ZoneID: "100003"}}
+	expectDriftReason(t, cp, nodeClaim, SubnetDrift)
+}
+
+func TestIsDrifted_SecurityGroupRemoved(t *testing.T) {
+	cp, fc, _, nodeClaim := driftFixture(t)
+	nc := fc.objects["drift-class"].(*api.TKEMachineNodeClass)
+	nc.Status.SecurityGroups = []api.SecurityGroup{{ID: "sg-002"}}
+	expectDriftReason(t, cp, nodeClaim, SecurityGroupDrift)
+}
+
+func TestIsDrifted_SSHKeyRemoved(t *testing.T) {
+	cp, fc, _, nodeClaim := driftFixture(t)
+	nc := fc.objects["drift-class"].(*api.TKEMachineNodeClass)
+	nc.Status.SSHKeys = []api.SSHKey{{ID: "skey-002"}}
+	expectDriftReason(t, cp, nodeClaim, SSHKeyDrift)
+}
+
+func TestIsDrifted_ImageChanged(t *testing.T) {
+	cp, fc, mc, nodeClaim := driftFixture(t)
+	nc := fc.objects["drift-class"].(*api.TKEMachineNodeClass)
+	nc.Spec.ImageSelectorTerms = []api.ImageSelectorTerm{{Name: "my-image"}}
+	nc.Status.Images = []api.Image{{ID: "img-new", Architecture: "amd64"}}
+	mc.Annotations[capiv1beta1.BetaImageAnnotation] = "img-old"
+	expectDriftReason(t, cp, nodeClaim, ImageDrift)
+
+	mc.Annotations[capiv1beta1.BetaImageAnnotation] = "img-new"
+	expectDriftReason(t, cp, nodeClaim, "")
+}
+
+func TestIsDrifted_ImageSelected(t *testing.T) {
+	cp, fc, _, nodeClaim := driftFixture(t)
+	nc := fc.objects["drift-class"].(*api.TKEMachineNodeClass)
+	nc.Spec.ImageSelectorTerms = []api.ImageSelectorTerm{{Name: "my-image"}}
+	expectDriftReason(t, cp, nodeClaim, "")
+
+	nc.Status.Images = []api.Image{{ID: "img-new", Architecture: "amd64"}}
+	expectDriftReason(t, cp, nodeClaim, ImageDrift)
+}
+
+func TestIsDrifted_ImageUnselected(t *testing.T) {
+	cp, _, mc, nodeClaim := driftFixture(t)
+	mc.Annotations[capiv1beta1.BetaImageAnnotation] = "img-old"
+	expectDriftReason(t, cp, nodeClaim, ImageDrift)
+}
+
+func TestIsDrifted_HPCClusterSelected(t *testing.T) {
+	cp, fc, _, nodeClaim := driftFixture(t)
+	nc := fc.objects["drift-class"].(*api.TKEMachineNodeClass)
+	nc.Spec.HPCClusterSelectorTerms = []api.HPCClusterSelectorTerm{{ID: "hpc-new"}}
+	expectDriftReason(t, cp, nodeClaim, "")
+
+	nc.Status.HPCClusters = []api.HPCCluster{{ID: "hpc-new", Zone: "ap-guangzhou-3"}}
+	expectDriftReason(t, cp, nodeClaim, HPCClusterDrift)
+}
+
+func TestIsDrifted_HPCClusterUnselected(t *testing.T) {
+	cp, _, mc, nodeClaim := driftFixture(t)
+	rawExt, err := capiv1beta1.RawExtensionFromProviderSpec(&capiv1beta1.CXMMachineProviderSpec{
+		InstanceType:     "S5.MEDIUM4",
+		SecurityGroupIDs: []string{"sg-001"},
+		KeyIDs:           []string{"skey-001"},
+		HpcClusterId:     "hpc-old",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	mc.Spec.ProviderSpec.Value = rawExt
+	expectDriftReason(t, cp, nodeClaim, HPCClusterDrift)
+}
+
+func TestIsDrifted_HostMaintenance(t *testing.T) {
+	cp, fc, mc, nodeClaim := driftFixture(t)
+	rawExt, err := capiv1beta1.RawExtensionFromProviderStatus(&capiv1beta1.CXMMachineProviderStatus{
+		MaintenanceStatus: lo.ToPtr(maintenanceStatusRepairing),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	mc.Status.ProviderStatus = rawExt
+	// the nodes are kept through the host maintenance by default
+	expectDriftReason(t, cp, nodeClaim, "")
+
+	nc := fc.objects["drift-class"].(*api.TKEMachineNodeClass)
+	nc.Spec.HostMaintenancePolicy = lo.ToPtr(api.HostMaintenancePolicyTerminate)
+	mc.Annotations[api.AnnotationTKEMachineNodeClassHash] = nc.Hash()
+	expectDriftReason(t, cp, nodeClaim, HostMaintenanceDrift)
+
+	rawExt, err = capiv1beta1.RawExtensionFromProviderStatus(&capiv1beta1.CXMMachineProviderStatus{
+		MaintenanceStatus: lo.ToPtr(capiv1beta1.MaintenanceStatusInitialized),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	mc.Status.ProviderStatus = rawExt
+	expectDriftReason(t, cp, nodeClaim, "")
+}
+
+func TestIsDrifted_ControlPlaneUpgraded(t *testing.T) {
+	cp, _, _, nodeClaim := driftFixture(t)
+	expectDriftReason(t, cp, nodeClaim, "")
+
+	cp.versionProvider = &mockVersionProvider{GetFn: func(context.Context) (semver.Version, error) {
+		return semver.MustParse("1.29.1"), nil
+	}}
+	expectDriftReason(t, cp, nodeClaim, VersionDrift)
+}
+
+func TestIsDrifted_PinnedVersions(t *testing.T) {
+	cp, fc, mc, nodeClaim := driftFixture(t)
+	// the pinned kubelet version takes precedence over the control plane version
+	cp.versionProvider = &mockVersionProvider{GetFn: func(context.Context) (semver.Version, error) {
+		return semver.MustParse("1.29.1"), nil
+	}}
+	nc := fc.objects["drift-class"].(*api.TKEMachineNodeClass)
+	nc.Spec.KubeletVersion = lo.ToPtr("1.28.0")
+	mc.Annotations[api.AnnotationTKEMachineNodeClassHash] = nc.Hash()
+	expectDriftReason(t, cp, nodeClaim, "")
+
+	nc.Spec.RuntimeVersion = lo.ToPtr("1.6.9")
+	mc.Annotations[api.AnnotationTKEMachineNodeClassHash] = nc.Hash()
+	// the runtime version isn't populated on the machine yet
+	expectDriftReason(t, cp, nodeClaim, "")
+	mc.Spec.RuntimeVersion = lo.ToPtr("1.6.4")
+	expectDriftReason(t, cp, nodeClaim, VersionDrift)
+
+	mc.Spec.RuntimeVersion = lo.ToPtr("1.6.9")
+	mc.Spec.KubeletVersion = lo.ToPtr("1.28.3")
+	expectDriftReason(t, cp, nodeClaim, VersionDrift)
+}
+
+func TestIsDrifted_NodeClassNotFound(t *testing.T) {
+	cp, fc, _, nodeClaim := driftFixture(t)
+	delete(fc.objects, "drift-class")
+	expectDriftReason(t, cp, nodeClaim, "")
+}
+
+func TestIsDrifted_MachineNotFound(t *testing.T) {
+	cp, _, _, nodeClaim := driftFixture(t)
+	nodeClaim.Status.ProviderID = "qcloud:///100003/ins-missing"
+	expectDriftReason(t, cp, nodeClaim, "")
+}
@@ -25,11 +25,20 @@ import (
 	"github.com/awslabs/operatorpkg/controller"
 	nodeclaimfailure "github.com/tencentcloud/karpenter-provider-tke/pkg/controllers/nodeclaim/failure"
 	nodeclaimgarbagecollection "github.com/tencentcloud/karpenter-provider-tke/pkg/controllers/nodeclaim/garbagecollection"
+	nodeclaimprepaid "github.com/tencentcloud/karpenter-provider-tke/pkg/controllers/nodeclaim/prepaid"
 	nodeclaimproviderid "github.com/tencentcloud/karpenter-provider-tke/pkg/controllers/nodeclaim/providerid"
+	nodeclaimtagging "github.com/tencentcloud/karpenter-provider-tke/pkg/controllers/nodeclaim/tagging"
 	nodeclassstatus "github.com/tencentcloud/karpenter-provider-tke/pkg/controllers/nodeclass/status"
 	nodeclassstermination "github.com/tencentcloud/karpenter-provider-tke/pkg/controllers/nodeclass/termination"
+	"github.com/tencentcloud/karpenter-provider-tke/pkg/operator/options"
+	"github.com/tencentcloud/karpenter-provider-tke/pkg/providers/eip"
+	"github.com/tencentcloud/karpenter-provider-tke/pkg/providers/hpccluster"
+	"github.com/tencentcloud/karpenter-provider-tke/pkg/providers/image"
+	"github.com/tencentcloud/karpenter-provider-tke/pkg/providers/instance"
 	"github.com/tencentcloud/karpenter-provider-tke/pkg/providers/instancetype"
+	"github.com/tencentcloud/karpenter-provider-tke/pkg/providers/machine"
 	"github.com/tencentcloud/karpenter-provider-tke/pkg/providers/sshkey"
+	"github.com/tencentcloud/karpenter-provider-tke/pkg/providers/validation"
 	"github.com/tencentcloud/karpenter-provider-tke/pkg/providers/vpc"
 	"github.com/tencentcloud/karpenter-provider-tke/pkg/providers/zone"
 	"sigs.k8s.io/karpenter/pkg/cloudprovider"
@@ -37,14 +46,19 @@ import (
 )
 
 func NewControllers(ctx context.Context, clk clock.Clock, kubeClient client.Client, recorder events.Recorder,
-	cloudProvider cloudprovider.CloudProvider, instancetypeProvier instancetype.Provider, zoneProvider zone.Provider, vpcProvider vpc.Provider, sshKeyProvider sshkey.Provider) []controller.Controller {
+	cloudProvider cloudprovider.CloudProvider, instancetypeProvier instancetype.Provider, zoneProvider zone.Provider, vpcProvider vpc.Provider, sshKeyProvider sshkey.Provider,
+	imageProvider image.Provider, hpcClusterProvider hpccluster.Provider,
+	eipProvider eip.Provider, instanceProvider instance.Provider,
+	machineProvider machine.Provider, validationProvider validation.Provider) []controller.Controller {
 
 	controllers := []controller.Controller{
 		nodeclaimproviderid.NewControllerNodeClaim(kubeClient),
 		nodeclaimproviderid.NewControllerMachine(kubeClient),
-		nodeclaimgarbagecollection.NewController(kubeClient, cloudProvider),
+		nodeclaimgarbagecollection.NewController(kubeClient, cloudProvider, eipProvider),
 		nodeclaimfailure.NewController(kubeClient, instancetypeProvier),
-		nodeclassstatus.NewController(kubeClient, zoneProvider, vpcProvider, sshKeyProvider),
+		nodeclaimtagging.NewController(kubeClient, options.FromContext(ctx).ClusterID),
+		nodeclaimprepaid.NewController(kubeClient, instanceProvider, clk),
+		nodeclassstatus.NewController(kubeClient, recorder, zoneProvider, vpcProvider, sshKeyProvider, imageProvider, hpcClusterProvider, eipProvider, machineProvider, validationProvider),
 		nodeclassstermination.NewController(kubeClient, recorder),
 	}
 	return controllers
@@ -25,6 +25,7 @@ import (
 	"github.com/awslabs/operatorpkg/singleton"
 	"github.com/samber/lo"
 	api "github.com/tencentcloud/karpenter-provider-tke/pkg/apis/v1beta1"
+	"github.com/tencentcloud/karpenter-provider-tke/pkg/providers/eip"
 	capiv1beta1 "github.com/tencentcloud/karpenter-provider-tke/staging/nativenode/v1beta1"
 	"go.uber.org/multierr"
 	"k8s.io/apimachinery/pkg/util/sets"
@@ -42,13 +43,15 @@ import (
 type Controller struct {
 	kubeClient      client.Client
 	cloudProvider   cloudprovider.CloudProvider
+	eipProvider     eip.Provider
 	successfulCount uint64 // keeps track of successful reconciles for more aggressive requeueing near the start of the controller
 }
 
-func NewController(kubeClient client.Client, cloudProvider cloudprovider.CloudProvider) *Controller {
+func NewController(kubeClient client.Client, cloudProvider cloudprovider.CloudProvider, eipProvider eip.Provider) *Controller {
 	return &Controller{
 		kubeClient:      kubeClient,
 		cloudProvider:   cloudProvider,
+		eipProvider:     eipProvider,
 		successfulCount: 0,
 	}
 }
@@ -93,15 +96,22 @@ func (c *Controller) Reconcile(ctx context.Context) (reconciler.Result, error) {
 
 func (c *Controller) garbageCollect(ctx context.Context, nodeClaim *v1.NodeClaim, machineList *capiv1beta1.MachineList) error {
 	ctx = log.IntoContext(ctx, log.FromContext(ctx).WithValues("owned-machine", nodeClaim.Annotations[api.AnnotationOwnedMachine]))
+	machine, found := lo.Find(machineList.Items, func(m capiv1beta1.Machine) bool {
+		return m.Name == nodeClaim.Annotations[api.AnnotationOwnedMachine]
+	})
+	// return the EIP taken from the address pool before the instance is gone, the EIP can't be found by instance later
+	if found {
+		if err := c.releaseAddress(ctx, &machine); err != nil {
+			return err
+		}
+	}
 	if err := c.cloudProvider.Delete(ctx, nodeClaim); err != nil {
 		return cloudprovider.IgnoreNodeClaimNotFoundError(err)
 	}
 	log.FromContext(ctx).Info("garbage collected cloudprovider instance")
 
 	// Go ahead and cleanup the node if we know that it exists to make scheduling go quicker
-	if machine, ok := lo.Find(machineList.Items, func(m capiv1beta1.Machine) bool {
-		return m.Name == nodeClaim.Annotations[api.AnnotationOwnedMachine]
-	}); ok {
+	if found {
 		if err := c.kubeClient.Delete(ctx, &machine); err != nil {
 			return client.IgnoreNotFound(err)
 		}
@@ -110,6 +120,29 @@ func (c *Controller) garbageCollect(ctx context.Context, nodeClaim *v1.NodeClaim
 	return nil
 }
 
+func (c *Controller) releaseAddress(ctx context.Context, machine *capiv1beta1.Machine) error {
+	providerSpec, err := capiv1beta1.ProviderSpecFromRawExtension(machine.Spec.ProviderSpec.Value)
+	if err != nil {
+		return fmt.Errorf("unable to get ProviderSpec from Machine %q, %w", machine.GetName(), err)
+	}
+	if providerSpec.InternetAccessible == nil || lo.FromPtr(providerSpec.InternetAccessible.AddressID) == "" {
+		return nil
+	}
+	providerStatus, err := capiv1beta1.ProviderStatusFromRawExtension(machine.Status.ProviderStatus)
+	if err != nil {
+		return fmt.Errorf("unable to get ProviderStatus from Machine %q, %w", machine.GetName(), err)
+	}
+	if lo.FromPtr(providerStatus.InstanceID) == "" {
+		return nil
+	}
+	addressID := lo.FromPtr(providerSpec.InternetAccessible.AddressID)
+	if err = c.eipProvider.Disassociate(ctx, addressID, lo.FromPtr(providerStatus.InstanceID)); err != nil {
+		return fmt.Errorf("releasing address %s, %w", addressID, err)
+	}
+	log.FromContext(ctx).WithValues("address", addressID).Info("released address to the address pool")
+	return nil
+}
+
 func (c *Controller) Register(_ context.Context, m manager.Manager) error {
 	return controllerruntime.NewControllerManagedBy(m).
 		Named("machine.garbagecollection").
@@ -0,0 +1,181 @@
+/*
+Copyright (C) 2012-2025 Tencent. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package prepaid
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/awslabs/operatorpkg/reasonable"
+	"github.com/samber/lo"
+	api "github.com/tencentcloud/karpenter-provider-tke/pkg/apis/v1beta1"
+	"github.com/tencentcloud/karpenter-provider-tke/pkg/providers/instance"
+	capiv1beta1 "github.com/tencentcloud/karpenter-provider-tke/staging/nativenode/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/utils/clock"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/operator/injection"
+)
+
+// Controller protects the prepaid nodes from voluntary disruption until their prepaid period ends. The end of the
+// period is read from the expiration time of the instance, so the renewals of the period extend the protection. The
+// protection is the do-not-disrupt annotation, the NodeClaim and the Node are annotated with the expiration time too,
+// so that only the protection added by this controller is lifted once the period ends.
+type Controller struct {
+	kubeClient       client.Client
+	instanceProvider instance.Provider
+	clock            clock.Clock
+}
+
+func NewController(kubeClient client.Client, instanceProvider instance.Provider, clk clock.Clock) *Controller {
+	return &Controller{
+		kubeClient:       kubeClient,
+		instanceProvider: instanceProvider,
+		clock:            clk,
+	}
+}
+
+func (c *Controller) Reconcile(ctx context.Context, nodeClaim *v1.NodeClaim) (reconcile.Result, error) {
+	ctx = injection.WithControllerName(ctx, "nodeclaim.prepaid")
+
+	if nodeClaim.Labels[v1.CapacityTypeLabelKey] != api.CapacityTypePrepaid || !nodeClaim.DeletionTimestamp.IsZero() {
+		return reconcile.Result{}, nil
+	}
+	if value, ok := nodeClaim.Annotations[api.AnnotationPrepaidExpiration]; ok {
+		if expiration, err := time.Parse(time.RFC3339, value); err == nil {
+			if remaining := expiration.Sub(c.clock.Now()); remaining > 0 {
+				return reconcile.Result{RequeueAfter: remaining}, nil
+			}
+			if _, protected := nodeClaim.Annotations[v1.DoNotDisruptAnnotationKey]; !protected {
+				return reconcile.Result{}, nil
+			}
+		}
+	}
+
+	expiration, err := c.expiration(ctx, nodeClaim)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	if expiration.IsZero() {
+		// the instance of the NodeClaim isn't created yet
+		return reconcile.Result{RequeueAfter: time.Minute}, nil
+	}
+	objects := []client.Object{nodeClaim}
+	if nodeClaim.Status.NodeName != "" {
+		node := &corev1.Node{}
+		if err := c.kubeClient.Get(ctx, client.ObjectKey{Name: nodeClaim.Status.NodeName}, node); client.IgnoreNotFound(err) != nil {
+			return reconcile.Result{}, err
+		} else if err == nil {
+			objects = append(objects, node)
+		}
+	}
+
+	if remaining := expiration.Sub(c.clock.Now()); remaining > 0 {
+		for _, obj := range objects {
+			if err := c.protect(ctx, obj, expiration); err != nil {
+				return reconcile.Result{}, err
+			}
+		}
+		return reconcile.Result{RequeueAfter: remaining}, nil
+	}
+	for _, obj := range objects {
+		if err := c.unprotect(ctx, obj); err != nil {
+			return reconcile.Result{}, err
+		}
+	}
+	log.FromContext(ctx).V(1).Info("prepaid period ended, lifted the disruption protection", "nodeclaim", nodeClaim.Name)
+	return reconcile.Result{}, nil
+}
+
+// expiration returns the expiration time of the instance of the NodeClaim, it's zero when the instance doesn't
+// exist yet.
+func (c *Controller) expiration(ctx context.Context, nodeClaim *v1.NodeClaim) (time.Time, error) {
+	machine := &capiv1beta1.Machine{}
+	if err := c.kubeClient.Get(ctx, client.ObjectKey{Name: nodeClaim.Annotations[api.AnnotationOwnedMachine]}, machine); err != nil {
+		return time.Time{}, client.IgnoreNotFound(err)
+	}
+	providerStatus, err := capiv1beta1.ProviderStatusFromRawExtension(machine.Status.ProviderStatus)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("unable to get ProviderStatus from Machine %q, %w", machine.GetName(), err)
+	}
+	if lo.FromPtr(providerStatus.InstanceID) == "" {
+		return time.Time{}, nil
+	}
+	instance, err := c.instanceProvider.Get(ctx, lo.FromPtr(providerStatus.InstanceID))
+	if err != nil || instance == nil || lo.FromPtr(instance.ExpiredTime) == "" {
+		return time.Time{}, err
+	}
+	expiration, err := time.Parse(time.RFC3339, lo.FromPtr(instance.ExpiredTime))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("parsing the expiration time of instance %s, %w", lo.FromPtr(providerStatus.InstanceID), err)
+	}
+	return expiration, nil
+}
+
+// protect annotates the object with the do-not-disrupt and the prepaid expiration annotations. The objects with a
+// do-not-disrupt annotation which isn't set for the prepaid period are left alone, so that it's never lifted.
+func (c *Controller) protect(ctx context.Context, obj client.Object, expiration time.Time) error {
+	annotations := obj.GetAnnotations()
+	_, prepaid := annotations[api.AnnotationPrepaidExpiration]
+	if _, protected := annotations[v1.DoNotDisruptAnnotationKey]; protected && !prepaid {
+		return nil
+	}
+	stored := obj.DeepCopyObject().(client.Object)
+	obj.SetAnnotations(lo.Assign(annotations, map[string]string{
+		v1.DoNotDisruptAnnotationKey:    "true",
+		api.AnnotationPrepaidExpiration: expiration.UTC().Format(time.RFC3339),
+	}))
+	if equality.Semantic.DeepEqual(stored, obj) {
+		return nil
+	}
+	return client.IgnoreNotFound(c.kubeClient.Patch(ctx, obj, client.MergeFrom(stored)))
+}
+
+// unprotect removes the do-not-disrupt annotation of the object. The objects without the prepaid expiration annotation
+// are left alone, since their do-not-disrupt annotation wasn't set for the prepaid period.
+func (c *Controller) unprotect(ctx context.Context, obj client.Object) error {
+	annotations := obj.GetAnnotations()
+	if _, ok := annotations[api.AnnotationPrepaidExpiration]; !ok {
+		return nil
+	}
+	if _, ok := annotations[v1.DoNotDisruptAnnotationKey]; !ok {
+		return nil
+	}
+	stored := obj.DeepCopyObject().(client.Object)
+	delete(annotations, v1.DoNotDisruptAnnotationKey)
+	obj.SetAnnotations(annotations)
+	return client.IgnoreNotFound(c.kubeClient.Patch(ctx, obj, client.MergeFrom(stored)))
+}
+
+func (c *Controller) Register(_ context.Context, m manager.Manager) error {
+	return controllerruntime.NewControllerManagedBy(m).
+		Named("nodeclaim.prepaid").
+		For(&v1.NodeClaim{}).
+		WithOptions(controller.Options{
+			RateLimiter:             reasonable.RateLimiter(),
+			MaxConcurrentReconciles: 10,
+		}).
+		Complete(reconcile.AsReconciler(m.GetClient(), c))
+}
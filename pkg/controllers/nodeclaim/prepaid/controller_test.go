@@ -0,0 +1,182 @@
+package prepaid
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/samber/lo"
+	"github.com/tencentcloud/karpenter-provider-tke/pkg/apis"
+	api "github.com/tencentcloud/karpenter-provider-tke/pkg/apis/v1beta1"
+	capiv1beta1 "github.com/tencentcloud/karpenter-provider-tke/staging/nativenode/v1beta1"
+	cvm2017 "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/cvm/v20170312"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	clocktesting "k8s.io/utils/clock/testing"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	karpenterapis "sigs.k8s.io/karpenter/pkg/apis"
+	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+)
+
+var expiration = time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)
+
+type mockInstanceProvider struct {
+	expiredTime string
+}
+
+func (m *mockInstanceProvider) Get(_ context.Context, id string) (*cvm2017.Instance, error) {
+	return &cvm2017.Instance{InstanceId: lo.ToPtr(id), ExpiredTime: lo.ToPtr(m.expiredTime)}, nil
+}
+
+func protectedAnnotations(expiration time.Time) map[string]string {
+	return map[string]string{
+		v1.DoNotDisruptAnnotationKey:    "true",
+		api.AnnotationPrepaidExpiration: expiration.Format(time.RFC3339),
+	}
+}
+
+func newObjects(t *testing.T, instanceID string, nodeClaimAnnotations, nodeAnnotations map[string]string) (*v1.NodeClaim, *corev1.Node, *capiv1beta1.Machine) {
+	t.Helper()
+	nodeClaim := &v1.NodeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "default-abc",
+			Labels:      map[string]string{v1.CapacityTypeLabelKey: api.CapacityTypePrepaid},
+			Annotations: lo.Assign(nodeClaimAnnotations, map[string]string{api.AnnotationOwnedMachine: "np-abc"}),
+		},
+		Status: v1.NodeClaimStatus{NodeName: "node-abc"},
+	}
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-abc", Annotations: nodeAnnotations}}
+	providerStatus, err := capiv1beta1.RawExtensionFromProviderStatus(&capiv1beta1.CXMMachineProviderStatus{InstanceID: lo.ToPtr(instanceID)})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	machine := &capiv1beta1.Machine{ObjectMeta: metav1.ObjectMeta{Name: "np-abc"}}
+	machine.Status.ProviderStatus = providerStatus
+	return nodeClaim, node, machine
+}
+
+func reconcileNodeClaim(t *testing.T, now time.Time, instanceExpiration time.Time, nodeClaim *v1.NodeClaim, node *corev1.Node, machine *capiv1beta1.Machine) (*v1.NodeClaim, *corev1.Node, time.Duration) {
+	t.Helper()
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := apis.AddToScheme(scheme); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	gv := schema.GroupVersion{Group: karpenterapis.Group, Version: "v1"}
+	scheme.AddKnownTypes(gv, &v1.NodeClaim{}, &v1.NodeClaimList{})
+	metav1.AddToGroupVersion(scheme, gv)
+	kubeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(nodeClaim, node, machine).Build()
+	c := NewController(kubeClient, &mockInstanceProvider{expiredTime: instanceExpiration.Format(time.RFC3339)}, clocktesting.NewFakeClock(now))
+	result, err := c.Reconcile(context.Background(), nodeClaim)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	updatedNodeClaim := &v1.NodeClaim{}
+	if err := kubeClient.Get(context.Background(), client.ObjectKeyFromObject(nodeClaim), updatedNodeClaim); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	updatedNode := &corev1.Node{}
+	if err := kubeClient.Get(context.Background(), client.ObjectKeyFromObject(node), updatedNode); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return updatedNodeClaim, updatedNode, result.RequeueAfter
+}
+
+func TestReconcile_ProtectsUntilInstanceExpiration(t *testing.T) {
+	nodeClaim, node, machine := newObjects(t, "ins-abc", nil, nil)
+	nodeClaim, node, requeueAfter := reconcileNodeClaim(t, expiration.Add(-time.Hour), expiration, nodeClaim, node, machine)
+	for _, annotations := range []map[string]string{nodeClaim.Annotations, node.Annotations} {
+		if annotations[v1.DoNotDisruptAnnotationKey] != "true" {
+			t.Error("expected the nodeclaim and the node to be protected within the prepaid period")
+		}
+		if annotations[api.AnnotationPrepaidExpiration] != expiration.Format(time.RFC3339) {
+			t.Errorf("expected the expiration of the instance, got %q", annotations[api.AnnotationPrepaidExpiration])
+		}
+	}
+	if requeueAfter != time.Hour {
+		t.Errorf("expected requeue at the end of the prepaid period, got %v", requeueAfter)
+	}
+}
+
+func TestReconcile_InstanceNotCreated(t *testing.T) {
+	nodeClaim, node, machine := newObjects(t, "", nil, nil)
+	nodeClaim, _, requeueAfter := reconcileNodeClaim(t, expiration.Add(-time.Hour), expiration, nodeClaim, node, machine)
+	if _, ok := nodeClaim.Annotations[api.AnnotationPrepaidExpiration]; ok {
+		t.Error("expected no expiration before the instance is created")
+	}
+	if requeueAfter != time.Minute {
+		t.Errorf("expected requeue until the instance is created, got %v", requeueAfter)
+	}
+}
+
+func TestReconcile_WithinPrepaidPeriod(t *testing.T) {
+	nodeClaim, node, machine := newObjects(t, "ins-abc", protectedAnnotations(expiration), protectedAnnotations(expiration))
+	nodeClaim, node, requeueAfter := reconcileNodeClaim(t, expiration.Add(-time.Hour), expiration, nodeClaim, node, machine)
+	if nodeClaim.Annotations[v1.DoNotDisruptAnnotationKey] != "true" || node.Annotations[v1.DoNotDisruptAnnotationKey] != "true" {
+		t.Error("expected the nodeclaim and the node to stay protected within the prepaid period")
+	}
+	if requeueAfter != time.Hour {
+		t.Errorf("expected requeue at the end of the prepaid period, got %v", requeueAfter)
+	}
+}
+
+func TestReconcile_PrepaidPeriodEnded(t *testing.T) {
+	nodeClaim, node, machine := newObjects(t, "ins-abc", protectedAnnotations(expiration), protectedAnnotations(expiration))
+	nodeClaim, node, requeueAfter := reconcileNodeClaim(t, expiration.Add(time.Minute), expiration, nodeClaim, node, machine)
+	for _, annotations := range []map[string]string{nodeClaim.Annotations, node.Annotations} {
+		if _, ok := annotations[v1.DoNotDisruptAnnotationKey]; ok {
+			t.Error("expected the do-not-disrupt annotation to be removed")
+		}
+	}
+	if requeueAfter != 0 {
+		t.Errorf("expected no requeue, got %v", requeueAfter)
+	}
+}
+
+func TestReconcile_PrepaidPeriodRenewed(t *testing.T) {
+	renewed := expiration.AddDate(0, 1, 0)
+	nodeClaim, node, machine := newObjects(t, "ins-abc", protectedAnnotations(expiration), protectedAnnotations(expiration))
+	nodeClaim, node, requeueAfter := reconcileNodeClaim(t, expiration.Add(time.Minute), renewed, nodeClaim, node, machine)
+	for _, annotations := range []map[string]string{nodeClaim.Annotations, node.Annotations} {
+		if annotations[v1.DoNotDisruptAnnotationKey] != "true" {
+			t.Error("expected the nodeclaim and the node to stay protected for the renewed period")
+		}
+		if annotations[api.AnnotationPrepaidExpiration] != renewed.Format(time.RFC3339) {
+			t.Errorf("expected the renewed expiration, got %q", annotations[api.AnnotationPrepaidExpiration])
+		}
+	}
+	if requeueAfter != renewed.Sub(expiration.Add(time.Minute)) {
+		t.Errorf("expected requeue at the end of the renewed period, got %v", requeueAfter)
+	}
+}
+
+func TestReconcile_KeepsUserProtection(t *testing.T) {
+	// the do-not-disrupt annotation added to the node by the user is kept
+	nodeClaim, node, machine := newObjects(t, "ins-abc", nil, map[string]string{v1.DoNotDisruptAnnotationKey: "true"})
+	nodeClaim, node, _ = reconcileNodeClaim(t, expiration.Add(-time.Hour), expiration, nodeClaim, node, machine)
+	if _, ok := node.Annotations[api.AnnotationPrepaidExpiration]; ok {
+		t.Error("expected the node protected by the user not to be annotated with the expiration")
+	}
+	nodeClaim, node, _ = reconcileNodeClaim(t, expiration.Add(time.Minute), expiration, nodeClaim, node, machine)
+	if _, ok := nodeClaim.Annotations[v1.DoNotDisruptAnnotationKey]; ok {
+		t.Error("expected the do-not-disrupt annotation of the nodeclaim to be removed")
+	}
+	if node.Annotations[v1.DoNotDisruptAnnotationKey] != "true" {
+		t.Error("expected the do-not-disrupt annotation of the node to be kept")
+	}
+}
+
+func TestReconcile_NotPrepaid(t *testing.T) {
+	nodeClaim, node, machine := newObjects(t, "ins-abc", nil, nil)
+	nodeClaim.Labels[v1.CapacityTypeLabelKey] = v1.CapacityTypeOnDemand
+	nodeClaim, _, requeueAfter := reconcileNodeClaim(t, expiration.Add(-time.Hour), expiration, nodeClaim, node, machine)
+	if _, ok := nodeClaim.Annotations[v1.DoNotDisruptAnnotationKey]; ok || requeueAfter != 0 {
+		t.Error("expected the nodeclaims of other capacity types to be left alone")
+	}
+}
@@ -0,0 +1,106 @@
+/*
+Copyright (C) 2012-2025 Tencent. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tagging
+
+import (
+	"context"
+
+	"github.com/awslabs/operatorpkg/reasonable"
+	api "github.com/tencentcloud/karpenter-provider-tke/pkg/apis/v1beta1"
+	machineprovider "github.com/tencentcloud/karpenter-provider-tke/pkg/providers/machine"
+	capiv1beta1 "github.com/tencentcloud/karpenter-provider-tke/staging/nativenode/v1beta1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/types"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/karpenter/pkg/operator/injection"
+)
+
+// Controller synchronizes the tags of the TKEMachineNodeClass to the cloud tag annotation of the existing Machines,
+// which is applied on the instances by TKE.
+type Controller struct {
+	kubeClient client.Client
+	clusterID  string
+}
+
+func NewController(kubeClient client.Client, clusterID string) *Controller {
+	return &Controller{
+		kubeClient: kubeClient,
+		clusterID:  clusterID,
+	}
+}
+
+func (c *Controller) Reconcile(ctx context.Context, machine *capiv1beta1.Machine) (reconcile.Result, error) {
+	ctx = injection.WithControllerName(ctx, "machine.tagging")
+
+	if machine.Annotations[api.AnnotationManagedBy] != c.clusterID || !machine.DeletionTimestamp.IsZero() {
+		return reconcile.Result{}, nil
+	}
+	nodeClass := &api.TKEMachineNodeClass{}
+	if err := c.kubeClient.Get(ctx, client.ObjectKey{Name: machine.Labels[api.LabelNodeClass]}, nodeClass); err != nil {
+		return reconcile.Result{}, client.IgnoreNotFound(err)
+	}
+	stored := machine.DeepCopy()
+
+	if machine.Annotations[capiv1beta1.AnnotationDisableSyncMachineCloudTag] != "true" {
+		tags := machineprovider.CloudTags(c.clusterID, nodeClass, machine.Labels)
+		current, err := machineprovider.UnmarshalCloudTags(machine.Annotations[capiv1beta1.AnnotationMachineCloudTag])
+		if err != nil || !equality.Semantic.DeepEqual(current, tags) {
+			if machine.Annotations[capiv1beta1.AnnotationMachineCloudTag], err = machineprovider.MarshalCloudTags(tags); err != nil {
+				return reconcile.Result{}, err
+			}
+		}
+	}
+
+	if !equality.Semantic.DeepEqual(stored, machine) {
+		if err := c.kubeClient.Patch(ctx, machine, client.MergeFrom(stored)); err != nil {
+			return reconcile.Result{}, client.IgnoreNotFound(err)
+		}
+		log.FromContext(ctx).V(1).Info("synchronized machine tags", "machine", machine.Name)
+	}
+	return reconcile.Result{}, nil
+}
+
+func (c *Controller) Register(_ context.Context, m manager.Manager) error {
+	return controllerruntime.NewControllerManagedBy(m).
+		Named("machine.tagging").
+		For(&capiv1beta1.Machine{}).
+		Watches(
+			&api.TKEMachineNodeClass{},
+			handler.EnqueueRequestsFromMapFunc(func(ctx context.Context, o client.Object) []reconcile.Request {
+				machineList := &capiv1beta1.MachineList{}
+				if err := c.kubeClient.List(ctx, machineList, client.MatchingLabels{api.LabelNodeClass: o.GetName()}); err != nil {
+					return nil
+				}
+				requests := make([]reconcile.Request, 0, len(machineList.Items))
+				for _, machine := range machineList.Items {
+					requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Namespace: machine.Namespace, Name: machine.Name}})
+				}
+				return requests
+			}),
+		).
+		WithOptions(controller.Options{
+			RateLimiter:             reasonable.RateLimiter(),
+			MaxConcurrentReconciles: 10,
+		}).
+		Complete(reconcile.AsReconciler(m.GetClient(), c))
+}
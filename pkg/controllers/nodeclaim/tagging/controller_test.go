@@ -0,0 +1,107 @@
+package tagging
+
+import (
+	"context"
+	"testing"
+
+	"github.com/tencentcloud/karpenter-provider-tke/pkg/apis"
+	api "github.com/tencentcloud/karpenter-provider-tke/pkg/apis/v1beta1"
+	machineprovider "github.com/tencentcloud/karpenter-provider-tke/pkg/providers/machine"
+	capiv1beta1 "github.com/tencentcloud/karpenter-provider-tke/staging/nativenode/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+)
+
+func newMachine(annotations map[string]string) *capiv1beta1.Machine {
+	return &capiv1beta1.Machine{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "np-abc",
+			Labels: map[string]string{
+				v1.NodePoolLabelKey: "default",
+				api.LabelNodeClaim:  "default-abc",
+				api.LabelNodeClass:  "default",
+			},
+			Annotations: annotations,
+		},
+	}
+}
+
+func reconcileMachine(t *testing.T, machine *capiv1beta1.Machine) *capiv1beta1.Machine {
+	t.Helper()
+	scheme := runtime.NewScheme()
+	if err := apis.AddToScheme(scheme); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	nodeClass := &api.TKEMachineNodeClass{
+		ObjectMeta: metav1.ObjectMeta{Name: "default"},
+		Spec:       api.TKEMachineNodeClassSpec{Tags: map[string]string{"team": "infra"}},
+	}
+	kubeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(nodeClass, machine).Build()
+	c := NewController(kubeClient, "cls-test")
+	if _, err := c.Reconcile(context.Background(), machine); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	updated := &capiv1beta1.Machine{}
+	if err := kubeClient.Get(context.Background(), client.ObjectKeyFromObject(machine), updated); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return updated
+}
+
+func TestReconcile_SyncTags(t *testing.T) {
+	machine := reconcileMachine(t, newMachine(map[string]string{
+		api.AnnotationManagedBy:               "cls-test",
+		capiv1beta1.AnnotationMachineCloudTag: `[{"tagKey":"team","tagValue":"old"}]`,
+	}))
+	tags, err := machineprovider.UnmarshalCloudTags(machine.Annotations[capiv1beta1.AnnotationMachineCloudTag])
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := map[string]string{
+		"team":           "infra",
+		api.TagClusterID: "cls-test",
+		api.TagNodePool:  "default",
+		api.TagNodeClaim: "default-abc",
+		api.TagNodeClass: "default",
+	}
+	if len(tags) != len(expected) {
+		t.Fatalf("expected tags %v, got %v", expected, tags)
+	}
+	for k, v := range expected {
+		if tags[k] != v {
+			t.Errorf("expected tag %s=%s, got %s", k, v, tags[k])
+		}
+	}
+}
+
+func TestReconcile_SyncDisabled(t *testing.T) {
+	machine := reconcileMachine(t, newMachine(map[string]string{
+		api.AnnotationManagedBy:                          "cls-test",
+		capiv1beta1.AnnotationMachineCloudTag:            `[{"tagKey":"team","tagValue":"old"}]`,
+		capiv1beta1.AnnotationDisableSyncMachineCloudTag: "true",
+	}))
+	if machine.Annotations[capiv1beta1.AnnotationMachineCloudTag] != `[{"tagKey":"team","tagValue":"old"}]` {
+		t.Errorf("expected tags not to be synchronized, got %s", machine.Annotations[capiv1beta1.AnnotationMachineCloudTag])
+	}
+}
+
+func TestReconcile_NotManaged(t *testing.T) {
+	machine := reconcileMachine(t, newMachine(map[string]string{api.AnnotationManagedBy: "cls-other"}))
+	if _, ok := machine.Annotations[capiv1beta1.AnnotationMachineCloudTag]; ok {
+		t.Error("expected the machines of other clusters to be ignored")
+	}
+}
+
+func TestReconcile_KeepsHash(t *testing.T) {
+	machine := reconcileMachine(t, newMachine(map[string]string{
+		api.AnnotationManagedBy:                      "cls-test",
+		api.AnnotationTKEMachineNodeClassHash:        "123",
+		api.AnnotationTKEMachineNodeClassHashVersion: "v0",
+	}))
+	if machine.Annotations[api.AnnotationTKEMachineNodeClassHash] != "123" || machine.Annotations[api.AnnotationTKEMachineNodeClassHashVersion] != "v0" {
+		t.Error("expected the hash not to be re-stamped, the drift would be hidden")
+	}
+}
@@ -0,0 +1,60 @@
+/*
+Copyright (C) 2012-2025 Tencent. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package status
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/samber/lo"
+	api "github.com/tencentcloud/karpenter-provider-tke/pkg/apis/v1beta1"
+	eipprovider "github.com/tencentcloud/karpenter-provider-tke/pkg/providers/eip"
+	vpc "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/vpc/v20170312"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+type Address struct {
+	eipProvider eipprovider.Provider
+}
+
+func (a *Address) Reconcile(ctx context.Context, nodeClass *api.TKEMachineNodeClass) (reconcile.Result, error) {
+	if len(lo.FromPtr(nodeClass.Spec.InternetAccessible).AddressPoolTags) == 0 {
+		nodeClass.Status.Addresses = nil
+		return reconcile.Result{}, nil
+	}
+	addresses, err := a.eipProvider.List(ctx, nodeClass)
+	if err != nil {
+		return reconcile.Result{}, fmt.Errorf("getting addresses, %w", err)
+	}
+	// the nodes are associated with the first unassociated address by ID which is not used by other machines
+	sort.Slice(addresses, func(i, j int) bool {
+		return lo.FromPtr(addresses[i].AddressId) < lo.FromPtr(addresses[j].AddressId)
+	})
+	nodeClass.Status.Addresses = lo.Map(addresses, func(addr *vpc.Address, _ int) api.Address {
+		return api.Address{
+			ID: lo.FromPtr(addr.AddressId),
+			IP: lo.FromPtr(addr.AddressIp),
+		}
+	})
+	if len(nodeClass.Status.Addresses) == 0 {
+		nodeClass.Status.Addresses = nil
+	}
+
+	return reconcile.Result{RequeueAfter: time.Minute}, nil
+}
@@ -0,0 +1,116 @@
+package status
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/samber/lo"
+	api "github.com/tencentcloud/karpenter-provider-tke/pkg/apis/v1beta1"
+	vpc "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/vpc/v20170312"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type mockEIPProvider struct {
+	listFn func(context.Context, *api.TKEMachineNodeClass) ([]*vpc.Address, error)
+}
+
+func (m *mockEIPProvider) List(ctx context.Context, nc *api.TKEMachineNodeClass) ([]*vpc.Address, error) {
+	if m.listFn != nil {
+		return m.listFn(ctx, nc)
+	}
+	return nil, nil
+}
+
+func (m *mockEIPProvider) Disassociate(_ context.Context, _, _ string) error {
+	return nil
+}
+
+func addressPoolNodeClass() *api.TKEMachineNodeClass {
+	return &api.TKEMachineNodeClass{
+		ObjectMeta: metav1.ObjectMeta{Name: "test"},
+		Spec: api.TKEMachineNodeClassSpec{
+			InternetAccessible: &api.InternetAccessible{
+				AddressType:     lo.ToPtr(api.AddressTypeEIP),
+				AddressPoolTags: map[string]string{"pool": "egress"},
+			},
+		},
+	}
+}
+
+func TestAddress_Reconcile_NoAddressPool(t *testing.T) {
+	a := &Address{
+		eipProvider: &mockEIPProvider{
+			listFn: func(_ context.Context, _ *api.TKEMachineNodeClass) ([]*vpc.Address, error) {
+				t.Fatal("expected no address lookup without address pool tags")
+				return nil, nil
+			},
+		},
+	}
+	nodeClass := &api.TKEMachineNodeClass{
+		ObjectMeta: metav1.ObjectMeta{Name: "test"},
+		Status: api.TKEMachineNodeClassStatus{
+			Addresses: []api.Address{{ID: "eip-old"}},
+		},
+	}
+	_, err := a.Reconcile(context.Background(), nodeClass)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if nodeClass.Status.Addresses != nil {
+		t.Error("expected nil addresses without address pool tags")
+	}
+}
+
+func TestAddress_Reconcile_Error(t *testing.T) {
+	a := &Address{
+		eipProvider: &mockEIPProvider{
+			listFn: func(_ context.Context, _ *api.TKEMachineNodeClass) ([]*vpc.Address, error) {
+				return nil, fmt.Errorf("address list failed")
+			},
+		},
+	}
+	if _, err := a.Reconcile(context.Background(), addressPoolNodeClass()); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestAddress_Reconcile_Sorted(t *testing.T) {
+	a := &Address{
+		eipProvider: &mockEIPProvider{
+			listFn: func(_ context.Context, _ *api.TKEMachineNodeClass) ([]*vpc.Address, error) {
+				return []*vpc.Address{
+					{AddressId: lo.ToPtr("eip-b"), AddressIp: lo.ToPtr("1.1.1.2")},
+					{AddressId: lo.ToPtr("eip-a"), AddressIp: lo.ToPtr("1.1.1.1")},
+				}, nil
+			},
+		},
+	}
+	nodeClass := addressPoolNodeClass()
+	res, err := a.Reconcile(context.Background(), nodeClass)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.RequeueAfter != time.Minute {
+		t.Errorf("expected requeue after 1m, got %v", res.RequeueAfter)
+	}
+	if len(nodeClass.Status.Addresses) != 2 {
+		t.Fatalf("expected 2 addresses, got %d", len(nodeClass.Status.Addresses))
+	}
+	if nodeClass.Status.Addresses[0].ID != "eip-a" || nodeClass.Status.Addresses[0].IP != "1.1.1.1" {
+		t.Errorf("expected eip-a first, got %+v", nodeClass.Status.Addresses[0])
+	}
+}
+
+func TestAddress_Reconcile_PoolExhausted(t *testing.T) {
+	a := &Address{eipProvider: &mockEIPProvider{}}
+	nodeClass := addressPoolNodeClass()
+	nodeClass.Status.Addresses = []api.Address{{ID: "eip-old"}}
+	if _, err := a.Reconcile(context.Background(), nodeClass); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if nodeClass.Status.Addresses != nil {
+		t.Error("expected nil addresses when the pool is exhausted")
+	}
+}
@@ -30,10 +30,16 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/karpenter/pkg/events"
 	"sigs.k8s.io/karpenter/pkg/operator/injection"
 
 	api "github.com/tencentcloud/karpenter-provider-tke/pkg/apis/v1beta1"
+	eipprovider "github.com/tencentcloud/karpenter-provider-tke/pkg/providers/eip"
+	hpcclusterprovider "github.com/tencentcloud/karpenter-provider-tke/pkg/providers/hpccluster"
+	imageprovider "github.com/tencentcloud/karpenter-provider-tke/pkg/providers/image"
+	machineprovider "github.com/tencentcloud/karpenter-provider-tke/pkg/providers/machine"
 	sshkeyprovider "github.com/tencentcloud/karpenter-provider-tke/pkg/providers/sshkey"
+	validationprovider "github.com/tencentcloud/karpenter-provider-tke/pkg/providers/validation"
 	"sigs.k8s.io/karpenter/pkg/utils/result"
 )
 
@@ -43,21 +49,32 @@ type nodeClassStatusReconciler interface {
 
 type Controller struct {
 	kubeClient client.Client
-
-	subnet    *Subnet
-	sg        *SecurityGroup
-	sshkey    *SSHKey
-	readiness *Readiness
+	recorder   events.Recorder
+
+	subnet     *Subnet
+	sg         *SecurityGroup
+	sshkey     *SSHKey
+	image      *Image
+	hpcCluster *HPCCluster
+	address    *Address
+	validation *Validation
 }
 
-func NewController(kubeClient client.Client, zoneProvider zone.Provider, vpcProvider vpc.Provider, sshKeyProvider sshkeyprovider.Provider) *Controller {
+func NewController(kubeClient client.Client, recorder events.Recorder, zoneProvider zone.Provider, vpcProvider vpc.Provider, sshKeyProvider sshkeyprovider.Provider,
+	imageProvider imageprovider.Provider, hpcClusterProvider hpcclusterprovider.Provider,
+	eipProvider eipprovider.Provider,
+	machineProvider machineprovider.Provider, validationProvider validationprovider.Provider) *Controller {
 	return &Controller{
 		kubeClient: kubeClient,
-
-		subnet:    &Subnet{zoneProvider: zoneProvider, vpcProvider: vpcProvider},
-		sg:        &SecurityGroup{vpcProvider: vpcProvider},
-		sshkey:    &SSHKey{sshKeyProvider: sshKeyProvider},
-		readiness: &Readiness{},
+		recorder:   recorder,
+
+		subnet:     &Subnet{zoneProvider: zoneProvider, vpcProvider: vpcProvider},
+		sg:         &SecurityGroup{vpcProvider: vpcProvider},
+		sshkey:     &SSHKey{sshKeyProvider: sshKeyProvider},
+		image:      &Image{imageProvider: imageProvider},
+		hpcCluster: &HPCCluster{zoneProvider: zoneProvider, hpcClusterProvider: hpcClusterProvider},
+		address:    &Address{eipProvider: eipProvider},
+		validation: &Validation{machineProvider: machineProvider, validationProvider: validationProvider},
 	}
 }
 
@@ -79,13 +96,18 @@ func (c *Controller) Reconcile(ctx context.Context, nodeClass *api.TKEMachineNod
 		c.subnet,
 		c.sg,
 		c.sshkey,
-		c.readiness,
+		c.image,
+		c.hpcCluster,
+		c.address,
+		c.validation,
 	} {
 		res, err := reconciler.Reconcile(ctx, nodeClass)
 		errs = multierr.Append(errs, err)
 		results = append(results, res)
 	}
 
+	c.recorder.Publish(resolvedSetChangedEvents(stored, nodeClass)...)
+
 	if !equality.Semantic.DeepEqual(stored, nodeClass) {
 		if err := c.kubeClient.Status().Patch(ctx, nodeClass, client.MergeFrom(stored)); err != nil {
 			errs = multierr.Append(errs, client.IgnoreNotFound(err))
@@ -5,10 +5,12 @@ import (
 	"fmt"
 	"testing"
 
+	"github.com/awslabs/operatorpkg/status"
 	"github.com/samber/lo"
 	api "github.com/tencentcloud/karpenter-provider-tke/pkg/apis/v1beta1"
 	cvm "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/cvm/v20170312"
 	vpc "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/vpc/v20170312"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -16,6 +18,7 @@ import (
 	"k8s.io/apimachinery/pkg/watch"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/karpenter/pkg/events"
 )
 
 // statusFakeClient is a minimal client.Client for testing the status controller.
@@ -80,6 +83,15 @@ func (c *statusFakeClient) IsObjectNamespaced(_ runtime.Object) (bool, error) {
 	return false, nil
 }
 
+// mockRecorder implements events.Recorder.
+type mockRecorder struct {
+	published []events.Event
+}
+
+func (r *mockRecorder) Publish(evts ...events.Event) {
+	r.published = append(r.published, evts...)
+}
+
 type statusFakeStatusWriter struct {
 	err error
 }
@@ -97,9 +109,15 @@ func (w *statusFakeStatusWriter) Patch(_ context.Context, _ client.Object, _ cli
 func TestController_NewController(t *testing.T) {
 	c := NewController(
 		&statusFakeClient{},
+		&mockRecorder{},
 		&mockSubnetZoneProvider{},
 		&mockVpcProvider{},
 		&mockSSHKeyProvider{},
+		&mockImageProvider{},
+		&mockHPCClusterProvider{},
+		&mockEIPProvider{},
+		&mockMachineProvider{},
+		&mockValidationProvider{},
 	)
 	if c == nil {
 		t.Fatal("expected non-nil controller")
@@ -113,8 +131,11 @@ func TestController_NewController(t *testing.T) {
 	if c.sshkey == nil {
 		t.Error("expected non-nil sshkey reconciler")
 	}
-	if c.readiness == nil {
-		t.Error("expected non-nil readiness reconciler")
+	if c.image == nil {
+		t.Error("expected non-nil image reconciler")
+	}
+	if c.validation == nil {
+		t.Error("expected non-nil validation reconciler")
 	}
 }
 
@@ -122,9 +143,15 @@ func TestController_Reconcile_AddFinalizer(t *testing.T) {
 	fc := &statusFakeClient{}
 	c := NewController(
 		fc,
+		&mockRecorder{},
 		&mockSubnetZoneProvider{},
 		&mockVpcProvider{},
 		&mockSSHKeyProvider{},
+		&mockImageProvider{},
+		&mockHPCClusterProvider{},
+		&mockEIPProvider{},
+		&mockMachineProvider{},
+		&mockValidationProvider{},
 	)
 	nodeClass := &api.TKEMachineNodeClass{
 		ObjectMeta: metav1.ObjectMeta{
@@ -145,9 +172,15 @@ func TestController_Reconcile_AlreadyHasFinalizer(t *testing.T) {
 	fc := &statusFakeClient{}
 	c := NewController(
 		fc,
+		&mockRecorder{},
 		&mockSubnetZoneProvider{},
 		&mockVpcProvider{},
 		&mockSSHKeyProvider{},
+		&mockImageProvider{},
+		&mockHPCClusterProvider{},
+		&mockEIPProvider{},
+		&mockMachineProvider{},
+		&mockValidationProvider{},
 	)
 	nodeClass := &api.TKEMachineNodeClass{
 		ObjectMeta: metav1.ObjectMeta{
@@ -166,9 +199,15 @@ func TestController_Reconcile_PatchFinalizerError(t *testing.T) {
 	fc := &statusFakeClient{patchErr: fmt.Errorf("patch failed")}
 	c := NewController(
 		fc,
+		&mockRecorder{},
 		&mockSubnetZoneProvider{},
 		&mockVpcProvider{},
 		&mockSSHKeyProvider{},
+		&mockImageProvider{},
+		&mockHPCClusterProvider{},
+		&mockEIPProvider{},
+		&mockMachineProvider{},
+		&mockValidationProvider{},
 	)
 	nodeClass := &api.TKEMachineNodeClass{
 		ObjectMeta: metav1.ObjectMeta{
@@ -187,6 +226,7 @@ func TestController_Reconcile_SubReconcilerError(t *testing.T) {
 	fc := &statusFakeClient{}
 	c := NewController(
 		fc,
+		&mockRecorder{},
 		&mockSubnetZoneProvider{},
 		&mockVpcProvider{
 			listSubnetsFn: func(_ context.Context, _ *api.TKEMachineNodeClass) ([]*vpc.Subnet, error) {
@@ -201,6 +241,11 @@ func TestController_Reconcile_SubReconcilerError(t *testing.T) {
 				return nil, fmt.Errorf("sshkey error")
 			},
 		},
+		&mockImageProvider{},
+		&mockHPCClusterProvider{},
+		&mockEIPProvider{},
+		&mockMachineProvider{},
+		&mockValidationProvider{},
 	)
 	nodeClass := &api.TKEMachineNodeClass{
 		ObjectMeta: metav1.ObjectMeta{
@@ -220,6 +265,7 @@ func TestController_Reconcile_StatusPatchError(t *testing.T) {
 	fc := &statusFakeClient{statusErr: fmt.Errorf("status patch failed")}
 	c := NewController(
 		fc,
+		&mockRecorder{},
 		&mockSubnetZoneProvider{},
 		&mockVpcProvider{
 			listSubnetsFn: func(_ context.Context, _ *api.TKEMachineNodeClass) ([]*vpc.Subnet, error) {
@@ -244,6 +290,11 @@ func TestController_Reconcile_StatusPatchError(t *testing.T) {
 				}, nil
 			},
 		},
+		&mockImageProvider{},
+		&mockHPCClusterProvider{},
+		&mockEIPProvider{},
+		&mockMachineProvider{},
+		&mockValidationProvider{},
 	)
 	nodeClass := &api.TKEMachineNodeClass{
 		ObjectMeta: metav1.ObjectMeta{
@@ -257,3 +308,114 @@ func TestController_Reconcile_StatusPatchError(t *testing.T) {
 		t.Fatal("expected error from status patch")
 	}
 }
+
+func TestController_Reconcile_ConditionsAndEvents(t *testing.T) {
+	recorder := &mockRecorder{}
+	c := NewController(
+		&statusFakeClient{},
+		recorder,
+		&mockSubnetZoneProvider{},
+		&mockVpcProvider{
+			listSubnetsFn: func(_ context.Context, _ *api.TKEMachineNodeClass) ([]*vpc.Subnet, error) {
+				return []*vpc.Subnet{{SubnetId: lo.ToPtr("subnet-123"), Zone: lo.ToPtr("ap-guangzhou-3")}}, nil
+			},
+			listSecurityGroupsFn: func(_ context.Context, _ *api.TKEMachineNodeClass) ([]*vpc.SecurityGroup, error) {
+				return []*vpc.SecurityGroup{{SecurityGroupId: lo.ToPtr("sg-123")}}, nil
+			},
+		},
+		&mockSSHKeyProvider{
+			listFn: func(_ context.Context, _ *api.TKEMachineNodeClass) ([]*cvm.KeyPair, error) {
+				return []*cvm.KeyPair{{KeyId: lo.ToPtr("skey-123")}}, nil
+			},
+		},
+		&mockImageProvider{},
+		&mockHPCClusterProvider{},
+		&mockEIPProvider{},
+		&mockMachineProvider{},
+		&mockValidationProvider{},
+	)
+	nodeClass := &api.TKEMachineNodeClass{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       "test",
+			Generation: 1,
+			Finalizers: []string{api.TerminationFinalizer},
+		},
+		Spec: api.TKEMachineNodeClassSpec{
+			SSHKeySelectorTerms: []api.SSHKeySelectorTerm{{ID: "skey-123"}},
+		},
+		Status: api.TKEMachineNodeClassStatus{
+			Subnets: []api.Subnet{{ID: "subnet-123", Zone: "ap-guangzhou-3"}},
+			SSHKeys: []api.SSHKey{{ID: "skey-old"}},
+		},
+	}
+	if _, err := c.Reconcile(context.Background(), nodeClass); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, cond := range []string{
+		api.ConditionTypeSubnetsReady,
+		api.ConditionTypeSecurityGroupsReady,
+		api.ConditionTypeSSHKeysReady,
+		api.ConditionTypeValidationSucceeded,
+		status.ConditionReady,
+	} {
+		if !nodeClass.StatusConditions().Get(cond).IsTrue() {
+			t.Errorf("expected %s condition to be true", cond)
+		}
+	}
+	reasons := lo.Map(recorder.published, func(e events.Event, _ int) string { return e.Reason })
+	if len(reasons) != 2 || !lo.Contains(reasons, "SecurityGroupsChanged") || !lo.Contains(reasons, "SSHKeysChanged") {
+		t.Errorf("expected SecurityGroupsChanged and SSHKeysChanged events, got %v", reasons)
+	}
+}
+
+func TestController_Reconcile_SubnetsLost(t *testing.T) {
+	recorder := &mockRecorder{}
+	c := NewController(
+		&statusFakeClient{},
+		recorder,
+		&mockSubnetZoneProvider{},
+		&mockVpcProvider{
+			listSecurityGroupsFn: func(_ context.Context, _ *api.TKEMachineNodeClass) ([]*vpc.SecurityGroup, error) {
+				return []*vpc.SecurityGroup{{SecurityGroupId: lo.ToPtr("sg-123")}}, nil
+			},
+		},
+		&mockSSHKeyProvider{
+			listFn: func(_ context.Context, _ *api.TKEMachineNodeClass) ([]*cvm.KeyPair, error) {
+				return []*cvm.KeyPair{{KeyId: lo.ToPtr("skey-123")}}, nil
+			},
+		},
+		&mockImageProvider{},
+		&mockHPCClusterProvider{},
+		&mockEIPProvider{},
+		&mockMachineProvider{},
+		&mockValidationProvider{},
+	)
+	nodeClass := &api.TKEMachineNodeClass{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       "test",
+			Generation: 1,
+			Finalizers: []string{api.TerminationFinalizer},
+		},
+		Spec: api.TKEMachineNodeClassSpec{
+			SSHKeySelectorTerms: []api.SSHKeySelectorTerm{{ID: "skey-123"}},
+		},
+		Status: api.TKEMachineNodeClassStatus{
+			Subnets:        []api.Subnet{{ID: "subnet-123", Zone: "ap-guangzhou-3"}},
+			SecurityGroups: []api.SecurityGroup{{ID: "sg-123"}},
+			SSHKeys:        []api.SSHKey{{ID: "skey-123"}},
+		},
+	}
+	if _, err := c.Reconcile(context.Background(), nodeClass); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cond := nodeClass.StatusConditions().Get(api.ConditionTypeSubnetsReady)
+	if !cond.IsFalse() || cond.Reason != api.ConditionReasonSubnetsNotFound {
+		t.Errorf("expected SubnetsReady to be false with reason %s, got %s/%s", api.ConditionReasonSubnetsNotFound, cond.Status, cond.Reason)
+	}
+	if !nodeClass.StatusConditions().Get(status.ConditionReady).IsFalse() {
+		t.Error("expected Ready condition to be false")
+	}
+	if len(recorder.published) != 1 || recorder.published[0].Reason != "SubnetsNotFound" || recorder.published[0].Type != corev1.EventTypeWarning {
+		t.Errorf("expected a SubnetsNotFound warning event, got %v", recorder.published)
+	}
+}
@@ -0,0 +1,85 @@
+/*
+Copyright (C) 2012-2025 Tencent. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package status
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/samber/lo"
+	"github.com/tencentcloud/karpenter-provider-tke/pkg/util"
+	corev1 "k8s.io/api/core/v1"
+
+	"sigs.k8s.io/karpenter/pkg/events"
+
+	api "github.com/tencentcloud/karpenter-provider-tke/pkg/apis/v1beta1"
+)
+
+// ResolvedSetChangedEvent reports that the set of resources resolved by a selector of the nodeClass changed.
+// An empty set is reported as a warning since nodes can't be launched with it.
+func ResolvedSetChangedEvent(nodeClass *api.TKEMachineNodeClass, kind string, ids []string) events.Event {
+	if len(ids) == 0 {
+		return events.Event{
+			InvolvedObject: nodeClass,
+			Type:           corev1.EventTypeWarning,
+			Reason:         fmt.Sprintf("%sNotFound", kind),
+			Message:        fmt.Sprintf("%sSelector did not match any %s", strings.TrimSuffix(kind, "s"), kind),
+			DedupeValues:   []string{string(nodeClass.UID), kind},
+		}
+	}
+	return events.Event{
+		InvolvedObject: nodeClass,
+		Type:           corev1.EventTypeNormal,
+		Reason:         fmt.Sprintf("%sChanged", kind),
+		Message:        fmt.Sprintf("Resolved %s changed to %s", kind, util.PrettySlice(ids, 5)),
+		DedupeValues:   []string{string(nodeClass.UID), kind, strings.Join(ids, ",")},
+	}
+}
+
+// resolvedSetChangedEvents compares the resolved subnets, security groups and ssh keys before and after reconciling
+// and returns an event for each set that changed.
+func resolvedSetChangedEvents(stored, nodeClass *api.TKEMachineNodeClass) []events.Event {
+	var evts []events.Event
+	for _, set := range []struct {
+		kind   string
+		before []string
+		after  []string
+	}{
+		{
+			kind:   "Subnets",
+			before: lo.Map(stored.Status.Subnets, func(s api.Subnet, _ int) string { return s.ID }),
+			after:  lo.Map(nodeClass.Status.Subnets, func(s api.Subnet, _ int) string { return s.ID }),
+		},
+		{
+			kind:   "SecurityGroups",
+			before: lo.Map(stored.Status.SecurityGroups, func(s api.SecurityGroup, _ int) string { return s.ID }),
+			after:  lo.Map(nodeClass.Status.SecurityGroups, func(s api.SecurityGroup, _ int) string { return s.ID }),
+		},
+		{
+			kind:   "SSHKeys",
+			before: lo.Map(stored.Status.SSHKeys, func(s api.SSHKey, _ int) string { return s.ID }),
+			after:  lo.Map(nodeClass.Status.SSHKeys, func(s api.SSHKey, _ int) string { return s.ID }),
+		},
+	} {
+		// Subnets are ordered by available ips, so only the membership is compared
+		if len(set.before) == len(set.after) && len(lo.Without(set.after, set.before...)) == 0 {
+			continue
+		}
+		evts = append(evts, ResolvedSetChangedEvent(nodeClass, set.kind, set.after))
+	}
+	return evts
+}
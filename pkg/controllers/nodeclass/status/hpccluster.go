@@ -0,0 +1,66 @@
+/*
+Copyright (C) 2012-2025 Tencent. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package status
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/samber/lo"
+	api "github.com/tencentcloud/karpenter-provider-tke/pkg/apis/v1beta1"
+	hpcclusterprovider "github.com/tencentcloud/karpenter-provider-tke/pkg/providers/hpccluster"
+	"github.com/tencentcloud/karpenter-provider-tke/pkg/providers/zone"
+	cvm "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/cvm/v20170312"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+type HPCCluster struct {
+	zoneProvider       zone.Provider
+	hpcClusterProvider hpcclusterprovider.Provider
+}
+
+func (h *HPCCluster) Reconcile(ctx context.Context, nodeClass *api.TKEMachineNodeClass) (reconcile.Result, error) {
+	if len(nodeClass.Spec.HPCClusterSelectorTerms) == 0 {
+		nodeClass.Status.HPCClusters = nil
+		return reconcile.Result{}, nil
+	}
+	clusters, err := h.hpcClusterProvider.List(ctx, nodeClass)
+	if err != nil {
+		return reconcile.Result{}, fmt.Errorf("getting hpc clusters, %w", err)
+	}
+	// a node can only join one HPC cluster, the first one by ID is used for each zone
+	sort.Slice(clusters, func(i, j int) bool {
+		return lo.FromPtr(clusters[i].HpcClusterId) < lo.FromPtr(clusters[j].HpcClusterId)
+	})
+	clusters = lo.UniqBy(clusters, func(c *cvm.HpcClusterInfo) string { return lo.FromPtr(c.Zone) })
+	if len(clusters) == 0 {
+		nodeClass.Status.HPCClusters = nil
+		return reconcile.Result{}, nil
+	}
+	nodeClass.Status.HPCClusters = lo.Map(clusters, func(c *cvm.HpcClusterInfo, _ int) api.HPCCluster {
+		zoneID, _ := h.zoneProvider.IDFromZone(lo.FromPtr(c.Zone))
+		return api.HPCCluster{
+			ID:     lo.FromPtr(c.HpcClusterId),
+			Zone:   lo.FromPtr(c.Zone),
+			ZoneID: zoneID,
+		}
+	})
+
+	return reconcile.Result{RequeueAfter: time.Minute}, nil
+}
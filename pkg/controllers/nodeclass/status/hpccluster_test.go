@@ -0,0 +1,112 @@
+package status
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/samber/lo"
+	api "github.com/tencentcloud/karpenter-provider-tke/pkg/apis/v1beta1"
+	cvm "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/cvm/v20170312"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type mockHPCClusterProvider struct {
+	listFn func(context.Context, *api.TKEMachineNodeClass) ([]*cvm.HpcClusterInfo, error)
+}
+
+func (m *mockHPCClusterProvider) List(ctx context.Context, nc *api.TKEMachineNodeClass) ([]*cvm.HpcClusterInfo, error) {
+	if m.listFn != nil {
+		return m.listFn(ctx, nc)
+	}
+	return nil, nil
+}
+
+func hpcClusterNodeClass() *api.TKEMachineNodeClass {
+	return &api.TKEMachineNodeClass{
+		ObjectMeta: metav1.ObjectMeta{Name: "test"},
+		Spec: api.TKEMachineNodeClassSpec{
+			HPCClusterSelectorTerms: []api.HPCClusterSelectorTerm{{Tags: map[string]string{"env": "prod"}}},
+		},
+	}
+}
+
+func TestHPCCluster_Reconcile_NoSelectorTerms(t *testing.T) {
+	h := &HPCCluster{
+		zoneProvider: &mockSubnetZoneProvider{},
+		hpcClusterProvider: &mockHPCClusterProvider{
+			listFn: func(_ context.Context, _ *api.TKEMachineNodeClass) ([]*cvm.HpcClusterInfo, error) {
+				t.Fatal("expected no hpc cluster lookup without selector terms")
+				return nil, nil
+			},
+		},
+	}
+	nodeClass := &api.TKEMachineNodeClass{
+		ObjectMeta: metav1.ObjectMeta{Name: "test"},
+		Status: api.TKEMachineNodeClassStatus{
+			HPCClusters: []api.HPCCluster{{ID: "hpc-old", Zone: "ap-guangzhou-3"}},
+		},
+	}
+	_, err := h.Reconcile(context.Background(), nodeClass)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if nodeClass.Status.HPCClusters != nil {
+		t.Error("expected nil hpc clusters without selector terms")
+	}
+}
+
+func TestHPCCluster_Reconcile_Error(t *testing.T) {
+	h := &HPCCluster{
+		zoneProvider: &mockSubnetZoneProvider{},
+		hpcClusterProvider: &mockHPCClusterProvider{
+			listFn: func(_ context.Context, _ *api.TKEMachineNodeClass) ([]*cvm.HpcClusterInfo, error) {
+				return nil, fmt.Errorf("hpc cluster list failed")
+			},
+		},
+	}
+	nodeClass := hpcClusterNodeClass()
+	if _, err := h.Reconcile(context.Background(), nodeClass); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestHPCCluster_Reconcile_OnePerZone(t *testing.T) {
+	h := &HPCCluster{
+		zoneProvider: &mockSubnetZoneProvider{
+			idFromZoneFn: func(zone string) (string, error) {
+				return map[string]string{"ap-guangzhou-3": "100003", "ap-guangzhou-6": "100006"}[zone], nil
+			},
+		},
+		hpcClusterProvider: &mockHPCClusterProvider{
+			listFn: func(_ context.Context, _ *api.TKEMachineNodeClass) ([]*cvm.HpcClusterInfo, error) {
+				return []*cvm.HpcClusterInfo{
+					{HpcClusterId: lo.ToPtr("hpc-c"), Zone: lo.ToPtr("ap-guangzhou-6")},
+					{HpcClusterId: lo.ToPtr("hpc-b"), Zone: lo.ToPtr("ap-guangzhou-3")},
+					{HpcClusterId: lo.ToPtr("hpc-a"), Zone: lo.ToPtr("ap-guangzhou-3")},
+				}, nil
+			},
+		},
+	}
+	nodeClass := hpcClusterNodeClass()
+	res, err := h.Reconcile(context.Background(), nodeClass)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.RequeueAfter != time.Minute {
+		t.Errorf("expected requeue after 1m, got %v", res.RequeueAfter)
+	}
+	expected := []api.HPCCluster{
+		{ID: "hpc-a", Zone: "ap-guangzhou-3", ZoneID: "100003"},
+		{ID: "hpc-c", Zone: "ap-guangzhou-6", ZoneID: "100006"},
+	}
+	if len(nodeClass.Status.HPCClusters) != len(expected) {
+		t.Fatalf("expected %d hpc clusters, got %d", len(expected), len(nodeClass.Status.HPCClusters))
+	}
+	for i := range expected {
+		if nodeClass.Status.HPCClusters[i] != expected[i] {
+			t.Errorf("expected %v, got %v", expected[i], nodeClass.Status.HPCClusters[i])
+		}
+	}
+}
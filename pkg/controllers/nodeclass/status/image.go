@@ -0,0 +1,93 @@
+/*
+Copyright (C) 2012-2025 Tencent. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package status
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/samber/lo"
+	api "github.com/tencentcloud/karpenter-provider-tke/pkg/apis/v1beta1"
+	imageprovider "github.com/tencentcloud/karpenter-provider-tke/pkg/providers/image"
+	cvm "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/cvm/v20170312"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+)
+
+type Image struct {
+	imageProvider imageprovider.Provider
+}
+
+func (i *Image) Reconcile(ctx context.Context, nodeClass *api.TKEMachineNodeClass) (reconcile.Result, error) {
+	if len(nodeClass.Spec.ImageSelectorTerms) == 0 {
+		nodeClass.Status.Images = nil
+		return reconcile.Result{}, nil
+	}
+	images, err := i.imageProvider.List(ctx, nodeClass)
+	if err != nil {
+		return reconcile.Result{}, fmt.Errorf("getting images, %w", err)
+	}
+	// the newest image of each architecture is used for launching
+	newest := map[string]*cvm.Image{}
+	for _, image := range images {
+		arch := architecture(lo.FromPtr(image.Architecture))
+		if arch == "" {
+			continue
+		}
+		if current, ok := newest[arch]; !ok || isNewer(image, current) {
+			newest[arch] = image
+		}
+	}
+	if len(newest) == 0 {
+		nodeClass.Status.Images = nil
+		return reconcile.Result{}, nil
+	}
+	nodeClass.Status.Images = lo.MapToSlice(newest, func(arch string, image *cvm.Image) api.Image {
+		return api.Image{
+			ID:           lo.FromPtr(image.ImageId),
+			Name:         lo.FromPtr(image.ImageName),
+			Architecture: arch,
+		}
+	})
+	sort.Slice(nodeClass.Status.Images, func(i, j int) bool {
+		return nodeClass.Status.Images[i].Architecture < nodeClass.Status.Images[j].Architecture
+	})
+
+	return reconcile.Result{RequeueAfter: time.Minute}, nil
+}
+
+// architecture converts the image architecture of tencentcloud to the kubernetes architecture
+func architecture(arch string) string {
+	switch arch {
+	case "x86_64", "amd64":
+		return v1.ArchitectureAmd64
+	case "arm", "arm64", "aarch64":
+		return v1.ArchitectureArm64
+	}
+	return ""
+}
+
+func isNewer(image, than *cvm.Image) bool {
+	imageCreated, err1 := time.Parse(time.RFC3339, lo.FromPtr(image.CreatedTime))
+	thanCreated, err2 := time.Parse(time.RFC3339, lo.FromPtr(than.CreatedTime))
+	if err1 != nil || err2 != nil || imageCreated.Equal(thanCreated) {
+		return lo.FromPtr(image.ImageId) < lo.FromPtr(than.ImageId)
+	}
+	return imageCreated.After(thanCreated)
+}
@@ -0,0 +1,107 @@
+package status
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/samber/lo"
+	api "github.com/tencentcloud/karpenter-provider-tke/pkg/apis/v1beta1"
+	cvm "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/cvm/v20170312"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type mockImageProvider struct {
+	listFn func(context.Context, *api.TKEMachineNodeClass) ([]*cvm.Image, error)
+}
+
+func (m *mockImageProvider) List(ctx context.Context, nc *api.TKEMachineNodeClass) ([]*cvm.Image, error) {
+	if m.listFn != nil {
+		return m.listFn(ctx, nc)
+	}
+	return nil, nil
+}
+
+func imageNodeClass() *api.TKEMachineNodeClass {
+	return &api.TKEMachineNodeClass{
+		ObjectMeta: metav1.ObjectMeta{Name: "test"},
+		Spec: api.TKEMachineNodeClassSpec{
+			ImageSelectorTerms: []api.ImageSelectorTerm{{Tags: map[string]string{"env": "prod"}}},
+		},
+	}
+}
+
+func TestImage_Reconcile_NoSelectorTerms(t *testing.T) {
+	i := &Image{
+		imageProvider: &mockImageProvider{
+			listFn: func(_ context.Context, _ *api.TKEMachineNodeClass) ([]*cvm.Image, error) {
+				t.Fatal("expected no image lookup without selector terms")
+				return nil, nil
+			},
+		},
+	}
+	nodeClass := &api.TKEMachineNodeClass{
+		ObjectMeta: metav1.ObjectMeta{Name: "test"},
+		Status: api.TKEMachineNodeClassStatus{
+			Images: []api.Image{{ID: "img-old", Architecture: "amd64"}},
+		},
+	}
+	_, err := i.Reconcile(context.Background(), nodeClass)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if nodeClass.Status.Images != nil {
+		t.Error("expected nil images without selector terms")
+	}
+}
+
+func TestImage_Reconcile_Error(t *testing.T) {
+	i := &Image{
+		imageProvider: &mockImageProvider{
+			listFn: func(_ context.Context, _ *api.TKEMachineNodeClass) ([]*cvm.Image, error) {
+				return nil, fmt.Errorf("image list failed")
+			},
+		},
+	}
+	nodeClass := imageNodeClass()
+	_, err := i.Reconcile(context.Background(), nodeClass)
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if nodeClass.Status.Images != nil {
+		t.Error("expected nil images on error")
+	}
+}
+
+func TestImage_Reconcile_NewestPerArchitecture(t *testing.T) {
+	i := &Image{
+		imageProvider: &mockImageProvider{
+			listFn: func(_ context.Context, _ *api.TKEMachineNodeClass) ([]*cvm.Image, error) {
+				return []*cvm.Image{
+					{ImageId: lo.ToPtr("img-old"), ImageName: lo.ToPtr("old"), Architecture: lo.ToPtr("x86_64"), CreatedTime: lo.ToPtr("2024-01-01T00:00:00Z")},
+					{ImageId: lo.ToPtr("img-new"), ImageName: lo.ToPtr("new"), Architecture: lo.ToPtr("x86_64"), CreatedTime: lo.ToPtr("2025-01-01T00:00:00Z")},
+					{ImageId: lo.ToPtr("img-arm"), ImageName: lo.ToPtr("arm"), Architecture: lo.ToPtr("arm"), CreatedTime: lo.ToPtr("2024-06-01T00:00:00Z")},
+					{ImageId: lo.ToPtr("img-unknown"), Architecture: lo.ToPtr("i386"), CreatedTime: lo.ToPtr("2026-01-01T00:00:00Z")},
+				}, nil
+			},
+		},
+	}
+	nodeClass := imageNodeClass()
+	result, err := i.Reconcile(context.Background(), nodeClass)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(nodeClass.Status.Images) != 2 {
+		t.Fatalf("expected 2 images, got %v", nodeClass.Status.Images)
+	}
+	if nodeClass.Status.Images[0] != (api.Image{ID: "img-new", Name: "new", Architecture: "amd64"}) {
+		t.Errorf("expected newest amd64 image img-new, got %v", nodeClass.Status.Images[0])
+	}
+	if nodeClass.Status.Images[1] != (api.Image{ID: "img-arm", Name: "arm", Architecture: "arm64"}) {
+		t.Errorf("expected arm64 image img-arm, got %v", nodeClass.Status.Images[1])
+	}
+	if result.RequeueAfter != time.Minute {
+		t.Errorf("expected requeue after 1 minute, got %v", result.RequeueAfter)
+	}
+}
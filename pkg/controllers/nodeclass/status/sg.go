@@ -20,6 +20,7 @@ import (
 	"context"
 	"fmt"
 	"sort"
+	"strings"
 
 	"time"
 
@@ -38,20 +39,41 @@ type SecurityGroup struct {
 func (s *SecurityGroup) Reconcile(ctx context.Context, nodeClass *api.TKEMachineNodeClass) (reconcile.Result, error) {
 	securityGroups, err := s.vpcProvider.ListSecurityGroups(ctx, nodeClass)
 	if err != nil {
+		nodeClass.StatusConditions().SetFalse(api.ConditionTypeSecurityGroupsReady, api.ConditionReasonSecurityGroupsResolveFailed, err.Error())
 		return reconcile.Result{}, fmt.Errorf("getting securitygroups, %w", err)
 	}
 	if len(securityGroups) == 0 {
 		nodeClass.Status.SecurityGroups = nil
+		nodeClass.StatusConditions().SetFalse(api.ConditionTypeSecurityGroupsReady, api.ConditionReasonSecurityGroupsNotFound, "SecurityGroupSelector did not match any SecurityGroups")
 		return reconcile.Result{}, nil
 	}
 	sort.Slice(securityGroups, func(i, j int) bool {
+		pi := vpcprovider.SecurityGroupPriority(nodeClass.Spec.SecurityGroupSelectorTerms, securityGroups[i])
+		pj := vpcprovider.SecurityGroupPriority(nodeClass.Spec.SecurityGroupSelectorTerms, securityGroups[j])
+		if (pi == nil) != (pj == nil) {
+			return pi != nil
+		}
+		if pi != nil && *pi != *pj {
+			return *pi < *pj
+		}
 		return *securityGroups[i].SecurityGroupId < *securityGroups[j].SecurityGroupId
 	})
+	// only the first security groups are applied on the instances, the rest are ignored
+	if len(securityGroups) > vpcprovider.MaxSecurityGroups {
+		ignored := lo.Map(securityGroups[vpcprovider.MaxSecurityGroups:], func(sg *vpc.SecurityGroup, _ int) string { return lo.FromPtr(sg.SecurityGroupId) })
+		nodeClass.StatusConditions().SetFalse(api.ConditionTypeSecurityGroupsWithinLimit, api.ConditionReasonSecurityGroupsLimitExceeded,
+			fmt.Sprintf("SecurityGroupSelector matched %d SecurityGroups, more than the limit of %d of an instance, ignoring %s",
+				len(securityGroups), vpcprovider.MaxSecurityGroups, strings.Join(ignored, ", ")))
+		securityGroups = securityGroups[:vpcprovider.MaxSecurityGroups]
+	} else {
+		nodeClass.StatusConditions().SetTrue(api.ConditionTypeSecurityGroupsWithinLimit)
+	}
 	nodeClass.Status.SecurityGroups = lo.Map(securityGroups, func(sg *vpc.SecurityGroup, _ int) api.SecurityGroup {
 		return api.SecurityGroup{
 			ID: lo.FromPtr(sg.SecurityGroupId),
 		}
 	})
+	nodeClass.StatusConditions().SetTrue(api.ConditionTypeSecurityGroupsReady)
 
 	return reconcile.Result{RequeueAfter: time.Minute}, nil
 }
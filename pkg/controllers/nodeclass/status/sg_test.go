@@ -3,6 +3,7 @@ package status
 import (
 	"context"
 	"fmt"
+	"strings"
 	"testing"
 	"time"
 
@@ -72,6 +73,9 @@ func TestSecurityGroup_Reconcile_Empty(t *testing.T) {
 	if nodeClass.Status.SecurityGroups != nil {
 		t.Error("expected nil security groups when empty list returned")
 	}
+	if cond := nodeClass.StatusConditions().Get(api.ConditionTypeSecurityGroupsReady); !cond.IsFalse() || cond.Reason != api.ConditionReasonSecurityGroupsNotFound {
+		t.Errorf("expected SecurityGroupsReady condition to be false with reason SecurityGroupsNotFound, got %s/%s", cond.Status, cond.Reason)
+	}
 	if result.RequeueAfter != 0 {
 		t.Error("expected no requeue for empty result")
 	}
@@ -109,3 +113,60 @@ func TestSecurityGroup_Reconcile_Success(t *testing.T) {
 		t.Errorf("expected 1 minute requeue, got %v", result.RequeueAfter)
 	}
 }
+
+func TestSecurityGroup_Reconcile_PriorityAndLimit(t *testing.T) {
+	sg := &SecurityGroup{
+		vpcProvider: &mockVpcProvider{
+			listSecurityGroupsFn: func(_ context.Context, _ *api.TKEMachineNodeClass) ([]*vpc.SecurityGroup, error) {
+				return lo.Map([]string{"sg-1", "sg-2", "sg-3", "sg-4", "sg-5", "sg-6", "sg-7"}, func(id string, _ int) *vpc.SecurityGroup {
+					return &vpc.SecurityGroup{SecurityGroupId: lo.ToPtr(id)}
+				}), nil
+			},
+		},
+	}
+	nodeClass := &api.TKEMachineNodeClass{
+		ObjectMeta: metav1.ObjectMeta{Name: "test"},
+		Spec: api.TKEMachineNodeClassSpec{
+			SecurityGroupSelectorTerms: []api.SecurityGroupSelectorTerm{
+				{ID: "sg-7", Priority: lo.ToPtr[int32](0)},
+				{ID: "sg-6", Priority: lo.ToPtr[int32](1)},
+				{ID: "sg-5", Priority: lo.ToPtr[int32](1)},
+				{Tags: map[string]string{"karpenter": "*"}},
+			},
+		},
+	}
+	if _, err := sg.Reconcile(context.Background(), nodeClass); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ids := lo.Map(nodeClass.Status.SecurityGroups, func(s api.SecurityGroup, _ int) string { return s.ID })
+	if want := []string{"sg-7", "sg-5", "sg-6", "sg-1", "sg-2"}; fmt.Sprint(ids) != fmt.Sprint(want) {
+		t.Errorf("expected security groups %v, got %v", want, ids)
+	}
+	cond := nodeClass.StatusConditions().Get(api.ConditionTypeSecurityGroupsWithinLimit)
+	if !cond.IsFalse() || cond.Reason != api.ConditionReasonSecurityGroupsLimitExceeded {
+		t.Fatalf("expected SecurityGroupsWithinLimit condition to be false with reason SecurityGroupsLimitExceeded, got %s/%s", cond.Status, cond.Reason)
+	}
+	if !strings.Contains(cond.Message, "sg-3, sg-4") {
+		t.Errorf("expected the ignored security groups in the message, got %q", cond.Message)
+	}
+	if !nodeClass.StatusConditions().Get(api.ConditionTypeSecurityGroupsReady).IsTrue() {
+		t.Errorf("expected SecurityGroupsReady condition to be true")
+	}
+	if root := nodeClass.StatusConditions().Root(); strings.Contains(root.Message, api.ConditionTypeSecurityGroupsWithinLimit) {
+		t.Errorf("expected the limit not to affect the readiness, got %q", root.Message)
+	}
+
+	// the condition is cleared once the security groups are within the limit
+	nodeClass.Spec.SecurityGroupSelectorTerms = nodeClass.Spec.SecurityGroupSelectorTerms[:3]
+	sg.vpcProvider = &mockVpcProvider{
+		listSecurityGroupsFn: func(_ context.Context, _ *api.TKEMachineNodeClass) ([]*vpc.SecurityGroup, error) {
+			return []*vpc.SecurityGroup{{SecurityGroupId: lo.ToPtr("sg-5")}, {SecurityGroupId: lo.ToPtr("sg-6")}, {SecurityGroupId: lo.ToPtr("sg-7")}}, nil
+		},
+	}
+	if _, err := sg.Reconcile(context.Background(), nodeClass); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !nodeClass.StatusConditions().Get(api.ConditionTypeSecurityGroupsWithinLimit).IsTrue() {
+		t.Errorf("expected SecurityGroupsWithinLimit condition to be true")
+	}
+}
@@ -36,12 +36,20 @@ type SSHKey struct {
 }
 
 func (s *SSHKey) Reconcile(ctx context.Context, nodeClass *api.TKEMachineNodeClass) (reconcile.Result, error) {
+	// ssh keys are optional, the nodes are launched without them when no term is given
+	if len(nodeClass.Spec.SSHKeySelectorTerms) == 0 {
+		nodeClass.Status.SSHKeys = nil
+		nodeClass.StatusConditions().SetTrue(api.ConditionTypeSSHKeysReady)
+		return reconcile.Result{}, nil
+	}
 	sshkeys, err := s.sshKeyProvider.List(ctx, nodeClass)
 	if err != nil {
+		nodeClass.StatusConditions().SetFalse(api.ConditionTypeSSHKeysReady, api.ConditionReasonSSHKeysResolveFailed, err.Error())
 		return reconcile.Result{}, fmt.Errorf("getting ssh key pairs, %w", err)
 	}
 	if len(sshkeys) == 0 {
 		nodeClass.Status.SSHKeys = nil
+		nodeClass.StatusConditions().SetFalse(api.ConditionTypeSSHKeysReady, api.ConditionReasonSSHKeysNotFound, "SSHKeySelector did not match any SSHKeys")
 		return reconcile.Result{}, nil
 	}
 	sort.Slice(sshkeys, func(i, j int) bool {
@@ -52,6 +60,7 @@ func (s *SSHKey) Reconcile(ctx context.Context, nodeClass *api.TKEMachineNodeCla
 			ID: lo.FromPtr(k.KeyId),
 		}
 	})
+	nodeClass.StatusConditions().SetTrue(api.ConditionTypeSSHKeysReady)
 
 	return reconcile.Result{RequeueAfter: time.Minute}, nil
 }
@@ -33,6 +33,9 @@ func TestSSHKey_Reconcile_Error(t *testing.T) {
 	}
 	nodeClass := &api.TKEMachineNodeClass{
 		ObjectMeta: metav1.ObjectMeta{Name: "test"},
+		Spec: api.TKEMachineNodeClassSpec{
+			SSHKeySelectorTerms: []api.SSHKeySelectorTerm{{Tags: map[string]string{"team": "a"}}},
+		},
 	}
 	_, err := s.Reconcile(context.Background(), nodeClass)
 	if err == nil {
@@ -53,6 +56,9 @@ func TestSSHKey_Reconcile_Empty(t *testing.T) {
 	}
 	nodeClass := &api.TKEMachineNodeClass{
 		ObjectMeta: metav1.ObjectMeta{Name: "test"},
+		Spec: api.TKEMachineNodeClassSpec{
+			SSHKeySelectorTerms: []api.SSHKeySelectorTerm{{Tags: map[string]string{"team": "a"}}},
+		},
 		Status: api.TKEMachineNodeClassStatus{
 			SSHKeys: []api.SSHKey{{ID: "old"}},
 		},
@@ -64,11 +70,40 @@ func TestSSHKey_Reconcile_Empty(t *testing.T) {
 	if nodeClass.Status.SSHKeys != nil {
 		t.Error("expected nil ssh keys when empty list returned")
 	}
+	if cond := nodeClass.StatusConditions().Get(api.ConditionTypeSSHKeysReady); !cond.IsFalse() || cond.Reason != api.ConditionReasonSSHKeysNotFound {
+		t.Errorf("expected SSHKeysReady condition to be false with reason SSHKeysNotFound, got %s/%s", cond.Status, cond.Reason)
+	}
 	if result.RequeueAfter != 0 {
 		t.Error("expected no requeue for empty result")
 	}
 }
 
+func TestSSHKey_Reconcile_NoTerms(t *testing.T) {
+	s := &SSHKey{
+		sshKeyProvider: &mockSSHKeyProvider{
+			listFn: func(_ context.Context, _ *api.TKEMachineNodeClass) ([]*cvm.KeyPair, error) {
+				t.Fatal("expected no ssh key lookup without selector terms")
+				return nil, nil
+			},
+		},
+	}
+	nodeClass := &api.TKEMachineNodeClass{
+		ObjectMeta: metav1.ObjectMeta{Name: "test"},
+		Status: api.TKEMachineNodeClassStatus{
+			SSHKeys: []api.SSHKey{{ID: "old"}},
+		},
+	}
+	if _, err := s.Reconcile(context.Background(), nodeClass); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if nodeClass.Status.SSHKeys != nil {
+		t.Error("expected nil ssh keys without selector terms")
+	}
+	if cond := nodeClass.StatusConditions().Get(api.ConditionTypeSSHKeysReady); !cond.IsTrue() {
+		t.Errorf("expected SSHKeysReady condition to be true without selector terms, got %s/%s", cond.Status, cond.Reason)
+	}
+}
+
 func TestSSHKey_Reconcile_Success(t *testing.T) {
 	s := &SSHKey{
 		sshKeyProvider: &mockSSHKeyProvider{
@@ -82,6 +117,9 @@ func TestSSHKey_Reconcile_Success(t *testing.T) {
 	}
 	nodeClass := &api.TKEMachineNodeClass{
 		ObjectMeta: metav1.ObjectMeta{Name: "test"},
+		Spec: api.TKEMachineNodeClassSpec{
+			SSHKeySelectorTerms: []api.SSHKeySelectorTerm{{Tags: map[string]string{"team": "a"}}},
+		},
 	}
 	result, err := s.Reconcile(context.Background(), nodeClass)
 	if err != nil {
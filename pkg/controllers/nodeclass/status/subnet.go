@@ -40,10 +40,12 @@ type Subnet struct {
 func (s *Subnet) Reconcile(ctx context.Context, nodeClass *api.TKEMachineNodeClass) (reconcile.Result, error) {
 	subnets, err := s.vpcProvider.ListSubnets(ctx, nodeClass)
 	if err != nil {
+		nodeClass.StatusConditions().SetFalse(api.ConditionTypeSubnetsReady, api.ConditionReasonSubnetsResolveFailed, err.Error())
 		return reconcile.Result{}, fmt.Errorf("getting subnets, %w", err)
 	}
 	if len(subnets) == 0 {
 		nodeClass.Status.Subnets = nil
+		nodeClass.StatusConditions().SetFalse(api.ConditionTypeSubnetsReady, api.ConditionReasonSubnetsNotFound, "SubnetSelector did not match any Subnets")
 		return reconcile.Result{}, nil
 	}
 	sort.Slice(subnets, func(i, j int) bool {
@@ -55,11 +57,14 @@ func (s *Subnet) Reconcile(ctx context.Context, nodeClass *api.TKEMachineNodeCla
 	nodeClass.Status.Subnets = lo.Map(subnets, func(vpcsubnet *vpc.Subnet, _ int) api.Subnet {
 		zoneID, _ := s.zoneProvider.IDFromZone(lo.FromPtr(vpcsubnet.Zone))
 		return api.Subnet{
-			ID:     lo.FromPtr(vpcsubnet.SubnetId),
-			Zone:   lo.FromPtr(vpcsubnet.Zone),
-			ZoneID: zoneID,
+			ID:                      lo.FromPtr(vpcsubnet.SubnetId),
+			Zone:                    lo.FromPtr(vpcsubnet.Zone),
+			ZoneID:                  zoneID,
+			IPv6CIDR:                lo.FromPtr(vpcsubnet.Ipv6CidrBlock),
+			AvailableIPAddressCount: lo.ToPtr(int64(lo.FromPtr(vpcsubnet.AvailableIpAddressCount))),
 		}
 	})
+	nodeClass.StatusConditions().SetTrue(api.ConditionTypeSubnetsReady)
 
 	return reconcile.Result{RequeueAfter: time.Minute}, nil
 }
@@ -74,6 +74,9 @@ func TestSubnet_Reconcile_Empty(t *testing.T) {
 	if nodeClass.Status.Subnets != nil {
 		t.Error("expected nil subnets when empty list returned")
 	}
+	if cond := nodeClass.StatusConditions().Get(api.ConditionTypeSubnetsReady); !cond.IsFalse() || cond.Reason != api.ConditionReasonSubnetsNotFound {
+		t.Errorf("expected SubnetsReady condition to be false with reason SubnetsNotFound, got %s/%s", cond.Status, cond.Reason)
+	}
 	if result.RequeueAfter != 0 {
 		t.Error("expected no requeue for empty result")
 	}
@@ -145,7 +148,39 @@ func TestSubnet_Reconcile_Success(t *testing.T) {
 	if nodeClass.Status.Subnets[0].ZoneID != "100004" {
 		t.Errorf("expected zoneID 100004 for subnet-aaa, got %s", nodeClass.Status.Subnets[0].ZoneID)
 	}
+	if lo.FromPtr(nodeClass.Status.Subnets[0].AvailableIPAddressCount) != 100 {
+		t.Errorf("expected 100 available IPs for subnet-aaa, got %v", nodeClass.Status.Subnets[0].AvailableIPAddressCount)
+	}
 	if result.RequeueAfter != time.Minute {
 		t.Errorf("expected 1 minute requeue, got %v", result.RequeueAfter)
 	}
 }
+
+func TestSubnet_Reconcile_IPv6CIDR(t *testing.T) {
+	s := &Subnet{
+		zoneProvider: &mockSubnetZoneProvider{},
+		vpcProvider: &mockVpcProvider{
+			listSubnetsFn: func(_ context.Context, _ *api.TKEMachineNodeClass) ([]*vpc.Subnet, error) {
+				return []*vpc.Subnet{
+					{
+						SubnetId:      lo.ToPtr("subnet-aaa"),
+						Zone:          lo.ToPtr("ap-guangzhou-3"),
+						Ipv6CidrBlock: lo.ToPtr("2402:4e00:1000:100::/64"),
+					},
+				}, nil
+			},
+		},
+	}
+	nodeClass := &api.TKEMachineNodeClass{
+		ObjectMeta: metav1.ObjectMeta{Name: "test"},
+	}
+	if _, err := s.Reconcile(context.Background(), nodeClass); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(nodeClass.Status.Subnets) != 1 {
+		t.Fatalf("expected 1 subnet, got %d", len(nodeClass.Status.Subnets))
+	}
+	if nodeClass.Status.Subnets[0].IPv6CIDR != "2402:4e00:1000:100::/64" {
+		t.Errorf("expected IPv6 CIDR 2402:4e00:1000:100::/64, got %q", nodeClass.Status.Subnets[0].IPv6CIDR)
+	}
+}
@@ -0,0 +1,69 @@
+/*
+Copyright (C) 2012-2025 Tencent. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package status
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	api "github.com/tencentcloud/karpenter-provider-tke/pkg/apis/v1beta1"
+	machineprovider "github.com/tencentcloud/karpenter-provider-tke/pkg/providers/machine"
+	validationprovider "github.com/tencentcloud/karpenter-provider-tke/pkg/providers/validation"
+)
+
+type Validation struct {
+	machineProvider    machineprovider.Provider
+	validationProvider validationprovider.Provider
+}
+
+// Reconcile checks the launch parameters of the nodeClass, the nodes are launched only if the validation succeeded.
+func (v *Validation) Reconcile(ctx context.Context, nodeClass *api.TKEMachineNodeClass) (reconcile.Result, error) {
+	if len(nodeClass.Spec.ImageSelectorTerms) != 0 && len(nodeClass.Status.Images) == 0 {
+		nodeClass.StatusConditions().SetFalse(api.ConditionTypeValidationSucceeded, api.ConditionReasonImagesNotFound, "ImageSelector did not match any Images")
+		return reconcile.Result{}, nil
+	}
+	if len(nodeClass.Spec.HPCClusterSelectorTerms) != 0 && len(nodeClass.Status.HPCClusters) == 0 {
+		nodeClass.StatusConditions().SetFalse(api.ConditionTypeValidationSucceeded, api.ConditionReasonHPCClustersNotFound, "HPCClusterSelector did not match any HPCClusters")
+		return reconcile.Result{}, nil
+	}
+	if err := v.machineProvider.ValidateLifecycleScript(ctx, nodeClass); err != nil {
+		nodeClass.StatusConditions().SetFalse(api.ConditionTypeValidationSucceeded, api.ConditionReasonLifecycleScriptInvalid, err.Error())
+		return reconcile.Result{}, nil
+	}
+	for _, check := range []struct {
+		reason   string
+		validate func(context.Context, *api.TKEMachineNodeClass) (string, error)
+	}{
+		{reason: api.ConditionReasonDiskTypesUnsupported, validate: v.validationProvider.ValidateDiskTypes},
+		{reason: api.ConditionReasonKMSKeysInvalid, validate: v.validationProvider.ValidateKMSKeys},
+		{reason: api.ConditionReasonBandwidthPackageNotFound, validate: v.validationProvider.ValidateBandwidthPackage},
+	} {
+		message, err := check.validate(ctx, nodeClass)
+		if err != nil {
+			return reconcile.Result{}, fmt.Errorf("validating launch parameters, %w", err)
+		}
+		if message != "" {
+			nodeClass.StatusConditions().SetFalse(api.ConditionTypeValidationSucceeded, check.reason, message)
+			return reconcile.Result{RequeueAfter: time.Minute}, nil
+		}
+	}
+	nodeClass.StatusConditions().SetTrue(api.ConditionTypeValidationSucceeded)
+	return reconcile.Result{RequeueAfter: time.Minute}, nil
+}
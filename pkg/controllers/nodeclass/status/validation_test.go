@@ -0,0 +1,254 @@
+package status
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/awslabs/operatorpkg/status"
+	api "github.com/tencentcloud/karpenter-provider-tke/pkg/apis/v1beta1"
+	capiv1beta1 "github.com/tencentcloud/karpenter-provider-tke/staging/nativenode/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+)
+
+// mockMachineProvider implements machine.Provider, only the lifecycle script validation is used by the reconcilers.
+type mockMachineProvider struct {
+	validateLifecycleScriptFn func(context.Context, *api.TKEMachineNodeClass) error
+}
+
+func (m *mockMachineProvider) Get(_ context.Context, _ string) (*capiv1beta1.Machine, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
+func (m *mockMachineProvider) List(_ context.Context) ([]*capiv1beta1.Machine, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
+func (m *mockMachineProvider) Create(_ context.Context, _ *api.TKEMachineNodeClass, _ *v1.NodeClaim, _ []*cloudprovider.InstanceType) (*capiv1beta1.Machine, *capiv1beta1.CXMMachineProviderSpec, error) {
+	return nil, nil, fmt.Errorf("not implemented")
+}
+
+func (m *mockMachineProvider) Delete(_ context.Context, _ *v1.NodeClaim) error {
+	return fmt.Errorf("not implemented")
+}
+
+func (m *mockMachineProvider) ValidateLifecycleScript(ctx context.Context, nc *api.TKEMachineNodeClass) error {
+	if m.validateLifecycleScriptFn != nil {
+		return m.validateLifecycleScriptFn(ctx, nc)
+	}
+	return nil
+}
+
+type mockValidationProvider struct {
+	diskTypesFn        func(context.Context, *api.TKEMachineNodeClass) (string, error)
+	kmsKeysFn          func(context.Context, *api.TKEMachineNodeClass) (string, error)
+	bandwidthPackageFn func(context.Context, *api.TKEMachineNodeClass) (string, error)
+}
+
+func (m *mockValidationProvider) ValidateDiskTypes(ctx context.Context, nc *api.TKEMachineNodeClass) (string, error) {
+	if m.diskTypesFn != nil {
+		return m.diskTypesFn(ctx, nc)
+	}
+	return "", nil
+}
+
+func (m *mockValidationProvider) ValidateKMSKeys(ctx context.Context, nc *api.TKEMachineNodeClass) (string, error) {
+	if m.kmsKeysFn != nil {
+		return m.kmsKeysFn(ctx, nc)
+	}
+	return "", nil
+}
+
+func (m *mockValidationProvider) ValidateBandwidthPackage(ctx context.Context, nc *api.TKEMachineNodeClass) (string, error) {
+	if m.bandwidthPackageFn != nil {
+		return m.bandwidthPackageFn(ctx, nc)
+	}
+	return "", nil
+}
+
+func newValidation() *Validation {
+	return &Validation{machineProvider: &mockMachineProvider{}, validationProvider: &mockValidationProvider{}}
+}
+
+// resolvedNodeClass returns a nodeClass whose subnets, security groups and ssh keys were resolved.
+func resolvedNodeClass() *api.TKEMachineNodeClass {
+	nodeClass := &api.TKEMachineNodeClass{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       "test",
+			Generation: 1,
+		},
+		Status: api.TKEMachineNodeClassStatus{
+			Subnets:        []api.Subnet{{ID: "subnet-123", Zone: "ap-guangzhou-3"}},
+			SecurityGroups: []api.SecurityGroup{{ID: "sg-123"}},
+			SSHKeys:        []api.SSHKey{{ID: "skey-123"}},
+		},
+	}
+	nodeClass.StatusConditions().SetTrue(api.ConditionTypeSubnetsReady)
+	nodeClass.StatusConditions().SetTrue(api.ConditionTypeSecurityGroupsReady)
+	nodeClass.StatusConditions().SetTrue(api.ConditionTypeSSHKeysReady)
+	return nodeClass
+}
+
+func TestValidation_Reconcile_NoSubnets(t *testing.T) {
+	r := newValidation()
+	nodeClass := resolvedNodeClass()
+	nodeClass.Status.Subnets = nil
+	nodeClass.StatusConditions().SetFalse(api.ConditionTypeSubnetsReady, api.ConditionReasonSubnetsNotFound, "")
+	_, err := r.Reconcile(context.Background(), nodeClass)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cond := nodeClass.StatusConditions().Get(status.ConditionReady)
+	if cond.IsTrue() {
+		t.Error("expected Ready condition to be false when no subnets")
+	}
+}
+
+func TestValidation_Reconcile_NoSecurityGroups(t *testing.T) {
+	r := newValidation()
+	nodeClass := resolvedNodeClass()
+	nodeClass.Status.SecurityGroups = nil
+	nodeClass.StatusConditions().SetFalse(api.ConditionTypeSecurityGroupsReady, api.ConditionReasonSecurityGroupsNotFound, "")
+	_, err := r.Reconcile(context.Background(), nodeClass)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cond := nodeClass.StatusConditions().Get(status.ConditionReady)
+	if cond.IsTrue() {
+		t.Error("expected Ready condition to be false when no security groups")
+	}
+}
+
+func TestValidation_Reconcile_AllReady(t *testing.T) {
+	r := newValidation()
+	nodeClass := resolvedNodeClass()
+	_, err := r.Reconcile(context.Background(), nodeClass)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !nodeClass.StatusConditions().Get(api.ConditionTypeValidationSucceeded).IsTrue() {
+		t.Error("expected ValidationSucceeded condition to be true")
+	}
+	cond := nodeClass.StatusConditions().Get(status.ConditionReady)
+	if !cond.IsTrue() {
+		t.Error("expected Ready condition to be true when subnets and security groups are present")
+	}
+}
+
+func TestValidation_Reconcile_NotFound(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		spec   api.TKEMachineNodeClassSpec
+		reason string
+	}{
+		{
+			name:   "images",
+			spec:   api.TKEMachineNodeClassSpec{ImageSelectorTerms: []api.ImageSelectorTerm{{Name: "my-image"}}},
+			reason: api.ConditionReasonImagesNotFound,
+		},
+		{
+			name:   "hpc clusters",
+			spec:   api.TKEMachineNodeClassSpec{HPCClusterSelectorTerms: []api.HPCClusterSelectorTerm{{ID: "hpc-123"}}},
+			reason: api.ConditionReasonHPCClustersNotFound,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			r := newValidation()
+			nodeClass := resolvedNodeClass()
+			nodeClass.Spec = tc.spec
+			_, err := r.Reconcile(context.Background(), nodeClass)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			cond := nodeClass.StatusConditions().Get(api.ConditionTypeValidationSucceeded)
+			if !cond.IsFalse() || cond.Reason != tc.reason {
+				t.Errorf("expected ValidationSucceeded to be false with reason %s, got %s/%s", tc.reason, cond.Status, cond.Reason)
+			}
+			if nodeClass.StatusConditions().Get(status.ConditionReady).IsTrue() {
+				t.Errorf("expected Ready condition to be false when %s are selected but not resolved", tc.name)
+			}
+		})
+	}
+}
+
+func TestValidation_Reconcile_LifecycleScriptInvalid(t *testing.T) {
+	r := &Validation{
+		machineProvider: &mockMachineProvider{
+			validateLifecycleScriptFn: func(_ context.Context, _ *api.TKEMachineNodeClass) error {
+				return fmt.Errorf("rendering post-init script, getting secret scripts, not found")
+			},
+		},
+		validationProvider: &mockValidationProvider{},
+	}
+	nodeClass := resolvedNodeClass()
+	if _, err := r.Reconcile(context.Background(), nodeClass); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cond := nodeClass.StatusConditions().Get(api.ConditionTypeValidationSucceeded)
+	if !cond.IsFalse() || cond.Reason != api.ConditionReasonLifecycleScriptInvalid {
+		t.Errorf("expected ValidationSucceeded to be false with reason %s, got %s/%s", api.ConditionReasonLifecycleScriptInvalid, cond.Status, cond.Reason)
+	}
+}
+
+func TestValidation_Reconcile_LaunchParametersInvalid(t *testing.T) {
+	invalid := func(message string) func(context.Context, *api.TKEMachineNodeClass) (string, error) {
+		return func(_ context.Context, _ *api.TKEMachineNodeClass) (string, error) { return message, nil }
+	}
+	for _, tc := range []struct {
+		name     string
+		provider *mockValidationProvider
+		reason   string
+	}{
+		{
+			name:     "disk types",
+			provider: &mockValidationProvider{diskTypesFn: invalid("disk types are not sold: CLOUD_SSD data disk in ap-guangzhou-3")},
+			reason:   api.ConditionReasonDiskTypesUnsupported,
+		},
+		{
+			name:     "kms keys",
+			provider: &mockValidationProvider{kmsKeysFn: invalid("kms key key-1 is notfound")},
+			reason:   api.ConditionReasonKMSKeysInvalid,
+		},
+		{
+			name:     "bandwidth package",
+			provider: &mockValidationProvider{bandwidthPackageFn: invalid("bandwidth package bwp-1 is not found")},
+			reason:   api.ConditionReasonBandwidthPackageNotFound,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			r := &Validation{machineProvider: &mockMachineProvider{}, validationProvider: tc.provider}
+			nodeClass := resolvedNodeClass()
+			if _, err := r.Reconcile(context.Background(), nodeClass); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			cond := nodeClass.StatusConditions().Get(api.ConditionTypeValidationSucceeded)
+			if !cond.IsFalse() || cond.Reason != tc.reason {
+				t.Errorf("expected ValidationSucceeded to be false with reason %s, got %s/%s", tc.reason, cond.Status, cond.Reason)
+			}
+			if nodeClass.StatusConditions().Get(status.ConditionReady).IsTrue() {
+				t.Error("expected Ready condition to be false")
+			}
+		})
+	}
+}
+
+func TestValidation_Reconcile_Error(t *testing.T) {
+	r := &Validation{
+		machineProvider: &mockMachineProvider{},
+		validationProvider: &mockValidationProvider{
+			diskTypesFn: func(_ context.Context, _ *api.TKEMachineNodeClass) (string, error) {
+				return "", fmt.Errorf("describe disk config quota failed")
+			},
+		},
+	}
+	nodeClass := resolvedNodeClass()
+	nodeClass.StatusConditions().SetTrue(api.ConditionTypeValidationSucceeded)
+	if _, err := r.Reconcile(context.Background(), nodeClass); err == nil {
+		t.Fatal("expected error when the validation request fails")
+	}
+	if !nodeClass.StatusConditions().Get(api.ConditionTypeValidationSucceeded).IsTrue() {
+		t.Error("expected ValidationSucceeded to be unchanged when the validation request fails")
+	}
+}
@@ -24,12 +24,19 @@ import (
 
 	"github.com/patrickmn/go-cache"
 	"github.com/samber/lo"
+	"github.com/tencentcloud/karpenter-provider-tke/pkg/apicache"
 	"github.com/tencentcloud/karpenter-provider-tke/pkg/apis"
 	api "github.com/tencentcloud/karpenter-provider-tke/pkg/apis/v1beta1"
 	"github.com/tencentcloud/karpenter-provider-tke/pkg/operator/options"
+	"github.com/tencentcloud/karpenter-provider-tke/pkg/providers/eip"
+	"github.com/tencentcloud/karpenter-provider-tke/pkg/providers/hpccluster"
+	"github.com/tencentcloud/karpenter-provider-tke/pkg/providers/image"
+	"github.com/tencentcloud/karpenter-provider-tke/pkg/providers/instance"
 	"github.com/tencentcloud/karpenter-provider-tke/pkg/providers/instancetype"
 	"github.com/tencentcloud/karpenter-provider-tke/pkg/providers/machine"
 	"github.com/tencentcloud/karpenter-provider-tke/pkg/providers/sshkey"
+	"github.com/tencentcloud/karpenter-provider-tke/pkg/providers/validation"
+	"github.com/tencentcloud/karpenter-provider-tke/pkg/providers/version"
 	"github.com/tencentcloud/karpenter-provider-tke/pkg/providers/vpc"
 	"github.com/tencentcloud/karpenter-provider-tke/pkg/providers/zone"
 	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common"
@@ -55,6 +62,12 @@ type Operator struct {
 	ZoneProvider         zone.Provider
 	VPCProvider          vpc.Provider
 	SSHKeyProvider       sshkey.Provider
+	ImageProvider        image.Provider
+	HPCClusterProvider   hpccluster.Provider
+	VersionProvider      version.Provider
+	EIPProvider          eip.Provider
+	InstanceProvider     instance.Provider
+	ValidationProvider   validation.Provider
 }
 
 func NewOperator(ctx context.Context, operator *operator.Operator) (context.Context, *Operator) {
@@ -84,10 +97,18 @@ func NewOperator(ctx context.Context, operator *operator.Operator) (context.Cont
 		log.Panicf("DescribeClusters failed: no cluster found")
 	}
 	zoneProvider := zone.NewDefaultProvider(ctx)
-	vpcProvider := vpc.NewDefaultProvider(ctx, vpcClient, lo.FromPtr(resp.Response.Clusters[0].ClusterNetworkSettings.VpcId))
-	sshKeyProvider := sshkey.NewDefaultProvider(ctx, cvmClient)
+	apiCache := apicache.New(apicache.DefaultTTL, apicache.DefaultCleanupInterval)
+	vpcProvider := vpc.NewDefaultProvider(ctx, vpcClient, lo.FromPtr(resp.Response.Clusters[0].ClusterNetworkSettings.VpcId),
+		instancetype.NewClusterProperty(resp.Response.Clusters[0]).IsDualStack, apiCache)
+	sshKeyProvider := sshkey.NewDefaultProvider(ctx, cvmClient, apiCache)
+	imageProvider := image.NewDefaultProvider(ctx, cvmClient)
+	hpcClusterProvider := hpccluster.NewDefaultProvider(ctx, options.FromContext(ctx).Region, cvmClient, commonClient)
+	eipProvider := eip.NewDefaultProvider(ctx, vpcClient)
+	instanceProvider := instance.NewDefaultProvider(ctx, cvmClient)
+	validationProvider := validation.NewDefaultProvider(ctx, vpcClient, commonClient, cache.New(5*time.Minute, time.Minute))
+	versionProvider := version.NewDefaultProvider(ctx, operator.KubernetesInterface, cache.New(5*time.Minute, time.Minute))
 
-	machineProvider := machine.NewDefaultProvider(ctx, operator.GetClient(), zoneProvider, options.FromContext(ctx).ClusterID)
+	machineProvider := machine.NewDefaultProvider(ctx, operator.KubernetesInterface, operator.GetClient(), zoneProvider, options.FromContext(ctx).ClusterID)
 	instanceTypeProvider := instancetype.NewDefaultProvider(ctx, options.FromContext(ctx).Region, operator.KubernetesInterface, operator.GetClient(), zoneProvider, commonClient, client2018, cache.New(10*time.Minute, time.Minute), cache.New(30*time.Minute, time.Minute))
 
 	return ctx, &Operator{
@@ -97,5 +118,11 @@ func NewOperator(ctx context.Context, operator *operator.Operator) (context.Cont
 		ZoneProvider:         zoneProvider,
 		VPCProvider:          vpcProvider,
 		SSHKeyProvider:       sshKeyProvider,
+		ImageProvider:        imageProvider,
+		HPCClusterProvider:   hpcClusterProvider,
+		VersionProvider:      versionProvider,
+		EIPProvider:          eipProvider,
+		InstanceProvider:     instanceProvider,
+		ValidationProvider:   validationProvider,
 	}
 }
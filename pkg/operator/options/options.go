@@ -40,6 +40,8 @@ type Options struct {
 	SecretID                string
 	SecretKey               string
 	VMMemoryOverheadPercent float64
+	SystemNamespace         string
+	APIKeySecretName        string
 }
 
 func (o *Options) AddFlags(fs *coreoptions.FlagSet) {
@@ -48,6 +50,8 @@ func (o *Options) AddFlags(fs *coreoptions.FlagSet) {
 	fs.StringVar(&o.SecretID, "secret-id", env.WithDefaultString("SECRET_ID", ""), "[REQUIRED] Secret id to access tencentcloud")
 	fs.StringVar(&o.SecretKey, "secret-key", env.WithDefaultString("SECRET_KEY", ""), "[REQUIRED] Secret key to access tencentcloud")
 	fs.Float64Var(&o.VMMemoryOverheadPercent, "vm-memory-overhead-percent", util.WithDefaultFloat64("VM_MEMORY_OVERHEAD_PERCENT", 0.075), "The VM memory overhead as a percent that will be subtracted from the total memory for all instance types.")
+	fs.StringVar(&o.SystemNamespace, "system-namespace", env.WithDefaultString("SYSTEM_NAMESPACE", "karpenter"), "The namespace of karpenter, the Secrets and ConfigMaps of the lifecycle scripts are read from it.")
+	fs.StringVar(&o.APIKeySecretName, "api-key-secret-name", env.WithDefaultString("API_KEY_SECRET_NAME", ""), "The Secret holding the secret id and key, it is never read by the lifecycle scripts.")
 }
 
 func (o *Options) Parse(fs *coreoptions.FlagSet, args ...string) error {
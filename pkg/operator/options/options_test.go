@@ -147,7 +147,7 @@ func TestAddFlags(t *testing.T) {
 	o.AddFlags(fs)
 
 	// Verify flags are registered
-	for _, name := range []string{"region", "cluster-id", "secret-id", "secret-key", "vm-memory-overhead-percent"} {
+	for _, name := range []string{"region", "cluster-id", "secret-id", "secret-key", "vm-memory-overhead-percent", "system-namespace"} {
 		if fs.Lookup(name) == nil {
 			t.Errorf("expected flag %q to be registered", name)
 		}
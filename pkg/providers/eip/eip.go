@@ -0,0 +1,116 @@
+/*
+Copyright (C) 2012-2025 Tencent. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package eip
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/samber/lo"
+	api "github.com/tencentcloud/karpenter-provider-tke/pkg/apis/v1beta1"
+	vpc2017 "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/vpc/v20170312"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	// describeLimit is the maximum page size of DescribeAddresses
+	describeLimit = 100
+
+	addressStatusBind   = "BIND"
+	addressStatusUnbind = "UNBIND"
+)
+
+type Provider interface {
+	List(context.Context, *api.TKEMachineNodeClass) ([]*vpc2017.Address, error)
+	Disassociate(ctx context.Context, addressID, instanceID string) error
+}
+
+type DefaultProvider struct {
+	client *vpc2017.Client
+}
+
+func NewDefaultProvider(_ context.Context, client *vpc2017.Client) *DefaultProvider {
+	return &DefaultProvider{
+		client: client,
+	}
+}
+
+// List returns the unassociated EIPs of the address pool of the TKEMachineNodeClass.
+func (p *DefaultProvider) List(ctx context.Context, nodeClass *api.TKEMachineNodeClass) ([]*vpc2017.Address, error) {
+	filters := getFilters(lo.FromPtr(nodeClass.Spec.InternetAccessible).AddressPoolTags)
+	if len(filters) == 0 {
+		return []*vpc2017.Address{}, nil
+	}
+	filters = append(filters, &vpc2017.Filter{Name: lo.ToPtr("address-status"), Values: []*string{lo.ToPtr(addressStatusUnbind)}})
+	var addresses []*vpc2017.Address
+	for offset := int64(0); ; offset += describeLimit {
+		req := vpc2017.NewDescribeAddressesRequest()
+		req.Filters = filters
+		req.Offset = lo.ToPtr(offset)
+		req.Limit = lo.ToPtr(int64(describeLimit))
+		resp, err := p.client.DescribeAddresses(req)
+		if err != nil {
+			return nil, fmt.Errorf("describe addresses failed: %v", err)
+		}
+		log.FromContext(ctx).WithValues("process", "listeip").V(1).Info("tencent cloud request", "action", req.GetAction(), "requestID", resp.Response.RequestId)
+		addresses = append(addresses, resp.Response.AddressSet...)
+		if len(resp.Response.AddressSet) < describeLimit || offset+describeLimit >= lo.FromPtr(resp.Response.TotalCount) {
+			return addresses, nil
+		}
+	}
+}
+
+// Disassociate returns the EIP to the address pool if it is still associated with the instance.
+func (p *DefaultProvider) Disassociate(ctx context.Context, addressID, instanceID string) error {
+	req := vpc2017.NewDescribeAddressesRequest()
+	req.AddressIds = []*string{lo.ToPtr(addressID)}
+	resp, err := p.client.DescribeAddresses(req)
+	if err != nil {
+		return fmt.Errorf("describe addresses failed: %v", err)
+	}
+	log.FromContext(ctx).WithValues("process", "disassociateeip").V(1).Info("tencent cloud request", "action", req.GetAction(), "requestID", resp.Response.RequestId)
+	if !lo.ContainsBy(resp.Response.AddressSet, func(a *vpc2017.Address) bool {
+		return lo.FromPtr(a.AddressStatus) == addressStatusBind && lo.FromPtr(a.InstanceId) == instanceID
+	}) {
+		return nil
+	}
+	disassociateReq := vpc2017.NewDisassociateAddressRequest()
+	disassociateReq.AddressId = lo.ToPtr(addressID)
+	disassociateResp, err := p.client.DisassociateAddress(disassociateReq)
+	if err != nil {
+		return fmt.Errorf("disassociate address failed: %v", err)
+	}
+	log.FromContext(ctx).WithValues("process", "disassociateeip").V(1).Info("tencent cloud request", "action", disassociateReq.GetAction(), "requestID", disassociateResp.Response.RequestId)
+	return nil
+}
+
+func getFilters(tags map[string]string) (res []*vpc2017.Filter) {
+	for k, v := range tags {
+		if v == "*" {
+			res = append(res, &vpc2017.Filter{
+				Name:   lo.ToPtr("tag-key"),
+				Values: []*string{lo.ToPtr(k)},
+			})
+		} else {
+			res = append(res, &vpc2017.Filter{
+				Name:   lo.ToPtr(fmt.Sprintf("tag:%s", k)),
+				Values: []*string{lo.ToPtr(v)},
+			})
+		}
+	}
+	return res
+}
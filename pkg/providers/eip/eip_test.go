@@ -0,0 +1,36 @@
+package eip
+
+import (
+	"context"
+	"testing"
+
+	"github.com/samber/lo"
+	api "github.com/tencentcloud/karpenter-provider-tke/pkg/apis/v1beta1"
+)
+
+func TestGetFilters(t *testing.T) {
+	filters := getFilters(map[string]string{"pool": "egress"})
+	if len(filters) != 1 || lo.FromPtr(filters[0].Name) != "tag:pool" || lo.FromPtr(filters[0].Values[0]) != "egress" {
+		t.Errorf("expected tag:pool=egress filter, got %v", filters)
+	}
+	filters = getFilters(map[string]string{"pool": "*"})
+	if len(filters) != 1 || lo.FromPtr(filters[0].Name) != "tag-key" || lo.FromPtr(filters[0].Values[0]) != "pool" {
+		t.Errorf("expected tag-key=pool filter, got %v", filters)
+	}
+}
+
+func TestList_NoAddressPool(t *testing.T) {
+	p := NewDefaultProvider(context.Background(), nil)
+	nodeClass := &api.TKEMachineNodeClass{
+		Spec: api.TKEMachineNodeClassSpec{
+			InternetAccessible: &api.InternetAccessible{AddressType: lo.ToPtr(api.AddressTypeEIP)},
+		},
+	}
+	addresses, err := p.List(context.Background(), nodeClass)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(addresses) != 0 {
+		t.Errorf("expected no addresses, got %d", len(addresses))
+	}
+}
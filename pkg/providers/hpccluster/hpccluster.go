@@ -0,0 +1,167 @@
+/*
+Copyright (C) 2012-2025 Tencent. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hpccluster
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/samber/lo"
+	api "github.com/tencentcloud/karpenter-provider-tke/pkg/apis/v1beta1"
+	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common"
+	tchttp "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common/http"
+	cvm2017 "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/cvm/v20170312"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	// describeLimit is the maximum page size of DescribeHpcClusters and DescribeResourcesByTags
+	describeLimit = 100
+)
+
+type Provider interface {
+	List(context.Context, *api.TKEMachineNodeClass) ([]*cvm2017.HpcClusterInfo, error)
+}
+
+type DefaultProvider struct {
+	region       string
+	client       *cvm2017.Client
+	commonClient *common.Client
+}
+
+func NewDefaultProvider(_ context.Context, region string, client *cvm2017.Client, commonClient *common.Client) *DefaultProvider {
+	return &DefaultProvider{
+		region:       region,
+		client:       client,
+		commonClient: commonClient,
+	}
+}
+
+func (p *DefaultProvider) List(ctx context.Context, nodeClass *api.TKEMachineNodeClass) ([]*cvm2017.HpcClusterInfo, error) {
+	ids, tagFilterSets := getFilterSets(nodeClass.Spec.HPCClusterSelectorTerms)
+	// DescribeHpcClusters doesn't support tag filters, the HPC clusters are looked up by tags first
+	for _, tagFilters := range tagFilterSets {
+		tagged, err := p.listIDsByTags(ctx, tagFilters)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, tagged...)
+	}
+	ids = lo.Uniq(ids)
+	if len(ids) == 0 {
+		return []*cvm2017.HpcClusterInfo{}, nil
+	}
+
+	var clusters []*cvm2017.HpcClusterInfo
+	for _, chunk := range lo.Chunk(ids, describeLimit) {
+		req := cvm2017.NewDescribeHpcClustersRequest()
+		req.HpcClusterIds = lo.ToSlicePtr(chunk)
+		req.Limit = lo.ToPtr(uint64(describeLimit))
+		resp, err := p.client.DescribeHpcClusters(req)
+		if err != nil {
+			return nil, fmt.Errorf("describe hpc clusters failed: %v", err)
+		}
+		log.FromContext(ctx).WithValues("process", "listhpccluster").V(1).Info("tencent cloud request", "action", req.GetAction(), "requestID", resp.Response.RequestId)
+		clusters = append(clusters, resp.Response.HpcClusterSet...)
+	}
+	return clusters, nil
+}
+
+type TagFilter struct {
+	TagKey   string   `json:"TagKey"`
+	TagValue []string `json:"TagValue,omitempty"`
+}
+
+type DescribeResourcesByTagsRequest struct {
+	TagFilters     []TagFilter `json:"TagFilters"`
+	ServiceType    string      `json:"ServiceType"`
+	ResourcePrefix string      `json:"ResourcePrefix"`
+	ResourceRegion string      `json:"ResourceRegion"`
+	Offset         uint64      `json:"Offset"`
+	Limit          uint64      `json:"Limit"`
+}
+
+type DescribeResourcesByTagsResponse struct {
+	Response *struct {
+		TotalCount uint64 `json:"TotalCount"`
+		Rows       []struct {
+			ResourceId string `json:"ResourceId"`
+		} `json:"Rows"`
+		RequestId *string `json:"RequestId"`
+	} `json:"Response"`
+}
+
+// listIDsByTags returns the ids of the HPC clusters in the region which have all the tags.
+func (p *DefaultProvider) listIDsByTags(ctx context.Context, tagFilters []TagFilter) ([]string, error) {
+	var ids []string
+	for offset := uint64(0); ; offset += describeLimit {
+		commonRequest := tchttp.NewCommonRequest("tag", "2018-08-13", "DescribeResourcesByTags")
+		params, _ := json.Marshal(DescribeResourcesByTagsRequest{
+			TagFilters:     tagFilters,
+			ServiceType:    "cvm",
+			ResourcePrefix: "hpc",
+			ResourceRegion: p.region,
+			Offset:         offset,
+			Limit:          describeLimit,
+		})
+		if err := commonRequest.SetActionParameters(string(params)); err != nil {
+			return nil, fmt.Errorf("set parameters failed: %v", err)
+		}
+		commonResponse := tchttp.NewCommonResponse()
+		if err := p.commonClient.Send(commonRequest, commonResponse); err != nil {
+			return nil, fmt.Errorf("describe resources by tags failed: %v", err)
+		}
+		if err := commonResponse.ParseErrorFromHTTPResponse(commonResponse.GetBody()); err != nil {
+			return nil, fmt.Errorf("describe resources by tags failed: %v", err)
+		}
+		response := DescribeResourcesByTagsResponse{}
+		if err := json.Unmarshal(commonResponse.GetBody(), &response); err != nil {
+			return nil, fmt.Errorf("unmarshal resources failed: %v", err)
+		}
+		if response.Response == nil {
+			return nil, fmt.Errorf("invaild response: %s", commonResponse.GetBody())
+		}
+		log.FromContext(ctx).WithValues("process", "listhpcclusterTags").V(1).Info("tencent cloud request", "action", "DescribeResourcesByTags", "requestID", response.Response.RequestId)
+		for _, row := range response.Response.Rows {
+			ids = append(ids, row.ResourceId)
+		}
+		if len(response.Response.Rows) < describeLimit || offset+describeLimit >= response.Response.TotalCount {
+			return ids, nil
+		}
+	}
+}
+
+func getFilterSets(terms []api.HPCClusterSelectorTerm) (ids []string, res [][]TagFilter) {
+	for _, term := range terms {
+		switch {
+		case term.ID != "":
+			ids = append(ids, term.ID)
+		default:
+			var filters []TagFilter
+			for k, v := range term.Tags {
+				if v == "*" {
+					filters = append(filters, TagFilter{TagKey: k})
+				} else {
+					filters = append(filters, TagFilter{TagKey: k, TagValue: []string{v}})
+				}
+			}
+			res = append(res, filters)
+		}
+	}
+	return ids, res
+}
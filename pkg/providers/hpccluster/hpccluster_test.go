@@ -0,0 +1,53 @@
+package hpccluster
+
+import (
+	"context"
+	"testing"
+
+	api "github.com/tencentcloud/karpenter-provider-tke/pkg/apis/v1beta1"
+)
+
+func TestGetFilterSets_IDFilter(t *testing.T) {
+	terms := []api.HPCClusterSelectorTerm{
+		{ID: "hpc-12345"},
+		{ID: "hpc-67890"},
+	}
+	ids, filterSets := getFilterSets(terms)
+	if len(ids) != 2 || ids[0] != "hpc-12345" || ids[1] != "hpc-67890" {
+		t.Errorf("expected [hpc-12345 hpc-67890], got %v", ids)
+	}
+	if len(filterSets) != 0 {
+		t.Errorf("expected 0 filter sets for ID-only terms, got %d", len(filterSets))
+	}
+}
+
+func TestGetFilterSets_Tags(t *testing.T) {
+	terms := []api.HPCClusterSelectorTerm{
+		{Tags: map[string]string{"env": "prod"}},
+		{Tags: map[string]string{"karpenter": "*"}},
+	}
+	ids, filterSets := getFilterSets(terms)
+	if len(ids) != 0 {
+		t.Errorf("expected 0 IDs, got %d", len(ids))
+	}
+	if len(filterSets) != 2 {
+		t.Fatalf("expected 2 filter sets, got %d", len(filterSets))
+	}
+	if filterSets[0][0].TagKey != "env" || len(filterSets[0][0].TagValue) != 1 || filterSets[0][0].TagValue[0] != "prod" {
+		t.Errorf("expected env=prod tag filter, got %v", filterSets[0][0])
+	}
+	if filterSets[1][0].TagKey != "karpenter" || len(filterSets[1][0].TagValue) != 0 {
+		t.Errorf("expected karpenter tag key filter without values, got %v", filterSets[1][0])
+	}
+}
+
+func TestList_NoTerms(t *testing.T) {
+	p := NewDefaultProvider(context.Background(), "ap-guangzhou", nil, nil)
+	clusters, err := p.List(context.Background(), &api.TKEMachineNodeClass{})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(clusters) != 0 {
+		t.Errorf("expected no clusters, got %d", len(clusters))
+	}
+}
@@ -0,0 +1,135 @@
+/*
+Copyright (C) 2012-2025 Tencent. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package image
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/samber/lo"
+	api "github.com/tencentcloud/karpenter-provider-tke/pkg/apis/v1beta1"
+	cvm2017 "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/cvm/v20170312"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	// describeImagesLimit is the maximum page size of DescribeImages
+	describeImagesLimit = 100
+)
+
+// availableImageStates are the image states that can be used to launch instances
+var availableImageStates = []string{"NORMAL", "USING"}
+
+type Provider interface {
+	List(context.Context, *api.TKEMachineNodeClass) ([]*cvm2017.Image, error)
+}
+
+type DefaultProvider struct {
+	client *cvm2017.Client
+}
+
+func NewDefaultProvider(_ context.Context, client *cvm2017.Client) *DefaultProvider {
+	return &DefaultProvider{
+		client: client,
+	}
+}
+
+func (p *DefaultProvider) List(ctx context.Context, nodeClass *api.TKEMachineNodeClass) ([]*cvm2017.Image, error) {
+	ids, filterSets := getFilterSets(nodeClass.Spec.ImageSelectorTerms)
+	if len(filterSets) == 0 && len(ids) == 0 {
+		return []*cvm2017.Image{}, nil
+	}
+
+	images := map[string]*cvm2017.Image{}
+	if len(ids) != 0 {
+		req := cvm2017.NewDescribeImagesRequest()
+		req.ImageIds = ids
+		imageSet, err := p.describeImages(ctx, "listimageID", req)
+		if err != nil {
+			return nil, err
+		}
+		for _, image := range imageSet {
+			images[lo.FromPtr(image.ImageId)] = image
+		}
+	}
+
+	for _, filter := range filterSets {
+		req := cvm2017.NewDescribeImagesRequest()
+		req.Filters = append(req.Filters, filter...)
+		imageSet, err := p.describeImages(ctx, "listimageFilter", req)
+		if err != nil {
+			return nil, err
+		}
+		for _, image := range imageSet {
+			images[lo.FromPtr(image.ImageId)] = image
+		}
+	}
+
+	return lo.Filter(lo.Values(images), func(image *cvm2017.Image, _ int) bool {
+		return lo.Contains(availableImageStates, lo.FromPtr(image.ImageState))
+	}), nil
+}
+
+// describeImages pages through all the images matching the request.
+func (p *DefaultProvider) describeImages(ctx context.Context, process string, req *cvm2017.DescribeImagesRequest) ([]*cvm2017.Image, error) {
+	var images []*cvm2017.Image
+	for offset := 0; ; offset += describeImagesLimit {
+		req.Offset = lo.ToPtr(uint64(offset))
+		req.Limit = lo.ToPtr(uint64(describeImagesLimit))
+		resp, err := p.client.DescribeImages(req)
+		if err != nil {
+			return nil, fmt.Errorf("describe images failed: %v", err)
+		}
+		log.FromContext(ctx).WithValues("process", process).V(1).Info("tencent cloud request", "action", req.GetAction(), "requestID", resp.Response.RequestId)
+		images = append(images, resp.Response.ImageSet...)
+		if len(resp.Response.ImageSet) < describeImagesLimit || int64(offset+describeImagesLimit) >= lo.FromPtr(resp.Response.TotalCount) {
+			return images, nil
+		}
+	}
+}
+
+func getFilterSets(terms []api.ImageSelectorTerm) (ids []*string, res [][]*cvm2017.Filter) {
+	for _, term := range terms {
+		switch {
+		case term.ID != "":
+			ids = append(ids, lo.ToPtr(term.ID))
+		default:
+			var filters []*cvm2017.Filter
+			if term.Name != "" {
+				filters = append(filters, &cvm2017.Filter{
+					Name:   lo.ToPtr("image-name"),
+					Values: []*string{lo.ToPtr(term.Name)},
+				})
+			}
+			for k, v := range term.Tags {
+				if v == "*" {
+					filters = append(filters, &cvm2017.Filter{
+						Name:   lo.ToPtr("tag-key"),
+						Values: []*string{lo.ToPtr(k)},
+					})
+				} else {
+					filters = append(filters, &cvm2017.Filter{
+						Name:   lo.ToPtr(fmt.Sprintf("tag:%s", k)),
+						Values: []*string{lo.ToPtr(v)},
+					})
+				}
+			}
+			res = append(res, filters)
+		}
+	}
+	return ids, res
+}
@@ -0,0 +1,129 @@
+package image
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/samber/lo"
+	api "github.com/tencentcloud/karpenter-provider-tke/pkg/apis/v1beta1"
+	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common"
+	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common/profile"
+	cvm2017 "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/cvm/v20170312"
+)
+
+func TestGetFilterSets_IDFilter(t *testing.T) {
+	terms := []api.ImageSelectorTerm{
+		{ID: "img-12345"},
+		{ID: "img-67890"},
+	}
+	ids, filterSets := getFilterSets(terms)
+	if len(ids) != 2 {
+		t.Fatalf("expected 2 IDs, got %d", len(ids))
+	}
+	if lo.FromPtr(ids[0]) != "img-12345" {
+		t.Errorf("expected img-12345, got %s", lo.FromPtr(ids[0]))
+	}
+	if len(filterSets) != 0 {
+		t.Errorf("expected 0 filter sets for ID-only terms, got %d", len(filterSets))
+	}
+}
+
+func TestGetFilterSets_NameAndTags(t *testing.T) {
+	terms := []api.ImageSelectorTerm{
+		{Name: "my-image", Tags: map[string]string{"env": "prod"}},
+	}
+	ids, filterSets := getFilterSets(terms)
+	if len(ids) != 0 {
+		t.Errorf("expected 0 IDs, got %d", len(ids))
+	}
+	if len(filterSets) != 1 {
+		t.Fatalf("expected 1 filter set, got %d", len(filterSets))
+	}
+	if len(filterSets[0]) != 2 {
+		t.Fatalf("expected 2 filters in set, got %d", len(filterSets[0]))
+	}
+	if lo.FromPtr(filterSets[0][0].Name) != "image-name" || lo.FromPtr(filterSets[0][0].Values[0]) != "my-image" {
+		t.Errorf("expected image-name filter my-image, got %s=%s", lo.FromPtr(filterSets[0][0].Name), lo.FromPtr(filterSets[0][0].Values[0]))
+	}
+	if lo.FromPtr(filterSets[0][1].Name) != "tag:env" || lo.FromPtr(filterSets[0][1].Values[0]) != "prod" {
+		t.Errorf("expected tag:env filter prod, got %s=%s", lo.FromPtr(filterSets[0][1].Name), lo.FromPtr(filterSets[0][1].Values[0]))
+	}
+}
+
+func TestGetFilterSets_TagWildcard(t *testing.T) {
+	terms := []api.ImageSelectorTerm{
+		{Tags: map[string]string{"karpenter": "*"}},
+	}
+	_, filterSets := getFilterSets(terms)
+	if len(filterSets) != 1 {
+		t.Fatalf("expected 1 filter set, got %d", len(filterSets))
+	}
+	if lo.FromPtr(filterSets[0][0].Name) != "tag-key" {
+		t.Errorf("expected filter name 'tag-key', got %q", lo.FromPtr(filterSets[0][0].Name))
+	}
+}
+
+func TestGetFilterSets_Empty(t *testing.T) {
+	ids, filterSets := getFilterSets(nil)
+	if len(ids) != 0 || len(filterSets) != 0 {
+		t.Errorf("expected no ids and filter sets, got %d and %d", len(ids), len(filterSets))
+	}
+}
+
+type mockRoundTripper struct {
+	fn func(req *http.Request) (*http.Response, error)
+}
+
+func (m *mockRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	return m.fn(req)
+}
+
+func TestList_Paginated(t *testing.T) {
+	var offsets []uint64
+	transport := &mockRoundTripper{
+		fn: func(req *http.Request) (*http.Response, error) {
+			body, _ := io.ReadAll(req.Body)
+			describeReq := cvm2017.NewDescribeImagesRequest()
+			if err := json.Unmarshal(body, describeReq); err != nil {
+				return nil, err
+			}
+			offset := lo.FromPtr(describeReq.Offset)
+			offsets = append(offsets, offset)
+			// 150 images are returned in pages of the requested limit
+			var imageSet []*cvm2017.Image
+			for i := offset; i < min(offset+lo.FromPtr(describeReq.Limit), 150); i++ {
+				imageSet = append(imageSet, &cvm2017.Image{ImageId: lo.ToPtr(fmt.Sprintf("img-%03d", i)), ImageState: lo.ToPtr("NORMAL")})
+			}
+			resp := cvm2017.NewDescribeImagesResponse()
+			resp.Response = &cvm2017.DescribeImagesResponseParams{
+				ImageSet:   imageSet,
+				TotalCount: lo.ToPtr(int64(150)),
+				RequestId:  lo.ToPtr("ok-request-id"),
+			}
+			respBody, _ := json.Marshal(resp)
+			return &http.Response{StatusCode: 200, Header: make(http.Header), Body: io.NopCloser(bytes.NewReader(respBody))}, nil
+		},
+	}
+	client, _ := cvm2017.NewClient(common.NewCredential("test-secret-id", "test-secret-key"), "ap-guangzhou", profile.NewClientProfile())
+	client.WithHttpTransport(transport)
+	p := NewDefaultProvider(context.Background(), client)
+
+	nodeClass := &api.TKEMachineNodeClass{Spec: api.TKEMachineNodeClassSpec{
+		ImageSelectorTerms: []api.ImageSelectorTerm{{Tags: map[string]string{"karpenter": "*"}}},
+	}}
+	images, err := p.List(context.Background(), nodeClass)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if len(images) != 150 {
+		t.Errorf("expected 150 images, got %d", len(images))
+	}
+	if len(offsets) != 2 || offsets[0] != 0 || offsets[1] != describeImagesLimit {
+		t.Errorf("expected the offsets [0 %d], got %v", describeImagesLimit, offsets)
+	}
+}
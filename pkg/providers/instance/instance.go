@@ -0,0 +1,55 @@
+/*
+Copyright (C) 2012-2025 Tencent. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instance
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/samber/lo"
+	cvm2017 "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/cvm/v20170312"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+type Provider interface {
+	Get(context.Context, string) (*cvm2017.Instance, error)
+}
+
+type DefaultProvider struct {
+	client *cvm2017.Client
+}
+
+func NewDefaultProvider(_ context.Context, client *cvm2017.Client) *DefaultProvider {
+	return &DefaultProvider{
+		client: client,
+	}
+}
+
+// Get returns the instance by ID, or nil if the instance doesn't exist.
+func (p *DefaultProvider) Get(ctx context.Context, id string) (*cvm2017.Instance, error) {
+	req := cvm2017.NewDescribeInstancesRequest()
+	req.InstanceIds = []*string{lo.ToPtr(id)}
+	resp, err := p.client.DescribeInstances(req)
+	if err != nil {
+		return nil, fmt.Errorf("describe instance %s failed: %v", id, err)
+	}
+	log.FromContext(ctx).WithValues("process", "getinstance").V(1).Info("tencent cloud request", "action", req.GetAction(), "requestID", resp.Response.RequestId)
+	if len(resp.Response.InstanceSet) == 0 {
+		return nil, nil
+	}
+	return resp.Response.InstanceSet[0], nil
+}
@@ -0,0 +1,76 @@
+package instance
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/samber/lo"
+	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common"
+	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common/profile"
+	cvm2017 "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/cvm/v20170312"
+)
+
+type mockRoundTripper struct {
+	fn func(req *http.Request) (*http.Response, error)
+}
+
+func (m *mockRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	return m.fn(req)
+}
+
+func newTestProvider(instances map[string]*cvm2017.Instance) *DefaultProvider {
+	transport := &mockRoundTripper{
+		fn: func(req *http.Request) (*http.Response, error) {
+			body, _ := io.ReadAll(req.Body)
+			describeReq := cvm2017.NewDescribeInstancesRequest()
+			if err := json.Unmarshal(body, describeReq); err != nil {
+				return nil, err
+			}
+			var instanceSet []*cvm2017.Instance
+			for _, id := range describeReq.InstanceIds {
+				if instance, ok := instances[lo.FromPtr(id)]; ok {
+					instanceSet = append(instanceSet, instance)
+				}
+			}
+			resp := cvm2017.NewDescribeInstancesResponse()
+			resp.Response = &cvm2017.DescribeInstancesResponseParams{
+				InstanceSet: instanceSet,
+				TotalCount:  lo.ToPtr(int64(len(instanceSet))),
+				RequestId:   lo.ToPtr("ok-request-id"),
+			}
+			respBody, _ := json.Marshal(resp)
+			return &http.Response{StatusCode: 200, Header: make(http.Header), Body: io.NopCloser(bytes.NewReader(respBody))}, nil
+		},
+	}
+	client, _ := cvm2017.NewClient(common.NewCredential("test-secret-id", "test-secret-key"), "ap-guangzhou", profile.NewClientProfile())
+	client.WithHttpTransport(transport)
+	return NewDefaultProvider(context.Background(), client)
+}
+
+func TestGet(t *testing.T) {
+	p := newTestProvider(map[string]*cvm2017.Instance{
+		"ins-abc": {InstanceId: lo.ToPtr("ins-abc"), ExpiredTime: lo.ToPtr("2026-11-01T00:00:00Z")},
+	})
+	instance, err := p.Get(context.Background(), "ins-abc")
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if instance == nil || lo.FromPtr(instance.ExpiredTime) != "2026-11-01T00:00:00Z" {
+		t.Errorf("expected the instance ins-abc, got %v", instance)
+	}
+}
+
+func TestGet_NotFound(t *testing.T) {
+	p := newTestProvider(nil)
+	instance, err := p.Get(context.Background(), "ins-abc")
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if instance != nil {
+		t.Errorf("expected no instance, got %v", instance)
+	}
+}
@@ -46,6 +46,9 @@ import (
 	"sigs.k8s.io/karpenter/pkg/scheduling"
 )
 
+// hoursPerMonth is used to convert the monthly prepaid price into an hourly price.
+const hoursPerMonth = 730
+
 type Provider interface {
 	List(context.Context,
 		// *v1beta1.KubeletConfiguration,
@@ -104,21 +107,22 @@ func (p *DefaultProvider) List(ctx context.Context,
 
 	odkey := fmt.Sprintf("instance-types-od-%016x", subnetZonesHash)
 	spotkey := fmt.Sprintf("instance-types-spot-%016x", subnetZonesHash)
+	prepaidkey := fmt.Sprintf("instance-types-prepaid-%016x", subnetZonesHash)
 	enikey := fmt.Sprintf("eni-limits-spot-%016x", subnetZonesHash)
 	clsinfokey := "cluster-info"
 
-	var odTypes, spotTypes []cxm.InstanceTypeQuotaItem
+	var odTypes, spotTypes, prepaidTypes []cxm.InstanceTypeQuotaItem
 	var eniLimits map[string][]*tke2018.PodLimitsInstance
 	var clsInfo tke2018.Cluster
 
 	if item, ok := p.providerCache.Get(odkey); ok {
 		odTypes = item.([]cxm.InstanceTypeQuotaItem)
 	} else {
-		odTypesAMD, err := p.getInstanceTypes(ctx, "amd64", false, refresh, nodeClass)
+		odTypesAMD, err := p.getInstanceTypes(ctx, "amd64", v1.CapacityTypeOnDemand, refresh, nodeClass)
 		if err != nil {
 			return nil, fmt.Errorf("get on-demand amd64 instance types failed: %v", err)
 		}
-		odTypesARM, err := p.getInstanceTypes(ctx, "arm64", false, refresh, nodeClass)
+		odTypesARM, err := p.getInstanceTypes(ctx, "arm64", v1.CapacityTypeOnDemand, refresh, nodeClass)
 		if err != nil {
 			return nil, fmt.Errorf("get on-demand arm64 instance types failed: %v", err)
 		}
@@ -129,11 +133,11 @@ func (p *DefaultProvider) List(ctx context.Context,
 	if item, ok := p.providerCache.Get(spotkey); ok {
 		spotTypes = item.([]cxm.InstanceTypeQuotaItem)
 	} else {
-		spotTypesAMD, err := p.getInstanceTypes(ctx, "amd64", true, refresh, nodeClass)
+		spotTypesAMD, err := p.getInstanceTypes(ctx, "amd64", v1.CapacityTypeSpot, refresh, nodeClass)
 		if err != nil {
 			return nil, fmt.Errorf("get spot amd64 instance types failed: %v", err)
 		}
-		spotTypesARM, err := p.getInstanceTypes(ctx, "arm64", true, refresh, nodeClass)
+		spotTypesARM, err := p.getInstanceTypes(ctx, "arm64", v1.CapacityTypeSpot, refresh, nodeClass)
 		if err != nil {
 			return nil, fmt.Errorf("get spot arm64 instance types failed: %v", err)
 		}
@@ -141,6 +145,24 @@ func (p *DefaultProvider) List(ctx context.Context,
 		p.providerCache.SetDefault(spotkey, spotTypes)
 	}
 
+	// prepaid instance types are only queried when the nodeClass configures the prepaid billing
+	if nodeClass.Spec.Prepaid != nil {
+		if item, ok := p.providerCache.Get(prepaidkey); ok {
+			prepaidTypes = item.([]cxm.InstanceTypeQuotaItem)
+		} else {
+			prepaidTypesAMD, err := p.getInstanceTypes(ctx, "amd64", api.CapacityTypePrepaid, refresh, nodeClass)
+			if err != nil {
+				return nil, fmt.Errorf("get prepaid amd64 instance types failed: %v", err)
+			}
+			prepaidTypesARM, err := p.getInstanceTypes(ctx, "arm64", api.CapacityTypePrepaid, refresh, nodeClass)
+			if err != nil {
+				return nil, fmt.Errorf("get prepaid arm64 instance types failed: %v", err)
+			}
+			prepaidTypes = append(prepaidTypesAMD, prepaidTypesARM...)
+			p.providerCache.SetDefault(prepaidkey, prepaidTypes)
+		}
+	}
+
 	if item, ok := p.providerCache.Get(enikey); ok {
 		eniLimits = item.(map[string][]*tke2018.PodLimitsInstance)
 	} else {
@@ -164,7 +186,8 @@ func (p *DefaultProvider) List(ctx context.Context,
 		if len(resp.Response.Clusters) == 0 || resp.Response.Clusters[0] == nil {
 			return nil, fmt.Errorf("no cluster found for %s", options.FromContext(ctx).ClusterID)
 		}
-		p.providerCache.SetDefault(clsinfokey, *resp.Response.Clusters[0])
+		clsInfo = *resp.Response.Clusters[0]
+		p.providerCache.SetDefault(clsinfokey, clsInfo)
 	}
 
 	var storageInGB int32
@@ -207,14 +230,46 @@ func (p *DefaultProvider) List(ctx context.Context,
 		}
 	}
 
+	for _, i := range prepaidTypes {
+		if p.isBlocked(i.InstanceType, api.CapacityTypePrepaid, i.Zone) {
+			continue
+		}
+		offeringsMap[i.InstanceType] = append(offeringsMap[i.InstanceType], p.createPrepaidOfferings(ctx, nodeClass.Spec.Prepaid, i)...)
+		if _, ok := instanceTypeMap[i.InstanceType]; !ok {
+			instanceTypeMap[i.InstanceType] = i
+		}
+	}
+
+	if len(nodeClass.Spec.HPCClusterSelectorTerms) != 0 {
+		restrictToHPCClusterZones(offeringsMap, nodeClass.Status.HPCClusters)
+	}
+
+	if len(lo.FromPtr(nodeClass.Spec.InternetAccessible).AddressPoolTags) != 0 {
+		restrictToAddressPool(offeringsMap, nodeClass.Status.Addresses)
+	}
+
+	restrictToSubnetCapacity(offeringsMap, nodeClass.Status.Subnets)
+
+	if len(nodeClass.Spec.ImageSelectorTerms) != 0 {
+		// only the architectures with a resolved image can be launched
+		archs := sets.New(lo.Map(nodeClass.Status.Images, func(i api.Image, _ int) string { return i.Architecture })...)
+		instanceTypeMap = lo.PickBy(instanceTypeMap, func(_ string, i cxm.InstanceTypeQuotaItem) bool {
+			return archs.Has(i.Arch)
+		})
+	}
+
 	if len(p.blacklistCache.Items()) == 0 {
 		blockedInstanceType.Reset()
 	}
 
+	kubelet := lo.FromPtr(nodeClass.Spec.Kubelet)
+	rdma := lo.Ternary(len(nodeClass.Spec.HPCClusterSelectorTerms) != 0, "true", "false")
 	return lo.MapToSlice(instanceTypeMap, func(k string, i cxm.InstanceTypeQuotaItem) *cloudprovider.InstanceType {
-		return NewInstanceType(ctx, p.region, storageInGB, i, currentVersion,
-			nil, nil, nil, nil, nil,
+		it := NewInstanceType(ctx, p.region, storageInGB, nodeClass.Spec.InstanceStorePolicy, i, currentVersion,
+			kubelet.MaxPods, kubelet.PodsPerCore, kubelet.KubeReserved, kubelet.SystemReserved, kubelet.EvictionHard,
 			offeringsMap[k], eniLimits[i.Zone], &clsInfo)
+		it.Requirements.Add(scheduling.NewRequirement(api.LabelRDMA, corev1.NodeSelectorOpIn, rdma))
+		return it
 	}), nil
 
 }
@@ -283,7 +338,7 @@ func (p *DefaultProvider) isBlocked(instName, capacityType, zone string) bool {
 	return false
 }
 
-func (p *DefaultProvider) getInstanceTypes(ctx context.Context, arch string, isSpot, refresh bool, nodeClass *api.TKEMachineNodeClass) ([]cxm.InstanceTypeQuotaItem, error) {
+func (p *DefaultProvider) getInstanceTypes(ctx context.Context, arch, capacityType string, refresh bool, nodeClass *api.TKEMachineNodeClass) ([]cxm.InstanceTypeQuotaItem, error) {
 	filters := []*tke2018.Filter{}
 	nodeClaimList := &v1.NodeClaimList{}
 	if err := p.rtclient.List(ctx, nodeClaimList); err != nil {
@@ -321,9 +376,12 @@ func (p *DefaultProvider) getInstanceTypes(ctx context.Context, arch string, isS
 		Name:   lo.ToPtr("instance-charge-type"),
 		Values: nil,
 	}
-	if isSpot {
+	switch capacityType {
+	case v1.CapacityTypeSpot:
 		chargeTypeFilter.Values = []*string{lo.ToPtr("SPOTPAID")}
-	} else {
+	case api.CapacityTypePrepaid:
+		chargeTypeFilter.Values = []*string{lo.ToPtr("PREPAID")}
+	default:
 		chargeTypeFilter.Values = []*string{lo.ToPtr("POSTPAID_BY_HOUR")}
 	}
 
@@ -402,6 +460,81 @@ func (p *DefaultProvider) createOfferings(ctx context.Context, capacityType stri
 	return offerings
 }
 
+// restrictToHPCClusterZones makes the offerings in the zones without a HPC cluster unavailable, since the nodes
+// can only be launched into the HPC cluster of their zone.
+func restrictToHPCClusterZones(offeringsMap map[string]cloudprovider.Offerings, hpcClusters []api.HPCCluster) {
+	hpcZones := sets.New(lo.Map(hpcClusters, func(c api.HPCCluster, _ int) string { return c.Zone })...)
+	for _, offerings := range offeringsMap {
+		for _, o := range offerings {
+			o.Available = o.Available && hpcZones.Has(o.Requirements.Get(api.LabelCBSToplogy).Any())
+		}
+	}
+}
+
+// restrictToSubnetCapacity makes the offerings unavailable in the zones whose subnets are all out of IPs.
+func restrictToSubnetCapacity(offeringsMap map[string]cloudprovider.Offerings, subnets []api.Subnet) {
+	exhaustedZones := sets.New[string]()
+	for zone, zoneSubnets := range lo.GroupBy(subnets, func(s api.Subnet) string { return s.Zone }) {
+		if lo.EveryBy(zoneSubnets, func(s api.Subnet) bool { return s.AvailableIPAddressCount != nil && *s.AvailableIPAddressCount <= 0 }) {
+			exhaustedZones.Insert(zone)
+		}
+	}
+	if exhaustedZones.Len() == 0 {
+		return
+	}
+	for _, offerings := range offeringsMap {
+		for _, o := range offerings {
+			o.Available = o.Available && !exhaustedZones.Has(o.Requirements.Get(api.LabelCBSToplogy).Any())
+		}
+	}
+}
+
+// restrictToAddressPool makes all the offerings unavailable once the address pool runs out of unassociated EIPs.
+func restrictToAddressPool(offeringsMap map[string]cloudprovider.Offerings, addresses []api.Address) {
+	if len(addresses) != 0 {
+		return
+	}
+	makeOfferingsUnavailable(offeringsMap)
+}
+
+// makeOfferingsUnavailable marks all the offerings unavailable when a resource every launch needs is exhausted. The
+// launches would be rejected by the API until the resource is released, so karpenter isn't allowed to pick the
+// offerings and can report the pods as unschedulable instead of retrying the launches.
+func makeOfferingsUnavailable(offeringsMap map[string]cloudprovider.Offerings) {
+	for _, offerings := range offeringsMap {
+		for _, o := range offerings {
+			o.Available = false
+		}
+	}
+}
+
+// createPrepaidOfferings creates the prepaid offering of the instance type, priced at the hourly cost of its monthly
+// price.
+func (p *DefaultProvider) createPrepaidOfferings(ctx context.Context, prepaid *api.Prepaid, insType cxm.InstanceTypeQuotaItem) []*cloudprovider.Offering {
+	period := PrepaidPeriod(prepaid)
+	available := insType.Status == "SELL" && insType.Inventory > 0
+	if lo.FromPtr(prepaid).ChargeType == api.PrepaidChargeTypeUnderwrite {
+		available = available && insType.Externals.PrepaidUnderwriteEnable &&
+			lo.Contains(insType.Externals.PrepaidUnderwritePeriods, int(period))
+	}
+	zoneID, _ := p.zoneProvider.IDFromZone(insType.Zone)
+	return []*cloudprovider.Offering{{
+		Requirements: scheduling.NewRequirements(
+			scheduling.NewRequirement(v1.CapacityTypeLabelKey, corev1.NodeSelectorOpIn, api.CapacityTypePrepaid),
+			scheduling.NewRequirement(corev1.LabelTopologyZone, corev1.NodeSelectorOpIn, zoneID),
+			scheduling.NewRequirement(api.LabelCBSToplogy, corev1.NodeSelectorOpIn, insType.Zone),
+		),
+		// the prepaid price is the monthly price
+		Price:     insType.Price.OriginalPrice / hoursPerMonth,
+		Available: available,
+	}}
+}
+
+// PrepaidPeriod returns the subscription period in months of the prepaid instances.
+func PrepaidPeriod(prepaid *api.Prepaid) int32 {
+	return lo.Ternary(lo.FromPtr(prepaid).Period > 0, lo.FromPtr(prepaid).Period, 1)
+}
+
 func (p *DefaultProvider) getENILimits(ctx context.Context, nodeClass *api.TKEMachineNodeClass) (map[string][]*tke2018.PodLimitsInstance, error) {
 
 	limits := map[string][]*tke2018.PodLimitsInstance{}
@@ -9,7 +9,9 @@ import (
 	"testing"
 	"time"
 
+	"github.com/mitchellh/hashstructure/v2"
 	"github.com/patrickmn/go-cache"
+	"github.com/samber/lo"
 	api "github.com/tencentcloud/karpenter-provider-tke/pkg/apis/v1beta1"
 	"github.com/tencentcloud/karpenter-provider-tke/staging/nativenode/cxm"
 	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common"
@@ -19,8 +21,13 @@ import (
 	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+	k8sversion "k8s.io/apimachinery/pkg/version"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	kubernetesfake "k8s.io/client-go/kubernetes/fake"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
 )
 
 func TestNormalizeVersion_WithPrefix(t *testing.T) {
@@ -297,6 +304,145 @@ func TestCreateOfferings_SpotCapacityType(t *testing.T) {
 	}
 }
 
+func TestRestrictToHPCClusterZones(t *testing.T) {
+	p := newTestProvider()
+	p.zoneProvider = &mockZoneProviderIT{}
+	ctx := context.Background()
+	offeringsMap := map[string]cloudprovider.Offerings{}
+	for _, zone := range []string{"ap-guangzhou-3", "ap-guangzhou-6"} {
+		offeringsMap["HCCPNV4h.48XLARGE1024"] = append(offeringsMap["HCCPNV4h.48XLARGE1024"], p.createOfferings(ctx, v1.CapacityTypeOnDemand, cxm.InstanceTypeQuotaItem{
+			InstanceType: "HCCPNV4h.48XLARGE1024",
+			Zone:         zone,
+			Status:       "SELL",
+			Inventory:    10,
+		})...)
+	}
+	restrictToHPCClusterZones(offeringsMap, []api.HPCCluster{{ID: "hpc-123", Zone: "ap-guangzhou-6"}})
+	for _, o := range offeringsMap["HCCPNV4h.48XLARGE1024"] {
+		zone := o.Requirements.Get(api.LabelCBSToplogy).Any()
+		if o.Available != (zone == "ap-guangzhou-6") {
+			t.Errorf("expected offering in %s to be available only in the hpc cluster zone, got %t", zone, o.Available)
+		}
+	}
+}
+
+func TestRestrictToSubnetCapacity(t *testing.T) {
+	p := newTestProvider()
+	p.zoneProvider = &mockZoneProviderIT{}
+	ctx := context.Background()
+	offeringsMap := map[string]cloudprovider.Offerings{}
+	for _, zone := range []string{"ap-guangzhou-3", "ap-guangzhou-4", "ap-guangzhou-6"} {
+		offeringsMap["S5.MEDIUM4"] = append(offeringsMap["S5.MEDIUM4"], p.createOfferings(ctx, v1.CapacityTypeOnDemand, cxm.InstanceTypeQuotaItem{
+			InstanceType: "S5.MEDIUM4",
+			Zone:         zone,
+			Status:       "SELL",
+			Inventory:    10,
+		})...)
+	}
+	restrictToSubnetCapacity(offeringsMap, []api.Subnet{
+		{ID: "subnet-1", Zone: "ap-guangzhou-3", AvailableIPAddressCount: lo.ToPtr(int64(0))},
+		{ID: "subnet-2", Zone: "ap-guangzhou-4", AvailableIPAddressCount: lo.ToPtr(int64(0))},
+		{ID: "subnet-3", Zone: "ap-guangzhou-4", AvailableIPAddressCount: lo.ToPtr(int64(10))},
+		// the available IPs of the subnet aren't resolved yet
+		{ID: "subnet-4", Zone: "ap-guangzhou-6"},
+	})
+	for _, o := range offeringsMap["S5.MEDIUM4"] {
+		zone := o.Requirements.Get(api.LabelCBSToplogy).Any()
+		if o.Available != (zone != "ap-guangzhou-3") {
+			t.Errorf("expected offering in %s to be unavailable only in the exhausted zone, got %t", zone, o.Available)
+		}
+	}
+}
+
+func TestRestrictToAddressPool(t *testing.T) {
+	p := newTestProvider()
+	p.zoneProvider = &mockZoneProviderIT{}
+	ctx := context.Background()
+	newOfferingsMap := func() map[string]cloudprovider.Offerings {
+		return map[string]cloudprovider.Offerings{
+			"S5.MEDIUM4": p.createOfferings(ctx, v1.CapacityTypeOnDemand, cxm.InstanceTypeQuotaItem{
+				InstanceType: "S5.MEDIUM4",
+				Zone:         "ap-guangzhou-3",
+				Status:       "SELL",
+				Inventory:    10,
+			}),
+		}
+	}
+
+	offeringsMap := newOfferingsMap()
+	restrictToAddressPool(offeringsMap, []api.Address{{ID: "eip-a"}})
+	if !offeringsMap["S5.MEDIUM4"][0].Available {
+		t.Error("expected offering to be available with an unassociated address")
+	}
+
+	offeringsMap = newOfferingsMap()
+	restrictToAddressPool(offeringsMap, nil)
+	if offeringsMap["S5.MEDIUM4"][0].Available {
+		t.Error("expected offering to be unavailable when the address pool is exhausted")
+	}
+}
+
+func TestCreatePrepaidOfferings_Prepaid(t *testing.T) {
+	p := newTestProvider()
+	p.zoneProvider = &mockZoneProviderIT{}
+	ctx := context.Background()
+	insType := cxm.InstanceTypeQuotaItem{
+		InstanceType: "S5.LARGE8",
+		Zone:         "ap-guangzhou-3",
+		Status:       "SELL",
+		Inventory:    20,
+		Price:        cxm.ItemPrice{OriginalPrice: 730},
+	}
+	offerings := p.createPrepaidOfferings(ctx, &api.Prepaid{ChargeType: api.PrepaidChargeTypePrepaid}, insType)
+	if len(offerings) != 1 {
+		t.Fatalf("expected 1 offering, got %d", len(offerings))
+	}
+	if !offerings[0].Available {
+		t.Error("expected prepaid offering to be available")
+	}
+	if offerings[0].Price != 1 {
+		t.Errorf("expected hourly price 1, got %f", offerings[0].Price)
+	}
+	if offerings[0].CapacityType() != api.CapacityTypePrepaid {
+		t.Errorf("expected prepaid capacity type, got %s", offerings[0].CapacityType())
+	}
+	if offerings[0].ReservationCapacity != 0 {
+		t.Errorf("expected no reservation, got capacity %d", offerings[0].ReservationCapacity)
+	}
+
+	// the hourly price doesn't depend on the length of the period
+	offerings = p.createPrepaidOfferings(ctx, &api.Prepaid{ChargeType: api.PrepaidChargeTypePrepaid, Period: 12}, insType)
+	if offerings[0].Price != 1 {
+		t.Errorf("expected hourly price 1 for a 12 months period, got %f", offerings[0].Price)
+	}
+}
+
+func TestCreatePrepaidOfferings_Underwrite(t *testing.T) {
+	p := newTestProvider()
+	p.zoneProvider = &mockZoneProviderIT{}
+	ctx := context.Background()
+	insType := cxm.InstanceTypeQuotaItem{
+		InstanceType: "S5.LARGE8",
+		Zone:         "ap-guangzhou-3",
+		Status:       "SELL",
+		Inventory:    20,
+		Externals:    cxm.Externals{PrepaidUnderwriteEnable: true, PrepaidUnderwritePeriods: []int{12, 24}},
+	}
+	offerings := p.createPrepaidOfferings(ctx, &api.Prepaid{ChargeType: api.PrepaidChargeTypeUnderwrite, Period: 12}, insType)
+	if !offerings[0].Available {
+		t.Error("expected underwrite offering to be available for a supported period")
+	}
+	offerings = p.createPrepaidOfferings(ctx, &api.Prepaid{ChargeType: api.PrepaidChargeTypeUnderwrite, Period: 1}, insType)
+	if offerings[0].Available {
+		t.Error("expected underwrite offering to be unavailable for an unsupported period")
+	}
+	insType.Externals.PrepaidUnderwriteEnable = false
+	offerings = p.createPrepaidOfferings(ctx, &api.Prepaid{ChargeType: api.PrepaidChargeTypeUnderwrite, Period: 12}, insType)
+	if offerings[0].Available {
+		t.Error("expected underwrite offering to be unavailable when underwrite is disabled")
+	}
+}
+
 // ---------------------------------------------------------------------------
 // Helpers for ZoneNotSupported tests
 // ---------------------------------------------------------------------------
@@ -449,7 +595,7 @@ func TestGetInstanceTypes_ZoneNotSupported_HTTPError(t *testing.T) {
 	ctx := context.Background()
 	nodeClass := minimalNodeClass("ap-guangzhou-3")
 
-	result, err := p.getInstanceTypes(ctx, "amd64", false, false, nodeClass)
+	result, err := p.getInstanceTypes(ctx, "amd64", v1.CapacityTypeOnDemand, false, nodeClass)
 	if err != nil {
 		t.Fatalf("expected nil error when ZoneNotSupported, got: %v", err)
 	}
@@ -475,7 +621,7 @@ func TestGetInstanceTypes_ZoneNotSupported_TransportError(t *testing.T) {
 	ctx := context.Background()
 	nodeClass := minimalNodeClass("ap-guangzhou-3")
 
-	result, err := p.getInstanceTypes(ctx, "amd64", false, false, nodeClass)
+	result, err := p.getInstanceTypes(ctx, "amd64", v1.CapacityTypeOnDemand, false, nodeClass)
 	if err != nil {
 		t.Fatalf("expected nil error when ZoneNotSupported, got: %v", err)
 	}
@@ -500,7 +646,7 @@ func TestGetInstanceTypes_OtherError(t *testing.T) {
 	ctx := context.Background()
 	nodeClass := minimalNodeClass("ap-guangzhou-3")
 
-	_, err := p.getInstanceTypes(ctx, "amd64", false, false, nodeClass)
+	_, err := p.getInstanceTypes(ctx, "amd64", v1.CapacityTypeOnDemand, false, nodeClass)
 	if err == nil {
 		t.Fatal("expected non-nil error for non-ZoneNotSupported failure")
 	}
@@ -608,3 +754,47 @@ func TestGetENILimits_OtherError(t *testing.T) {
 		t.Fatal("expected non-nil error for non-ZoneNotSupported failure in getENILimits")
 	}
 }
+
+// TestList_ClusterInfoCacheMiss verifies that the cluster info described on a cache miss is used for the instance
+// types of that very List call, and is cached for the following ones.
+func TestList_ClusterInfoCacheMiss(t *testing.T) {
+	describeClusters := 0
+	transport := &mockRoundTripper{
+		fn: func(req *http.Request) (*http.Response, error) {
+			describeClusters++
+			return makeHTTPResponse(200, `{"Response":{"RequestId":"ok-request-id","TotalCount":1,"Clusters":[{"ClusterId":"cls-test","Property":"{\"NetworkType\":\"GR\"}","ClusterNetworkSettings":{"MaxNodePodNum":67}}]}}`), nil
+		},
+	}
+
+	clientset := kubernetesfake.NewSimpleClientset()
+	clientset.Discovery().(*fakediscovery.FakeDiscovery).FakedServerVersion = &k8sversion.Info{GitVersion: "v1.30.0"}
+	p := newTestProvider()
+	p.k8sclient = clientset
+	p.zoneProvider = &mockZoneProviderIT{}
+	p.client2018 = newTKE2018ClientWithTransport(transport)
+
+	nodeClass := minimalNodeClass("ap-guangzhou-3")
+	// only the cluster info misses the cache
+	subnetZonesHash, _ := hashstructure.Hash(sets.New("ap-guangzhou-3"), hashstructure.FormatV2, &hashstructure.HashOptions{SlicesAsSets: true})
+	p.providerCache.SetDefault(fmt.Sprintf("instance-types-od-%016x", subnetZonesHash), []cxm.InstanceTypeQuotaItem{
+		{InstanceType: "S5.LARGE8", Zone: "ap-guangzhou-3", Arch: "amd64", CPU: 4, Memory: 8, Status: "SELL", Inventory: 100},
+	})
+	p.providerCache.SetDefault(fmt.Sprintf("instance-types-spot-%016x", subnetZonesHash), []cxm.InstanceTypeQuotaItem{})
+	p.providerCache.SetDefault(fmt.Sprintf("eni-limits-spot-%016x", subnetZonesHash), map[string][]*tke2018.PodLimitsInstance{})
+
+	for range 2 {
+		instanceTypes, err := p.List(testCtx(), nodeClass, false)
+		if err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		if len(instanceTypes) != 1 {
+			t.Fatalf("expected 1 instance type, got %d", len(instanceTypes))
+		}
+		if pods := instanceTypes[0].Capacity.Pods().Value(); pods != 64 {
+			t.Errorf("expected the pods to be limited by the pod CIDR of the cluster, got %d", pods)
+		}
+	}
+	if describeClusters != 1 {
+		t.Errorf("expected the cluster info to be described once, got %d", describeClusters)
+	}
+}
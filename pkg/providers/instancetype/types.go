@@ -21,14 +21,17 @@ import (
 	"encoding/json"
 	"fmt"
 	"math"
+	"regexp"
 	"strconv"
 	"strings"
 
 	"github.com/samber/lo"
 	"github.com/tencentcloud/karpenter-provider-tke/staging/nativenode/cxm"
+	capiv1beta1 "github.com/tencentcloud/karpenter-provider-tke/staging/nativenode/v1beta1"
 	tke2018 "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/tke/v20180525"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/sets"
 
 	"github.com/blang/semver/v4"
 	api "github.com/tencentcloud/karpenter-provider-tke/pkg/apis/v1beta1"
@@ -83,16 +86,28 @@ type ClusterProperty struct {
 	IsNetworkWithApp          bool         `json:"IsNetworkWithApp,omitempty"`
 }
 
-func NewInstanceType(ctx context.Context, region string, storageInGB int32, instanceType cxm.InstanceTypeQuotaItem, k8sVersion semver.Version,
+// NewClusterProperty parses the property of the cluster, an empty property is returned if it can't be parsed.
+func NewClusterProperty(clsinfo *tke2018.Cluster) *ClusterProperty {
+	clsProperty := &ClusterProperty{}
+	if clsinfo != nil && clsinfo.Property != nil {
+		_ = json.Unmarshal([]byte(lo.FromPtr(clsinfo.Property)), clsProperty)
+	}
+	return clsProperty
+}
+
+func NewInstanceType(ctx context.Context, region string, storageInGB int32, instanceStorePolicy *api.InstanceStorePolicy, instanceType cxm.InstanceTypeQuotaItem, k8sVersion semver.Version,
 	maxPods *int32, podsPerCore *int32,
 	kubeReserved map[string]string, systemReserved map[string]string, evictionHard map[string]string,
 	offerings cloudprovider.Offerings, eniLimits []*tke2018.PodLimitsInstance, clsinfo *tke2018.Cluster) *cloudprovider.InstanceType {
 
 	if clsinfo != nil && clsinfo.Property != nil {
-		clsProperty := &ClusterProperty{}
-		_ = json.Unmarshal([]byte(lo.FromPtr(clsinfo.Property)), clsProperty)
+		clsProperty := NewClusterProperty(clsinfo)
 		if clsProperty.NetworkType != "VPC-CNI" && clsinfo.ClusterNetworkSettings != nil && lo.FromPtr(clsinfo.ClusterNetworkSettings.MaxNodePodNum) > 3 {
-			maxPods = lo.ToPtr(int32(lo.FromPtr(clsinfo.ClusterNetworkSettings.MaxNodePodNum) - 3))
+			// the pod cidr of the node limits the pods, a lower maxPods from the kubelet configuration is still honored
+			clusterMaxPods := int32(lo.FromPtr(clsinfo.ClusterNetworkSettings.MaxNodePodNum) - 3)
+			if lo.FromPtr(maxPods) <= 0 || lo.FromPtr(maxPods) > clusterMaxPods {
+				maxPods = lo.ToPtr(clusterMaxPods)
+			}
 		}
 		if len(clsProperty.VpcCniType) == 0 &&
 			clsProperty.NetworkType != "VPC-CNI" &&
@@ -101,7 +116,7 @@ func NewInstanceType(ctx context.Context, region string, storageInGB int32, inst
 		}
 	}
 
-	capacity := computeCapacity(ctx, storageInGB, instanceType, maxPods, podsPerCore, eniLimits)
+	capacity := computeCapacity(ctx, storageInGB, instanceStorePolicy, instanceType, maxPods, podsPerCore, eniLimits)
 	it := &cloudprovider.InstanceType{
 		Name:         instanceType.InstanceType,
 		Requirements: computeRequirements(offerings, region, instanceType),
@@ -142,14 +157,33 @@ func computeRequirements(offerings cloudprovider.Offerings, region string, insta
 		scheduling.NewRequirement(api.LabelInstanceCPU, corev1.NodeSelectorOpIn, fmt.Sprint(instanceTypeInfo.CPU)),
 		scheduling.NewRequirement(api.LabelInstanceMemoryGB, corev1.NodeSelectorOpIn, fmt.Sprintf("%d", instanceTypeInfo.Memory)),
 		scheduling.NewRequirement(api.LabelInstanceFamily, corev1.NodeSelectorOpIn, instanceTypeInfo.InstanceFamily),
+		scheduling.NewRequirement(api.LabelInstanceCategory, corev1.NodeSelectorOpIn, instanceCategory(instanceTypeInfo)),
+		scheduling.NewRequirement(api.LabelInstanceGPUCount, corev1.NodeSelectorOpDoesNotExist),
+		scheduling.NewRequirement(api.LabelInstanceGPUName, corev1.NodeSelectorOpDoesNotExist),
+		scheduling.NewRequirement(api.LabelInstanceGPUMemory, corev1.NodeSelectorOpDoesNotExist),
 	)
+	if instanceTypeInfo.Gpu > 0 {
+		requirements.Get(api.LabelInstanceGPUCount).Insert(fmt.Sprint(instanceTypeInfo.Gpu))
+		if name := gpuName(instanceTypeInfo.Externals.GpuAttr.Type); name != "" {
+			requirements.Get(api.LabelInstanceGPUName).Insert(name)
+		}
+		if memory := gpuMemoryInMiB(instanceTypeInfo.Externals.GPUDesc); memory > 0 {
+			requirements.Get(api.LabelInstanceGPUMemory).Insert(fmt.Sprint(memory))
+		}
+	}
 	return requirements
 }
 
-func computeCapacity(ctx context.Context, storageInGB int32,
+func computeCapacity(ctx context.Context, storageInGB int32, instanceStorePolicy *api.InstanceStorePolicy,
 	instanceTypeInfo cxm.InstanceTypeQuotaItem,
 	maxPods *int32, podsPerCore *int32, eniLimits []*tke2018.PodLimitsInstance) corev1.ResourceList {
 	eniip, directeni, subeni := eni(ctx, instanceTypeInfo, eniLimits)
+	// the RAID0 array of the local disks is used as the ephemeral storage instead of the cloud disks
+	if lo.FromPtr(instanceStorePolicy) == api.InstanceStorePolicyRAID0 {
+		if localStorage := localStorageInGB(instanceTypeInfo); localStorage > 0 {
+			storageInGB = localStorage
+		}
+	}
 	resourceList := corev1.ResourceList{
 		corev1.ResourceCPU:              resource.MustParse(strconv.Itoa(instanceTypeInfo.CPU)),
 		corev1.ResourceMemory:           *memory(ctx, instanceTypeInfo.Memory*1024*1024*1024),
@@ -175,6 +209,66 @@ func computeCapacity(ctx context.Context, storageInGB int32,
 	return resourceList
 }
 
+// highMemoryPerCPUInGB is the memory per vCPU above which the instance type is a high memory one,
+// the memory optimized families such as M5 have 8GB per vCPU.
+const highMemoryPerCPUInGB = 8
+
+// instanceCategory classifies the instance type into one of the InstanceCategory values.
+func instanceCategory(instanceTypeInfo cxm.InstanceTypeQuotaItem) string {
+	family := strings.ToUpper(instanceTypeInfo.InstanceFamily)
+	typeName := strings.ToLower(instanceTypeInfo.TypeName)
+	switch {
+	case instanceTypeInfo.Gpu > 0 || instanceTypeInfo.GpuCount > 0 || strings.Contains(typeName, "gpu"):
+		return api.InstanceCategoryGPU
+	case instanceTypeInfo.Fpga > 0 || strings.HasPrefix(family, "FX") || strings.Contains(typeName, "fpga"):
+		return api.InstanceCategoryFPGA
+	case strings.HasPrefix(family, "BM") || strings.Contains(typeName, "bare metal") || strings.Contains(typeName, "裸金属"):
+		return api.InstanceCategoryBareMetal
+	case instanceTypeInfo.CPU > 0 && instanceTypeInfo.Memory > instanceTypeInfo.CPU*highMemoryPerCPUInGB:
+		return api.InstanceCategoryHighMemory
+	default:
+		return api.InstanceCategoryGeneral
+	}
+}
+
+var (
+	// gpuVendorWords are dropped from the GPU names, e.g. "NVIDIA Tesla T4" is named t4
+	gpuVendorWords   = sets.New("nvidia", "tesla", "gpu")
+	gpuNameSeparator = regexp.MustCompile(`[^a-z0-9.]+`)
+	// gpuMemory matches the memory of a GPU in its description, e.g. "1 * NVIDIA T4 16GB"
+	gpuMemory = regexp.MustCompile(`(?i)(\d+)\s*G(?:i?B)?\b`)
+)
+
+// gpuName returns the GPU model of the GPU type in the form of a label value, e.g. "NVIDIA A10" is named a10.
+func gpuName(gpuType string) string {
+	words := lo.Reject(gpuNameSeparator.Split(strings.ToLower(gpuType), -1), func(w string, _ int) bool {
+		return w == "" || gpuVendorWords.Has(w)
+	})
+	return strings.Trim(strings.Join(words, "-"), ".-")
+}
+
+// gpuMemoryInMiB returns the memory of a GPU in the GPU description, 0 if it's not described.
+func gpuMemoryInMiB(gpuDesc string) int64 {
+	match := gpuMemory.FindStringSubmatch(gpuDesc)
+	if match == nil {
+		return 0
+	}
+	memory, err := strconv.ParseInt(match[1], 10, 64)
+	if err != nil {
+		return 0
+	}
+	return memory * 1024
+}
+
+// localStorageInGB returns the total size of the local disks which come with the instance type.
+func localStorageInGB(instanceTypeInfo cxm.InstanceTypeQuotaItem) int32 {
+	attr := instanceTypeInfo.Externals.StorageBlockAttr
+	if attr.Type != string(capiv1beta1.LOCALNVMEDiskType) && attr.Type != string(capiv1beta1.LOCALSSDDiskType) {
+		return 0
+	}
+	return int32(instanceTypeInfo.StorageBlock) * int32(attr.MaxSize)
+}
+
 func memory(ctx context.Context, m int) *resource.Quantity {
 	mem := resources.Quantity(strconv.Itoa(
 		int(
@@ -333,7 +333,7 @@ func TestComputeCapacity(t *testing.T) {
 		CPU:          4,
 		Memory:       8,
 	}
-	result := computeCapacity(ctx, 50, inst, nil, nil, nil)
+	result := computeCapacity(ctx, 50, nil, inst, nil, nil, nil)
 	if _, ok := result[corev1.ResourceCPU]; !ok {
 		t.Error("expected CPU in capacity")
 	}
@@ -356,7 +356,7 @@ func TestComputeCapacity_WithGPU(t *testing.T) {
 		Memory:       40,
 		Gpu:          1,
 	}
-	result := computeCapacity(ctx, 50, inst, nil, nil, nil)
+	result := computeCapacity(ctx, 50, nil, inst, nil, nil, nil)
 	gpu, ok := result[corev1.ResourceName(api.ResourceNVIDIAGPU)]
 	if !ok {
 		t.Error("expected GPU in capacity for GPU instance")
@@ -366,6 +366,32 @@ func TestComputeCapacity_WithGPU(t *testing.T) {
 	}
 }
 
+func TestComputeCapacity_WithInstanceStore(t *testing.T) {
+	ctx := testCtx()
+	inst := cxm.InstanceTypeQuotaItem{
+		InstanceType: "IT5.8XLARGE128",
+		CPU:          32,
+		Memory:       128,
+		StorageBlock: 2,
+		Externals: cxm.Externals{
+			StorageBlockAttr: cxm.StorageBlock{Type: "LOCAL_NVME", MinSize: 3570, MaxSize: 3570},
+		},
+	}
+	result := computeCapacity(ctx, 50, nil, inst, nil, nil, nil)
+	if storage := result[corev1.ResourceEphemeralStorage]; storage.String() != "50G" {
+		t.Errorf("expected ephemeral storage 50G without instance store policy, got %s", storage.String())
+	}
+	result = computeCapacity(ctx, 50, lo.ToPtr(api.InstanceStorePolicyRAID0), inst, nil, nil, nil)
+	if storage := result[corev1.ResourceEphemeralStorage]; storage.String() != "7140G" {
+		t.Errorf("expected ephemeral storage 7140G from local disks, got %s", storage.String())
+	}
+	inst.StorageBlock = 0
+	result = computeCapacity(ctx, 50, lo.ToPtr(api.InstanceStorePolicyRAID0), inst, nil, nil, nil)
+	if storage := result[corev1.ResourceEphemeralStorage]; storage.String() != "50G" {
+		t.Errorf("expected ephemeral storage 50G without local disks, got %s", storage.String())
+	}
+}
+
 func TestComputeRequirements(t *testing.T) {
 	inst := cxm.InstanceTypeQuotaItem{
 		InstanceType:   "S5.LARGE8",
@@ -400,6 +426,9 @@ func TestComputeRequirements(t *testing.T) {
 	if got := reqs.Get(api.LabelInstanceMemoryGB); !got.Has("8") {
 		t.Errorf("expected 8 GB memory in requirements")
 	}
+	if got := reqs.Get(api.LabelInstanceCategory); !got.Has(api.InstanceCategoryGeneral) {
+		t.Errorf("expected instance category general in requirements")
+	}
 }
 
 func TestComputeRequirements_DefaultArch(t *testing.T) {
@@ -446,7 +475,7 @@ func TestNewInstanceType(t *testing.T) {
 		},
 	}
 	version := semver.MustParse("1.30.0")
-	it := NewInstanceType(ctx, "ap-guangzhou", 50, inst, version,
+	it := NewInstanceType(ctx, "ap-guangzhou", 50, nil, inst, version,
 		nil, nil, nil, nil, nil, offerings, nil, nil)
 	if it.Name != "S5.LARGE8" {
 		t.Errorf("expected name S5.LARGE8, got %s", it.Name)
@@ -490,7 +519,7 @@ func TestNewInstanceType_WithClusterInfo(t *testing.T) {
 		},
 	}
 
-	it := NewInstanceType(ctx, "ap-guangzhou", 50, inst, version,
+	it := NewInstanceType(ctx, "ap-guangzhou", 50, nil, inst, version,
 		nil, nil, nil, nil, nil, offerings, nil, clsInfo)
 	if it == nil {
 		t.Fatal("expected non-nil instance type")
@@ -502,6 +531,74 @@ func TestNewInstanceType_WithClusterInfo(t *testing.T) {
 	}
 }
 
+func TestNewInstanceType_WithClusterInfoAndKubeletMaxPods(t *testing.T) {
+	ctx := testCtx()
+	inst := cxm.InstanceTypeQuotaItem{
+		InstanceType:   "S5.LARGE8",
+		CPU:            4,
+		Memory:         8,
+		InstanceFamily: "S5",
+		Arch:           "amd64",
+	}
+	version := semver.MustParse("1.30.0")
+	property := `{"NetworkType":"GR"}`
+	maxNodePodNum := uint64(64)
+	clsInfo := &tke2018.Cluster{
+		Property: &property,
+		ClusterNetworkSettings: &tke2018.ClusterNetworkSettings{
+			MaxNodePodNum: &maxNodePodNum,
+		},
+	}
+
+	tests := []struct {
+		maxPods  int32
+		expected int64
+	}{
+		{maxPods: 30, expected: 30},
+		{maxPods: 100, expected: 61},
+	}
+	for _, tt := range tests {
+		it := NewInstanceType(ctx, "ap-guangzhou", 50, nil, inst, version,
+			lo.ToPtr(tt.maxPods), nil, nil, nil, nil, nil, nil, clsInfo)
+		podsQty := it.Capacity[corev1.ResourcePods]
+		if podsQty.Value() != tt.expected {
+			t.Errorf("maxPods %d: expected %d pods, got %d", tt.maxPods, tt.expected, podsQty.Value())
+		}
+	}
+}
+
+func TestNewInstanceType_WithKubeletConfiguration(t *testing.T) {
+	ctx := testCtx()
+	inst := cxm.InstanceTypeQuotaItem{
+		InstanceType:   "S5.LARGE8",
+		CPU:            4,
+		Memory:         8,
+		InstanceFamily: "S5",
+		Arch:           "amd64",
+	}
+	version := semver.MustParse("1.30.0")
+
+	it := NewInstanceType(ctx, "ap-guangzhou", 50, nil, inst, version,
+		nil, lo.ToPtr(int32(10)),
+		map[string]string{"cpu": "500m"},
+		map[string]string{"memory": "1Gi"},
+		map[string]string{MemoryAvailable: "500Mi"},
+		nil, nil, nil)
+
+	if it.Capacity.Pods().Value() != 40 {
+		t.Errorf("expected 40 pods from podsPerCore, got %d", it.Capacity.Pods().Value())
+	}
+	if it.Overhead.KubeReserved.Cpu().String() != "500m" {
+		t.Errorf("expected kube reserved cpu 500m, got %s", it.Overhead.KubeReserved.Cpu().String())
+	}
+	if it.Overhead.SystemReserved.Memory().String() != "1Gi" {
+		t.Errorf("expected system reserved memory 1Gi, got %s", it.Overhead.SystemReserved.Memory().String())
+	}
+	if it.Overhead.EvictionThreshold.Memory().String() != "500Mi" {
+		t.Errorf("expected eviction threshold memory 500Mi, got %s", it.Overhead.EvictionThreshold.Memory().String())
+	}
+}
+
 func TestNewInstanceType_VPCCNICluster(t *testing.T) {
 	ctx := testCtx()
 	inst := cxm.InstanceTypeQuotaItem{
@@ -529,7 +626,7 @@ func TestNewInstanceType_VPCCNICluster(t *testing.T) {
 		Property: &property,
 	}
 
-	it := NewInstanceType(ctx, "ap-guangzhou", 50, inst, version,
+	it := NewInstanceType(ctx, "ap-guangzhou", 50, nil, inst, version,
 		nil, nil, nil, nil, nil, offerings, nil, clsInfo)
 	if it == nil {
 		t.Fatal("expected non-nil instance type")
@@ -558,7 +655,7 @@ func TestComputeCapacity_WithENILimits(t *testing.T) {
 			},
 		},
 	}
-	result := computeCapacity(ctx, 50, inst, nil, nil, eniLimits)
+	result := computeCapacity(ctx, 50, nil, inst, nil, nil, eniLimits)
 	// Should have EIP resource when eniLimits is non-empty
 	if _, ok := result[corev1.ResourceName(api.TKELabelEIP)]; !ok {
 		t.Error("expected EIP in capacity when eniLimits provided")
@@ -661,7 +758,7 @@ func TestNewInstanceType_ClusterPropertyNilENILimits(t *testing.T) {
 		},
 	}
 
-	it := NewInstanceType(ctx, "ap-guangzhou", 50, inst, version,
+	it := NewInstanceType(ctx, "ap-guangzhou", 50, nil, inst, version,
 		nil, nil, nil, nil, nil, offerings, eniLimits, clsInfo)
 	if it == nil {
 		t.Fatal("expected non-nil instance type")
@@ -708,7 +805,7 @@ func TestNewInstanceType_ClusterInfoNilProperty(t *testing.T) {
 		Property: nil,
 	}
 
-	it := NewInstanceType(ctx, "ap-guangzhou", 50, inst, version,
+	it := NewInstanceType(ctx, "ap-guangzhou", 50, nil, inst, version,
 		nil, nil, nil, nil, nil, offerings, nil, clsInfo)
 	if it == nil {
 		t.Fatal("expected non-nil instance type when clsInfo.Property is nil")
@@ -759,7 +856,7 @@ func TestNewInstanceType_WithENILimitsAndVPCCNI(t *testing.T) {
 		},
 	}
 
-	it := NewInstanceType(ctx, "ap-guangzhou", 50, inst, version,
+	it := NewInstanceType(ctx, "ap-guangzhou", 50, nil, inst, version,
 		nil, nil, nil, nil, nil, offerings, eniLimits, clsInfo)
 	if it == nil {
 		t.Fatal("expected non-nil instance type")
@@ -779,3 +876,106 @@ func TestNewInstanceType_WithENILimitsAndVPCCNI(t *testing.T) {
 		t.Error("expected SubENI in capacity for VPC-CNI cluster with eniLimits")
 	}
 }
+
+func TestNewClusterProperty(t *testing.T) {
+	if NewClusterProperty(nil).IsDualStack {
+		t.Error("expected no dual-stack without cluster info")
+	}
+	if NewClusterProperty(&tke2018.Cluster{Property: lo.ToPtr("invalid")}).IsDualStack {
+		t.Error("expected no dual-stack with an invalid property")
+	}
+	if !NewClusterProperty(&tke2018.Cluster{Property: lo.ToPtr(`{"NetworkType":"VPC-CNI","IsDualStack":true}`)}).IsDualStack {
+		t.Error("expected dual-stack from the cluster property")
+	}
+}
+
+func TestInstanceCategory(t *testing.T) {
+	tests := []struct {
+		name string
+		info cxm.InstanceTypeQuotaItem
+		want string
+	}{
+		{"standard", cxm.InstanceTypeQuotaItem{InstanceFamily: "S5", TypeName: "Standard S5", CPU: 4, Memory: 8}, api.InstanceCategoryGeneral},
+		{"memory optimized", cxm.InstanceTypeQuotaItem{InstanceFamily: "M5", TypeName: "Memory Optimized M5", CPU: 4, Memory: 32}, api.InstanceCategoryGeneral},
+		{"gpu", cxm.InstanceTypeQuotaItem{InstanceFamily: "GN7", Gpu: 1, CPU: 8, Memory: 32}, api.InstanceCategoryGPU},
+		{"gpu type name", cxm.InstanceTypeQuotaItem{InstanceFamily: "GI3X", TypeName: "GPU Compute GI3X", CPU: 8, Memory: 32}, api.InstanceCategoryGPU},
+		{"gpu bare metal", cxm.InstanceTypeQuotaItem{InstanceFamily: "BMG5", Gpu: 8, CPU: 96, Memory: 384}, api.InstanceCategoryGPU},
+		{"fpga", cxm.InstanceTypeQuotaItem{InstanceFamily: "FX4", Fpga: 1, CPU: 8, Memory: 32}, api.InstanceCategoryFPGA},
+		{"bare metal", cxm.InstanceTypeQuotaItem{InstanceFamily: "BMS5", CPU: 96, Memory: 384}, api.InstanceCategoryBareMetal},
+		{"bare metal type name", cxm.InstanceTypeQuotaItem{InstanceFamily: "S5se", TypeName: "Bare Metal S5se", CPU: 96, Memory: 384}, api.InstanceCategoryBareMetal},
+		{"high memory", cxm.InstanceTypeQuotaItem{InstanceFamily: "M6mp", CPU: 4, Memory: 48}, api.InstanceCategoryHighMemory},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := instanceCategory(tt.info); got != tt.want {
+				t.Errorf("instanceCategory() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestComputeRequirements_GPU(t *testing.T) {
+	offerings := cloudprovider.Offerings{
+		&cloudprovider.Offering{
+			Requirements: scheduling.NewRequirements(
+				scheduling.NewRequirement(v1.CapacityTypeLabelKey, corev1.NodeSelectorOpIn, v1.CapacityTypeOnDemand),
+				scheduling.NewRequirement(corev1.LabelTopologyZone, corev1.NodeSelectorOpIn, "100003"),
+				scheduling.NewRequirement(api.LabelCBSToplogy, corev1.NodeSelectorOpIn, "ap-guangzhou-3"),
+			),
+			Available: true,
+		},
+	}
+	gpu := cxm.InstanceTypeQuotaItem{
+		InstanceType:   "GN7.2XLARGE32",
+		CPU:            8,
+		Memory:         32,
+		InstanceFamily: "GN7",
+		Gpu:            1,
+		Externals: cxm.Externals{
+			GpuAttr: cxm.GpuAttr{Type: "NVIDIA Tesla T4"},
+			GPUDesc: "1 * NVIDIA T4 16GB",
+		},
+	}
+	reqs := computeRequirements(offerings, "ap-guangzhou", gpu)
+	for key, want := range map[string]string{
+		api.LabelInstanceGPUCount:  "1",
+		api.LabelInstanceGPUName:   "t4",
+		api.LabelInstanceGPUMemory: "16384",
+	} {
+		if got := reqs.Get(key); got.Operator() != corev1.NodeSelectorOpIn || !got.Has(want) {
+			t.Errorf("expected %s In [%s], got %s", key, want, got)
+		}
+	}
+
+	cpu := cxm.InstanceTypeQuotaItem{InstanceType: "S5.LARGE8", CPU: 4, Memory: 8, InstanceFamily: "S5"}
+	reqs = computeRequirements(offerings, "ap-guangzhou", cpu)
+	for _, key := range []string{api.LabelInstanceGPUCount, api.LabelInstanceGPUName, api.LabelInstanceGPUMemory} {
+		if got := reqs.Get(key); got.Operator() != corev1.NodeSelectorOpDoesNotExist {
+			t.Errorf("expected %s DoesNotExist, got %s", key, got)
+		}
+	}
+}
+
+func TestGPUNameAndMemory(t *testing.T) {
+	tests := []struct {
+		gpuType    string
+		gpuDesc    string
+		wantName   string
+		wantMemory int64
+	}{
+		{"NVIDIA Tesla V100", "8 * NVIDIA V100 32GB", "v100", 32768},
+		{"NVIDIA A10", "1 * NVIDIA A10 24 GB", "a10", 24576},
+		{"T4", "NVIDIA T4 (16GiB)", "t4", 16384},
+		{"NVIDIA GeForce RTX 4090", "", "geforce-rtx-4090", 0},
+		{"t4", "1/4 * NVIDIA T4", "t4", 0},
+		{"", "", "", 0},
+	}
+	for _, tt := range tests {
+		if got := gpuName(tt.gpuType); got != tt.wantName {
+			t.Errorf("gpuName(%q) = %q, want %q", tt.gpuType, got, tt.wantName)
+		}
+		if got := gpuMemoryInMiB(tt.gpuDesc); got != tt.wantMemory {
+			t.Errorf("gpuMemoryInMiB(%q) = %d, want %d", tt.gpuDesc, got, tt.wantMemory)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+/*
+Copyright (C) 2012-2025 Tencent. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package machine
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/samber/lo"
+	api "github.com/tencentcloud/karpenter-provider-tke/pkg/apis/v1beta1"
+	capiv1beta1 "github.com/tencentcloud/karpenter-provider-tke/staging/nativenode/v1beta1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+)
+
+// addressReservationTTL bounds how long an EIP stays reserved for a machine which never shows up in the cache.
+const addressReservationTTL = time.Minute
+
+// inflightAddresses reserves the EIPs picked for the machines which haven't shown up in the informer cache yet, as the
+// EIPs taken by the machines are only known from the cached Machine list.
+type inflightAddresses struct {
+	mu       sync.Mutex
+	reserved map[string]time.Time
+}
+
+func newInflightAddresses() *inflightAddresses {
+	return &inflightAddresses{reserved: map[string]time.Time{}}
+}
+
+// release drops the reservation of the EIP, once the machine taking it shows up in the cache or fails to be created.
+func (i *inflightAddresses) release(addressID string) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	delete(i.reserved, addressID)
+}
+
+// pickAddress reserves the first EIP of the address pool which is neither associated, taken by another Machine whose
+// instance hasn't been associated with it yet, nor reserved by a concurrent launch.
+func (p *DefaultProvider) pickAddress(ctx context.Context, nodeClass *api.TKEMachineNodeClass) (string, error) {
+	p.inflightAddresses.mu.Lock()
+	defer p.inflightAddresses.mu.Unlock()
+
+	machineList := &capiv1beta1.MachineList{}
+	if err := p.kubeClient.List(ctx, machineList); err != nil {
+		return "", fmt.Errorf("listing machines, %w", err)
+	}
+	inUse := sets.New[string]()
+	for _, m := range machineList.Items {
+		providerSpec, err := capiv1beta1.ProviderSpecFromRawExtension(m.Spec.ProviderSpec.Value)
+		if err != nil || providerSpec.InternetAccessible == nil {
+			continue
+		}
+		inUse.Insert(lo.FromPtr(providerSpec.InternetAccessible.AddressID))
+	}
+	now := time.Now()
+	for addressID, reservedAt := range p.inflightAddresses.reserved {
+		if inUse.Has(addressID) || now.Sub(reservedAt) > addressReservationTTL {
+			delete(p.inflightAddresses.reserved, addressID)
+		}
+	}
+	address, found := lo.Find(nodeClass.Status.Addresses, func(a api.Address) bool {
+		_, reserved := p.inflightAddresses.reserved[a.ID]
+		return !inUse.Has(a.ID) && !reserved
+	})
+	if !found {
+		return "", cloudprovider.NewInsufficientCapacityError(fmt.Errorf("no unassociated address in the address pool"))
+	}
+	p.inflightAddresses.reserved[address.ID] = now
+	return address.ID, nil
+}
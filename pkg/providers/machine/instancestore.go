@@ -0,0 +1,85 @@
+/*
+Copyright (C) 2012-2025 Tencent. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package machine
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/samber/lo"
+	api "github.com/tencentcloud/karpenter-provider-tke/pkg/apis/v1beta1"
+	capiv1beta1 "github.com/tencentcloud/karpenter-provider-tke/staging/nativenode/v1beta1"
+)
+
+const (
+	defaultRuntimeRootDir = "/var/lib/containerd"
+	kubeletRootDir        = "/var/lib/kubelet"
+	instanceStoreMountDir = "/mnt/k8s-disks"
+)
+
+// raid0Script assembles the local disks of the instance into a RAID0 array and bind mounts it to the
+// container runtime and kubelet root directories. The cloud disks (serial disk-xxx), the mounted and the
+// partitioned disks are skipped, the script is a no-op on the instance types without local disks. It's run by bash
+// through a heredoc, since the pre-init script keeps the interpreter line of the user script, e.g. #!/bin/sh.
+const raid0Script = `# setup the local instance store disks as RAID0 by karpenter
+bash <<'KARPENTER_INSTANCE_STORE'
+setup_instance_store() {
+  local devices=()
+  for dev in $(lsblk -dpno NAME,TYPE | awk '$2 == "disk" {print $1}'); do
+    [[ "$(lsblk -dno SERIAL "$dev")" == disk-* ]] && continue
+    [[ -n "$(lsblk -no MOUNTPOINT "$dev" | tr -d '[:space:]')" ]] && continue
+    [[ "$(lsblk -no NAME "$dev" | wc -l)" -gt 1 ]] && continue
+    devices+=("$dev")
+  done
+  [[ ${#devices[@]} -eq 0 ]] && return 0
+  local array="${devices[0]}"
+  if [[ ${#devices[@]} -gt 1 ]]; then
+    array=/dev/md/k8s-disks
+    mdadm --create --force --verbose "$array" --level=0 --name=k8s-disks --raid-devices=${#devices[@]} "${devices[@]}"
+    while [[ ! -e "$array" ]]; do sleep 1; done
+    mdadm --detail --scan >> /etc/mdadm.conf
+  fi
+  mkfs.ext4 -F "$array"
+  mkdir -p %[1]s
+  mount "$array" %[1]s
+  echo "$array %[1]s ext4 defaults,nofail 0 2" >> /etc/fstab
+  for dir in %[2]s; do
+    mkdir -p "%[1]s${dir}" "$dir"
+    mount --bind "%[1]s${dir}" "$dir"
+    echo "%[1]s${dir} $dir none bind,nofail 0 0" >> /etc/fstab
+  done
+}
+setup_instance_store
+KARPENTER_INSTANCE_STORE
+`
+
+// renderInstanceStore prepends the script to setup the local disks to the pre-init script of the machine.
+func renderInstanceStore(nodeClass *api.TKEMachineNodeClass, providerSpec *capiv1beta1.CXMMachineProviderSpec, machine *capiv1beta1.Machine) {
+	if lo.FromPtr(nodeClass.Spec.InstanceStorePolicy) != api.InstanceStorePolicyRAID0 {
+		return
+	}
+	runtimeRootDir := lo.Ternary(machine.Spec.RuntimeRootDir != "", machine.Spec.RuntimeRootDir, defaultRuntimeRootDir)
+	script := fmt.Sprintf(raid0Script, instanceStoreMountDir, strings.Join([]string{runtimeRootDir, kubeletRootDir}, " "))
+	preInit := providerSpec.Lifecycle.PreInit
+	// keep the interpreter line of the user script at the top
+	if strings.HasPrefix(preInit, "#!") {
+		shebang, rest, _ := strings.Cut(preInit, "\n")
+		providerSpec.Lifecycle.PreInit = shebang + "\n" + script + rest
+		return
+	}
+	providerSpec.Lifecycle.PreInit = "#!/bin/bash\n" + script + preInit
+}
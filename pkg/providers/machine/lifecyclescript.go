@@ -0,0 +1,147 @@
+/*
+Copyright (C) 2012-2025 Tencent. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package machine
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/samber/lo"
+	api "github.com/tencentcloud/karpenter-provider-tke/pkg/apis/v1beta1"
+	"github.com/tencentcloud/karpenter-provider-tke/pkg/operator/options"
+	"go.uber.org/multierr"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+)
+
+// maxLifecycleScriptSize is the maximum size of each rendered lifecycle script of the machine.
+const maxLifecycleScriptSize = 16 * 1024
+
+// scriptData is the variables of the lifecycle script fragments.
+type scriptData struct {
+	ClusterID    string
+	NodePool     string
+	NodeClaim    string
+	Zone         string
+	InstanceType string
+	CapacityType string
+}
+
+// renderLifecycleScript appends the rendered fragments to the inline script in order.
+func (p *DefaultProvider) renderLifecycleScript(ctx context.Context, script *string, fragments []api.ScriptSource, data scriptData) (string, error) {
+	parts := lo.Ternary(lo.FromPtr(script) != "", []string{lo.FromPtr(script)}, nil)
+	for i, fragment := range fragments {
+		content, err := p.resolveScriptSource(ctx, fragment)
+		if err != nil {
+			return "", fmt.Errorf("resolving script fragment %d, %w", i, err)
+		}
+		tmpl, err := template.New(fmt.Sprintf("fragment-%d", i)).Option("missingkey=error").Parse(content)
+		if err != nil {
+			return "", fmt.Errorf("parsing script fragment %d, %w", i, err)
+		}
+		buf := &bytes.Buffer{}
+		if err = tmpl.Execute(buf, data); err != nil {
+			return "", fmt.Errorf("rendering script fragment %d, %w", i, err)
+		}
+		parts = append(parts, buf.String())
+	}
+	return strings.Join(parts, "\n"), nil
+}
+
+// resolveScriptSource reads the content of the fragment. Only the Secrets and ConfigMaps labeled with
+// LabelLifecycleScript are read, and never the Secret of the credentials of karpenter, since the rendered
+// scripts are readable in the userdata of the instance and in the Machine.
+func (p *DefaultProvider) resolveScriptSource(ctx context.Context, source api.ScriptSource) (string, error) {
+	switch {
+	case source.Inline != nil:
+		return lo.FromPtr(source.Inline), nil
+	case source.SecretKeyRef != nil:
+		opts := options.FromContext(ctx)
+		if source.SecretKeyRef.Name == opts.APIKeySecretName {
+			return "", fmt.Errorf("secret %s holds the credentials of karpenter and can't be used by lifecycle scripts", source.SecretKeyRef.Name)
+		}
+		secret, err := p.kubernetesInterface.CoreV1().Secrets(opts.SystemNamespace).Get(ctx, source.SecretKeyRef.Name, metav1.GetOptions{})
+		if err != nil {
+			return "", fmt.Errorf("getting secret %s, %w", source.SecretKeyRef.Name, err)
+		}
+		if secret.Labels[api.LabelLifecycleScript] != "true" {
+			return "", fmt.Errorf("secret %s isn't labeled with %s=true", source.SecretKeyRef.Name, api.LabelLifecycleScript)
+		}
+		content, ok := secret.Data[source.SecretKeyRef.Key]
+		if !ok {
+			return "", fmt.Errorf("key %s not found in secret %s", source.SecretKeyRef.Key, source.SecretKeyRef.Name)
+		}
+		return string(content), nil
+	case source.ConfigMapKeyRef != nil:
+		configMap, err := p.kubernetesInterface.CoreV1().ConfigMaps(options.FromContext(ctx).SystemNamespace).Get(ctx, source.ConfigMapKeyRef.Name, metav1.GetOptions{})
+		if err != nil {
+			return "", fmt.Errorf("getting configmap %s, %w", source.ConfigMapKeyRef.Name, err)
+		}
+		if configMap.Labels[api.LabelLifecycleScript] != "true" {
+			return "", fmt.Errorf("configmap %s isn't labeled with %s=true", source.ConfigMapKeyRef.Name, api.LabelLifecycleScript)
+		}
+		content, ok := configMap.Data[source.ConfigMapKeyRef.Key]
+		if !ok {
+			return "", fmt.Errorf("key %s not found in configmap %s", source.ConfigMapKeyRef.Key, source.ConfigMapKeyRef.Name)
+		}
+		return content, nil
+	}
+	return "", fmt.Errorf("empty script source")
+}
+
+// ValidateLifecycleScript renders the lifecycle scripts of the nodeClass with sample variables, so that the missing
+// fragments, the invalid templates and the oversized scripts are reported before any node is launched.
+func (p *DefaultProvider) ValidateLifecycleScript(ctx context.Context, nodeClass *api.TKEMachineNodeClass) error {
+	if nodeClass.Spec.LifecycleScript == nil {
+		return nil
+	}
+	zone := ""
+	if len(nodeClass.Status.Subnets) != 0 {
+		zone = nodeClass.Status.Subnets[0].Zone
+	}
+	data := scriptData{
+		ClusterID:    p.clusterID,
+		NodePool:     "default",
+		NodeClaim:    "default-xxxxx",
+		Zone:         zone,
+		InstanceType: "S5.MEDIUM4",
+		CapacityType: v1.CapacityTypeOnDemand,
+	}
+	preInit, err := p.renderLifecycleScript(ctx, nodeClass.Spec.LifecycleScript.PreInitScript, nodeClass.Spec.LifecycleScript.PreInitScripts, data)
+	if err != nil {
+		return fmt.Errorf("rendering pre-init script, %w", err)
+	}
+	postInit, err := p.renderLifecycleScript(ctx, nodeClass.Spec.LifecycleScript.PostInitScript, nodeClass.Spec.LifecycleScript.PostInitScripts, data)
+	if err != nil {
+		return fmt.Errorf("rendering post-init script, %w", err)
+	}
+	return multierr.Combine(
+		validateLifecycleScriptSize("pre-init", preInit),
+		validateLifecycleScriptSize("post-init", postInit),
+	)
+}
+
+// validateLifecycleScriptSize rejects the lifecycle scripts which exceed the size limit of the machine.
+func validateLifecycleScriptSize(name, script string) error {
+	if len(script) > maxLifecycleScriptSize {
+		return fmt.Errorf("%s script is %d bytes, exceeding the limit of %d bytes", name, len(script), maxLifecycleScriptSize)
+	}
+	return nil
+}
@@ -18,7 +18,6 @@ package machine
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"math"
 	"net"
@@ -29,11 +28,15 @@ import (
 
 	"github.com/samber/lo"
 	api "github.com/tencentcloud/karpenter-provider-tke/pkg/apis/v1beta1"
+	"github.com/tencentcloud/karpenter-provider-tke/pkg/providers/instancetype"
+	"github.com/tencentcloud/karpenter-provider-tke/pkg/providers/vpc"
 	"github.com/tencentcloud/karpenter-provider-tke/pkg/providers/zone"
 	capiv1beta1 "github.com/tencentcloud/karpenter-provider-tke/staging/nativenode/v1beta1"
+	"go.uber.org/multierr"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	utilrand "k8s.io/apimachinery/pkg/util/rand"
+	"k8s.io/client-go/kubernetes"
 	"k8s.io/klog/v2"
 	"k8s.io/kubernetes/pkg/apis/core"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -44,31 +47,34 @@ import (
 
 const (
 	maxInstanceTypes = 60
+	diskEncrypt      = "ENCRYPT"
 )
 
-type Tag struct {
-	TagKey   string `json:"tagKey"`
-	TagValue string `json:"tagValue"`
-}
-
 type Provider interface {
 	Get(context.Context, string) (*capiv1beta1.Machine, error)
 	List(context.Context) ([]*capiv1beta1.Machine, error)
 	Create(context.Context, *api.TKEMachineNodeClass, *v1.NodeClaim, []*cloudprovider.InstanceType) (*capiv1beta1.Machine, *capiv1beta1.CXMMachineProviderSpec, error)
 	Delete(context.Context, *v1.NodeClaim) error
+	ValidateLifecycleScript(context.Context, *api.TKEMachineNodeClass) error
 }
 
 type DefaultProvider struct {
-	kubeClient   client.Client
-	zoneProvider zone.Provider
-	clusterID    string
+	kubernetesInterface kubernetes.Interface
+	kubeClient          client.Client
+	zoneProvider        zone.Provider
+	clusterID           string
+	inflightIPs         *inflightIPs
+	inflightAddresses   *inflightAddresses
 }
 
-func NewDefaultProvider(_ context.Context, kubeClient client.Client, zoneProvider zone.Provider, clusterID string) *DefaultProvider {
+func NewDefaultProvider(_ context.Context, kubernetesInterface kubernetes.Interface, kubeClient client.Client, zoneProvider zone.Provider, clusterID string) *DefaultProvider {
 	return &DefaultProvider{
-		kubeClient:   kubeClient,
-		zoneProvider: zoneProvider,
-		clusterID:    clusterID,
+		kubernetesInterface: kubernetesInterface,
+		kubeClient:          kubeClient,
+		zoneProvider:        zoneProvider,
+		clusterID:           clusterID,
+		inflightIPs:         newInflightIPs(),
+		inflightAddresses:   newInflightAddresses(),
 	}
 }
 
@@ -114,6 +120,7 @@ func (p *DefaultProvider) Create(ctx context.Context, nodeClass *api.TKEMachineN
 	if !schedulingRequirements.HasMinValues() {
 		instanceTypes = p.filterInstanceTypes(nodeClaim, instanceTypes)
 	}
+	instanceTypes = filterInstanceTypesByImages(nodeClass, instanceTypes)
 	instanceTypes, err := cloudprovider.InstanceTypes(instanceTypes).Truncate(ctx, schedulingRequirements, maxInstanceTypes)
 	if err != nil {
 		return nil, nil, fmt.Errorf("truncating instance types, %w", err)
@@ -125,23 +132,19 @@ func (p *DefaultProvider) Create(ctx context.Context, nodeClass *api.TKEMachineN
 
 	instanceTypes = orderInstanceTypesByPrice(instanceTypes, schedulingRequirements)
 
-	zone, err := p.zoneProvider.ZoneFromID(instanceTypes[0].Offerings.Available().Compatible(schedulingRequirements).Cheapest().Requirements.Get(corev1.LabelTopologyZone).Any())
+	launchOffering := instanceTypes[0].Offerings.Available().Compatible(schedulingRequirements).Cheapest()
+	zone, err := p.zoneProvider.ZoneFromID(launchOffering.Requirements.Get(corev1.LabelTopologyZone).Any())
 	if err != nil {
 		return nil, nil, fmt.Errorf("getting zone failed: %v", err)
 	}
-	var subnetID string
-
-	for _, z := range nodeClass.Status.Subnets {
-		if z.Zone == zone {
-			subnetID = z.ID
-			break
-		}
-	}
-	if len(subnetID) == 0 {
-		return nil, nil, fmt.Errorf("subnet for %s not found", zone)
+	subnet, err := p.pickSubnet(nodeClass, zone)
+	if err != nil {
+		return nil, nil, err
 	}
+	subnetID := subnet.ID
 
 	machine := &capiv1beta1.Machine{}
+	created := false
 	providerSpec := &capiv1beta1.CXMMachineProviderSpec{}
 	labels := map[string]string{
 		v1.NodePoolLabelKey: nodeClaim.GetLabels()[v1.NodePoolLabelKey],
@@ -149,6 +152,15 @@ func (p *DefaultProvider) Create(ctx context.Context, nodeClass *api.TKEMachineN
 		api.LabelNodeClass:  nodeClass.Name,
 	}
 
+	if len(nodeClass.Spec.HPCClusterSelectorTerms) != 0 {
+		hpcCluster, found := lo.Find(nodeClass.Status.HPCClusters, func(c api.HPCCluster) bool { return c.Zone == zone })
+		if !found {
+			return nil, nil, fmt.Errorf("hpc cluster for %s not found", zone)
+		}
+		providerSpec.HpcClusterId = hpcCluster.ID
+		labels[api.LabelRDMA] = "true"
+	}
+
 	if instanceTypes[0].Requirements.Get(api.LabelInstanceFamily).Len() > 0 {
 		labels[api.LabelInstanceFamily] = instanceTypes[0].Requirements.Get(api.LabelInstanceFamily).Values()[0]
 	}
@@ -158,25 +170,40 @@ func (p *DefaultProvider) Create(ctx context.Context, nodeClass *api.TKEMachineN
 	if instanceTypes[0].Requirements.Get(api.LabelInstanceMemoryGB).Len() > 0 {
 		labels[api.LabelInstanceMemoryGB] = instanceTypes[0].Requirements.Get(api.LabelInstanceMemoryGB).Values()[0]
 	}
+	for _, key := range []string{api.LabelInstanceCategory, api.LabelInstanceGPUCount, api.LabelInstanceGPUName, api.LabelInstanceGPUMemory} {
+		if vals := instanceTypes[0].Requirements.Get(key).Values(); len(vals) > 0 {
+			labels[key] = vals[0]
+		}
+	}
 	if vals := instanceTypes[0].Requirements.Get(corev1.LabelArchStable).Values(); len(vals) > 0 {
 		labels[corev1.LabelArchStable] = vals[0]
 	}
-	
-	cheapestOffering := instanceTypes[0].Offerings.Available().Compatible(schedulingRequirements).Cheapest()
-	if cheapestOffering != nil && cheapestOffering.Requirements.Get(v1.CapacityTypeLabelKey).Len() > 0 {
-		labels[v1.CapacityTypeLabelKey] = cheapestOffering.Requirements.Get(v1.CapacityTypeLabelKey).Any()
+
+	if launchOffering.Requirements.Get(v1.CapacityTypeLabelKey).Len() > 0 {
+		labels[v1.CapacityTypeLabelKey] = launchOffering.Requirements.Get(v1.CapacityTypeLabelKey).Any()
 	}
 
 	machine.SetLabels(labels)
 	//TODO may be conflict with existed machineset
 	machine.GenerateName = fmt.Sprintf("np-%s-", utilrand.String(8))
 	machine.Spec.DisplayName = nodeClaim.Name
+	machine.Spec.KubeletVersion = nodeClass.Spec.KubeletVersion
+	machine.Spec.RuntimeVersion = nodeClass.Spec.RuntimeVersion
 	machine.Spec.SubnetID = subnetID
 	machine.Spec.Zone = zone
-	if machine.GetLabels()[v1.CapacityTypeLabelKey] == v1.CapacityTypeSpot {
+	switch machine.GetLabels()[v1.CapacityTypeLabelKey] {
+	case v1.CapacityTypeSpot:
 		machine.Spec.ProviderSpec.Type = capiv1beta1.MachineTypeNativeCVM
 		providerSpec.InstanceChargeType = capiv1beta1.SpotpaidChargeType
-	} else {
+	case api.CapacityTypePrepaid:
+		machine.Spec.ProviderSpec.Type = capiv1beta1.MachineTypeNative
+		providerSpec.InstanceChargeType = lo.Ternary(lo.FromPtr(nodeClass.Spec.Prepaid).ChargeType == api.PrepaidChargeTypeUnderwrite,
+			capiv1beta1.UnderwriteChargeType, capiv1beta1.PrepaidChargeType)
+		providerSpec.InstanceChargePrepaid = &capiv1beta1.InstanceChargePrepaid{
+			Period:    instancetype.PrepaidPeriod(nodeClass.Spec.Prepaid),
+			RenewFlag: capiv1beta1.RenewFlagType(lo.Ternary(lo.FromPtr(nodeClass.Spec.Prepaid).RenewFlag != "", lo.FromPtr(nodeClass.Spec.Prepaid).RenewFlag, api.RenewFlagNotifyAndManualRenew)),
+		}
+	default:
 		machine.Spec.ProviderSpec.Type = capiv1beta1.MachineTypeNative
 		providerSpec.InstanceChargeType = capiv1beta1.PostpaidByHourChargeType
 	}
@@ -190,11 +217,8 @@ func (p *DefaultProvider) Create(ctx context.Context, nodeClass *api.TKEMachineN
 
 	providerSpec.InstanceType = instanceTypes[0].Name
 	providerSpec.KeyIDs = lo.Map(nodeClass.Status.SSHKeys, func(s api.SSHKey, _ int) string { return s.ID })
-	providerSpec.SecurityGroupIDs = lo.Map(nodeClass.Status.SecurityGroups, func(s api.SecurityGroup, _ int) string { return s.ID })
-
-	if len(providerSpec.SecurityGroupIDs) > 5 {
-		providerSpec.SecurityGroupIDs = providerSpec.SecurityGroupIDs[:5]
-	}
+	// the security groups in the status are ordered by priority and capped at the limit of an instance
+	providerSpec.SecurityGroupIDs = lo.Slice(lo.Map(nodeClass.Status.SecurityGroups, func(s api.SecurityGroup, _ int) string { return s.ID }), 0, vpc.MaxSecurityGroups)
 
 	if nodeClass.Spec.SystemDisk != nil {
 		providerSpec.SystemDisk.DiskSize = nodeClass.Spec.SystemDisk.Size
@@ -211,6 +235,10 @@ func (p *DefaultProvider) Create(ctx context.Context, nodeClass *api.TKEMachineN
 	if kmsID, ok := annots[api.AnnotationSystemDiskKMSID]; ok && kmsID != "" {
 		providerSpec.SystemDisk.KmsKeyId = kmsID
 	}
+	// the typed fields take precedence over the deprecated annotations
+	if nodeClass.Spec.SystemDisk != nil {
+		renderDiskEncryption(&providerSpec.SystemDisk, nodeClass.Spec.SystemDisk.Encrypted, nodeClass.Spec.SystemDisk.KMSKeyID)
+	}
 	dataDisksThroughput := p.getTargetAnnotations(api.AnnotationDataDisksThroughputKey, nodeClass.GetAnnotations())
 	dataDisksEncrypt := p.getTargetAnnotations(api.AnnotationDataDisksEncryptKey, nodeClass.GetAnnotations())
 	dataDisksKMSID := p.getTargetAnnotations(api.AnnotationDataDisksKMSID, nodeClass.GetAnnotations())
@@ -248,6 +276,16 @@ func (p *DefaultProvider) Create(ctx context.Context, nodeClass *api.TKEMachineN
 		if ok {
 			cxmDisk.ImageCacheId = imageCacheID
 		}
+		renderDiskEncryption(&cxmDisk, d.Encrypted, d.KMSKeyID)
+		if d.SnapshotID != nil {
+			cxmDisk.SnapshotId = lo.FromPtr(d.SnapshotID)
+		}
+		if d.ThroughputPerformance != nil {
+			cxmDisk.ThroughputPerformance = int(lo.FromPtr(d.ThroughputPerformance))
+		}
+		if d.ImageCacheID != nil {
+			cxmDisk.ImageCacheId = lo.FromPtr(d.ImageCacheID)
+		}
 		if d.FileSystem != nil {
 			cxmDisk.FileSystem = string(lo.FromPtr(d.FileSystem))
 		}
@@ -272,49 +310,30 @@ func (p *DefaultProvider) Create(ctx context.Context, nodeClass *api.TKEMachineN
 		if nodeClass.Spec.InternetAccessible.BandwidthPackageID != nil {
 			providerSpec.InternetAccessible.BandwidthPackageID = lo.FromPtr(nodeClass.Spec.InternetAccessible.BandwidthPackageID)
 		}
-		if machine.GetLabels()[v1.CapacityTypeLabelKey] == v1.CapacityTypeSpot {
+		if nodeClass.Spec.InternetAccessible.AddressType != nil {
+			providerSpec.InternetAccessible.AddressType = string(*nodeClass.Spec.InternetAccessible.AddressType)
+			providerSpec.InternetAccessible.PublicIPAssigned = *nodeClass.Spec.InternetAccessible.AddressType == api.AddressTypePublicIP
+		} else if machine.GetLabels()[v1.CapacityTypeLabelKey] == v1.CapacityTypeSpot {
 			providerSpec.InternetAccessible.AddressType = capiv1beta1.PublicIpAddressType
 			providerSpec.InternetAccessible.PublicIPAssigned = true
 		}
+		if len(nodeClass.Spec.InternetAccessible.AddressPoolTags) != 0 {
+			addressID, err := p.pickAddress(ctx, nodeClass)
+			if err != nil {
+				return nil, nil, err
+			}
+			// the reservation is kept for a created machine until it shows up in the cache
+			defer func() {
+				if !created {
+					p.inflightAddresses.release(addressID)
+				}
+			}()
+			providerSpec.InternetAccessible.AddressID = lo.ToPtr(addressID)
+		}
 	}
 
+	renderManagement(nodeClass, nodeClaim, instanceTypes[0], providerSpec, machine)
 	for k, v := range nodeClaim.Annotations {
-		result := strings.Split(k, api.AnnotationKubeletArgPrefix)
-		if len(result) == 2 {
-			providerSpec.Management.KubeletArgs = append(providerSpec.Management.KubeletArgs, fmt.Sprintf("%s=%s", result[1], v))
-		}
-		result = strings.Split(k, api.AnnotationKernelArgPrefix)
-		if len(result) == 2 {
-			providerSpec.Management.KernelArgs = append(providerSpec.Management.KernelArgs, fmt.Sprintf("%s=%s", result[1], v))
-		}
-		result = strings.Split(k, api.AnnotationHostsPrefix)
-		if len(result) == 2 {
-			if net.ParseIP(result[1]) == nil {
-				klog.Warningf("invalid IP address in host alias: %s", result[1])
-				continue
-			}
-			host := core.HostAlias{}
-			host.IP = result[1]
-			host.Hostnames = strings.Split(strings.ReplaceAll(v, " ", ""), ",")
-			providerSpec.Management.Hosts = append(providerSpec.Management.Hosts, host)
-		}
-		if k == api.AnnotationNameserversKey {
-			result = strings.Split(strings.ReplaceAll(v, " ", ""), ",")
-			if len(result) > 0 {
-				providerSpec.Management.Nameservers = append(providerSpec.Management.Nameservers, result...)
-				providerSpec.Management.Nameservers = lo.Uniq(providerSpec.Management.Nameservers)
-			}
-		}
-		if k == api.AnnotationHostnameKey {
-			if len(v) > 0 {
-				providerSpec.HostName = v
-			}
-		}
-		if k == api.AnnotationRuntimeRootKey {
-			if len(v) > 0 {
-				machine.Spec.RuntimeRootDir = v
-			}
-		}
 		if _, ok := instanceTypes[0].Capacity[corev1.ResourceName(api.ResourceNVIDIAGPU)]; ok {
 			if k == api.AnnotationGPUDriverKey {
 				if len(v) > 0 {
@@ -343,15 +362,37 @@ func (p *DefaultProvider) Create(ctx context.Context, nodeClass *api.TKEMachineN
 			}
 		}
 	}
-	providerSpec.Management.KubeletArgs = append(providerSpec.Management.KubeletArgs, fmt.Sprintf("register-with-taints=%s", v1.UnregisteredNoExecuteTaint.ToString()))
+	// the node registers with all its taints so that no pod is scheduled before karpenter initializes it, only the
+	// taints of the NodeClaim are reconciled by the Machine since the startup taints are removed by other controllers
+	providerSpec.Management.KubeletArgs = append(providerSpec.Management.KubeletArgs, fmt.Sprintf("register-with-taints=%s",
+		strings.Join(lo.Map(registerTaints(nodeClaim), func(t corev1.Taint, _ int) string { return t.ToString() }), ",")))
+	machine.Spec.Taints = nodeClaim.Spec.Taints
+	machine.Spec.ObjectMeta.Labels = lo.Assign(nodeClaim.GetLabels())
 	if nodeClass.Spec.LifecycleScript != nil {
-		if nodeClass.Spec.LifecycleScript.PreInitScript != nil {
-			providerSpec.Lifecycle.PreInit = lo.FromPtr(nodeClass.Spec.LifecycleScript.PreInitScript)
+		data := scriptData{
+			ClusterID:    p.clusterID,
+			NodePool:     nodeClaim.Labels[v1.NodePoolLabelKey],
+			NodeClaim:    nodeClaim.Name,
+			Zone:         zone,
+			InstanceType: instanceTypes[0].Name,
+			CapacityType: machine.GetLabels()[v1.CapacityTypeLabelKey],
+		}
+		if providerSpec.Lifecycle.PreInit, err = p.renderLifecycleScript(ctx, nodeClass.Spec.LifecycleScript.PreInitScript,
+			nodeClass.Spec.LifecycleScript.PreInitScripts, data); err != nil {
+			return nil, nil, fmt.Errorf("rendering pre-init script, %w", err)
 		}
-		if nodeClass.Spec.LifecycleScript.PostInitScript != nil {
-			providerSpec.Lifecycle.PostInit = lo.FromPtr(nodeClass.Spec.LifecycleScript.PostInitScript)
+		if providerSpec.Lifecycle.PostInit, err = p.renderLifecycleScript(ctx, nodeClass.Spec.LifecycleScript.PostInitScript,
+			nodeClass.Spec.LifecycleScript.PostInitScripts, data); err != nil {
+			return nil, nil, fmt.Errorf("rendering post-init script, %w", err)
 		}
 	}
+	renderInstanceStore(nodeClass, providerSpec, machine)
+	if err = multierr.Combine(
+		validateLifecycleScriptSize("pre-init", providerSpec.Lifecycle.PreInit),
+		validateLifecycleScriptSize("post-init", providerSpec.Lifecycle.PostInit),
+	); err != nil {
+		return nil, nil, err
+	}
 
 	rawProviderSpec, err := capiv1beta1.RawExtensionFromProviderSpec(providerSpec)
 	if err != nil {
@@ -360,7 +401,9 @@ func (p *DefaultProvider) Create(ctx context.Context, nodeClass *api.TKEMachineN
 	machine.Spec.ProviderSpec.Value = rawProviderSpec
 	machine.SetAnnotations(map[string]string{
 		api.AnnotationManagedBy:                            p.clusterID,
-		api.AnnotationUnitPrice:                            strconv.FormatFloat(instanceTypes[0].Offerings.Available().Compatible(schedulingRequirements).Cheapest().Price, 'f', 10, 64),
+		api.AnnotationTKEMachineNodeClassHash:              nodeClass.Hash(),
+		api.AnnotationTKEMachineNodeClassHashVersion:       api.TKEMachineNodeClassHashVersion,
+		api.AnnotationUnitPrice:                            strconv.FormatFloat(launchOffering.Price, 'f', 10, 64),
 		api.CapacityGroup + api.AnnotationCPU:              instanceTypes[0].Capacity.Cpu().String(),
 		api.CapacityGroup + api.AnnotationMemory:           instanceTypes[0].Capacity.Memory().String(),
 		api.CapacityGroup + api.AnnotationPods:             instanceTypes[0].Capacity.Pods().String(),
@@ -373,6 +416,16 @@ func (p *DefaultProvider) Create(ctx context.Context, nodeClass *api.TKEMachineN
 		api.EvictionThresholdGroup + api.AnnotationEphemeralStorage: instanceTypes[0].Overhead.EvictionThreshold.StorageEphemeral().String(),
 	})
 
+	if len(nodeClass.Spec.ImageSelectorTerms) != 0 {
+		image, ok := lo.Find(nodeClass.Status.Images, func(i api.Image) bool {
+			return instanceTypes[0].Requirements.Get(corev1.LabelArchStable).Has(i.Architecture)
+		})
+		if !ok {
+			return nil, nil, fmt.Errorf("image for instance type %s not found", instanceTypes[0].Name)
+		}
+		machine.Annotations[capiv1beta1.BetaImageAnnotation] = image.ID
+	}
+
 	if !instanceTypes[0].Overhead.KubeReserved.StorageEphemeral().IsZero() {
 		machine.Annotations[api.KubeReservedGroup+api.AnnotationMemory] = instanceTypes[0].Overhead.KubeReserved.StorageEphemeral().String()
 	}
@@ -404,15 +457,13 @@ func (p *DefaultProvider) Create(ctx context.Context, nodeClass *api.TKEMachineN
 	}
 	if c, ok := instanceTypes[0].Capacity[corev1.ResourceName(api.ResourceNVIDIAGPU)]; ok {
 		machine.Annotations[api.CapacityGroup+api.AnnotationGPUCount] = c.String()
+		if vals := instanceTypes[0].Requirements.Get(api.LabelInstanceGPUName).Values(); len(vals) > 0 {
+			machine.Annotations[api.CapacityGroup+api.AnnotationGPUType] = vals[0]
+		}
 	}
 
-	if len(nodeClass.Spec.Tags) != 0 {
-		tags := lo.MapToSlice(nodeClass.Spec.Tags, func(k string, value string) Tag { return Tag{TagKey: k, TagValue: value} })
-		tagsByte, err := json.Marshal(tags)
-		if err != nil {
-			return nil, nil, fmt.Errorf("marshalling tags failed, %w", err)
-		}
-		machine.Annotations[capiv1beta1.AnnotationMachineCloudTag] = string(tagsByte)
+	if machine.Annotations[capiv1beta1.AnnotationMachineCloudTag], err = MarshalCloudTags(CloudTags(p.clusterID, nodeClass, machine.GetLabels())); err != nil {
+		return nil, nil, err
 	}
 
 	machine.Spec.Annotations = p.getTargetAnnotations(api.AnnotationMachineSpecAnnotationsKey, nodeClaim.GetAnnotations())
@@ -421,9 +472,109 @@ func (p *DefaultProvider) Create(ctx context.Context, nodeClass *api.TKEMachineN
 		machine.Annotations[k] = v
 	}
 
-	err = p.kubeClient.Create(ctx, machine)
-	return machine, providerSpec, err
+	if err = p.kubeClient.Create(ctx, machine); err != nil {
+		return machine, providerSpec, err
+	}
+	created = true
+	p.inflightIPs.add(subnet)
+	return machine, providerSpec, nil
+}
+
+// renderManagement renders the management settings of the nodeClass into the provider spec. The deprecated
+// NodeClaim annotations are still honored and override the typed settings of the nodeClass. The kubelet
+// configuration takes precedence over both, as it must match the capacity and overhead of the instance type.
+func renderManagement(nodeClass *api.TKEMachineNodeClass, nodeClaim *v1.NodeClaim, instanceType *cloudprovider.InstanceType,
+	providerSpec *capiv1beta1.CXMMachineProviderSpec, machine *capiv1beta1.Machine) {
+	kubeletArgs := map[string]string{}
+	kernelArgs := map[string]string{}
+	hosts := map[string][]string{}
+	var nameservers []string
+	if m := nodeClass.Spec.Management; m != nil {
+		kubeletArgs = lo.Assign(m.KubeletArgs)
+		kernelArgs = lo.Assign(m.KernelArgs)
+		for _, h := range m.Hosts {
+			hosts[h.IP] = h.Hostnames
+		}
+		nameservers = lo.Map(m.Nameservers, func(n api.Nameserver, _ int) string { return string(n) })
+		providerSpec.HostName = lo.FromPtr(m.HostName)
+		machine.Spec.RuntimeRootDir = lo.FromPtr(m.RuntimeRootDir)
+	}
+
+	for k, v := range nodeClaim.Annotations {
+		if arg, ok := strings.CutPrefix(k, api.AnnotationKubeletArgPrefix); ok {
+			kubeletArgs[arg] = v
+		}
+		if arg, ok := strings.CutPrefix(k, api.AnnotationKernelArgPrefix); ok {
+			kernelArgs[arg] = v
+		}
+		if ip, ok := strings.CutPrefix(k, api.AnnotationHostsPrefix); ok {
+			if net.ParseIP(ip) == nil {
+				klog.Warningf("invalid IP address in host alias: %s", ip)
+				continue
+			}
+			hosts[ip] = strings.Split(strings.ReplaceAll(v, " ", ""), ",")
+		}
+		if k == api.AnnotationNameserversKey {
+			if result := lo.Compact(strings.Split(strings.ReplaceAll(v, " ", ""), ",")); len(result) > 0 {
+				nameservers = result
+			}
+		}
+		if k == api.AnnotationHostnameKey && len(v) > 0 {
+			providerSpec.HostName = v
+		}
+		if k == api.AnnotationRuntimeRootKey && len(v) > 0 {
+			machine.Spec.RuntimeRootDir = v
+		}
+	}
+
+	kubeletArgs = lo.Assign(kubeletArgs, kubeletConfigurationArgs(nodeClass.Spec.Kubelet, instanceType))
+
+	for _, k := range lo.Keys(kubeletArgs) {
+		providerSpec.Management.KubeletArgs = append(providerSpec.Management.KubeletArgs, fmt.Sprintf("%s=%s", k, kubeletArgs[k]))
+	}
+	for _, k := range lo.Keys(kernelArgs) {
+		providerSpec.Management.KernelArgs = append(providerSpec.Management.KernelArgs, fmt.Sprintf("%s=%s", k, kernelArgs[k]))
+	}
+	for ip, hostnames := range hosts {
+		providerSpec.Management.Hosts = append(providerSpec.Management.Hosts, core.HostAlias{IP: ip, Hostnames: hostnames})
+	}
+	// keep the rendered provider spec stable regardless of map iteration order
+	sort.Strings(providerSpec.Management.KubeletArgs)
+	sort.Strings(providerSpec.Management.KernelArgs)
+	sort.Slice(providerSpec.Management.Hosts, func(i, j int) bool {
+		return providerSpec.Management.Hosts[i].IP < providerSpec.Management.Hosts[j].IP
+	})
+	providerSpec.Management.Nameservers = lo.Uniq(nameservers)
+}
+
+// kubeletConfigurationArgs converts the kubelet configuration into kubelet arguments. max-pods is taken
+// from the capacity of the instance type since it is also limited by podsPerCore and the cluster network.
+func kubeletConfigurationArgs(kubelet *api.KubeletConfiguration, instanceType *cloudprovider.InstanceType) map[string]string {
+	args := map[string]string{}
+	if kubelet == nil {
+		return args
+	}
+	if lo.FromPtr(kubelet.MaxPods) > 0 || lo.FromPtr(kubelet.PodsPerCore) > 0 {
+		args["max-pods"] = instanceType.Capacity.Pods().String()
+	}
+	if len(kubelet.KubeReserved) != 0 {
+		args["kube-reserved"] = joinKubeletMap(kubelet.KubeReserved, "=")
+	}
+	if len(kubelet.SystemReserved) != 0 {
+		args["system-reserved"] = joinKubeletMap(kubelet.SystemReserved, "=")
+	}
+	if len(kubelet.EvictionHard) != 0 {
+		args["eviction-hard"] = joinKubeletMap(kubelet.EvictionHard, "<")
+	}
+	return args
 }
+
+func joinKubeletMap(m map[string]string, separator string) string {
+	keys := lo.Keys(m)
+	sort.Strings(keys)
+	return strings.Join(lo.Map(keys, func(k string, _ int) string { return k + separator + m[k] }), ",")
+}
+
 func (p *DefaultProvider) getTargetAnnotations(targetKey string, annotations map[string]string) map[string]string {
 	// annotation value of the form "key1=value1,key2=value2"
 	if val, found := annotations[targetKey]; found {
@@ -477,13 +628,29 @@ func (p *DefaultProvider) Delete(ctx context.Context, nodeClaim *v1.NodeClaim) e
 }
 
 func (p *DefaultProvider) filterInstanceTypes(nodeClaim *v1.NodeClaim, instanceTypes []*cloudprovider.InstanceType) []*cloudprovider.InstanceType {
-	instanceTypes = filterExoticInstanceTypes(instanceTypes)
+	instanceTypes = filterExoticInstanceTypes(nodeClaim, instanceTypes)
 	if p.isMixedCapacityLaunch(nodeClaim, instanceTypes) {
 		instanceTypes = filterUnwantedSpot(instanceTypes)
 	}
 	return instanceTypes
 }
 
+// renderDiskEncryption sets the encryption of the disk from the typed fields of the nodeClass.
+func renderDiskEncryption(disk *capiv1beta1.CXMDisk, encrypted *bool, kmsKeyID *string) {
+	if encrypted == nil {
+		return
+	}
+	if !lo.FromPtr(encrypted) {
+		disk.Encrypt = ""
+		disk.KmsKeyId = ""
+		return
+	}
+	disk.Encrypt = diskEncrypt
+	if kmsKeyID != nil {
+		disk.KmsKeyId = lo.FromPtr(kmsKeyID)
+	}
+}
+
 func (p *DefaultProvider) isMixedCapacityLaunch(nodeClaim *v1.NodeClaim, instanceTypes []*cloudprovider.InstanceType) bool {
 	requirements := scheduling.NewNodeSelectorRequirementsWithMinValues(nodeClaim.Spec.Requirements...)
 	if !requirements.Get(v1.CapacityTypeLabelKey).Has(v1.CapacityTypeSpot) ||
@@ -509,9 +676,28 @@ func (p *DefaultProvider) isMixedCapacityLaunch(nodeClaim *v1.NodeClaim, instanc
 	return hasSpotOfferings && hasODOffering
 }
 
-func filterExoticInstanceTypes(instanceTypes []*cloudprovider.InstanceType) []*cloudprovider.InstanceType {
+// filterInstanceTypesByImages removes the instance types whose architecture has no resolved image
+// when images are selected by the nodeClass.
+func filterInstanceTypesByImages(nodeClass *api.TKEMachineNodeClass, instanceTypes []*cloudprovider.InstanceType) []*cloudprovider.InstanceType {
+	if len(nodeClass.Spec.ImageSelectorTerms) == 0 {
+		return instanceTypes
+	}
+	return lo.Filter(instanceTypes, func(it *cloudprovider.InstanceType, _ int) bool {
+		return lo.ContainsBy(nodeClass.Status.Images, func(i api.Image) bool {
+			return it.Requirements.Get(corev1.LabelArchStable).Has(i.Architecture)
+		})
+	})
+}
+
+// filterExoticInstanceTypes removes the GPU, FPGA, bare metal and high memory instance types unless the nodeClaim
+// asks for them explicitly, they are only launched when no generic instance type fits the pods.
+func filterExoticInstanceTypes(nodeClaim *v1.NodeClaim, instanceTypes []*cloudprovider.InstanceType) []*cloudprovider.InstanceType {
+	requirements := scheduling.NewNodeSelectorRequirementsWithMinValues(nodeClaim.Spec.Requirements...)
 	var genericInstanceTypes []*cloudprovider.InstanceType
 	for _, it := range instanceTypes {
+		if isExotic(it) && !isRequestedExplicitly(requirements, it) {
+			continue
+		}
 		genericInstanceTypes = append(genericInstanceTypes, it)
 	}
 	if len(genericInstanceTypes) != 0 {
@@ -520,6 +706,26 @@ func filterExoticInstanceTypes(instanceTypes []*cloudprovider.InstanceType) []*c
 	return instanceTypes
 }
 
+func isExotic(instanceType *cloudprovider.InstanceType) bool {
+	if !instanceType.Requirements.Has(api.LabelInstanceCategory) {
+		return false
+	}
+	return instanceType.Requirements.Get(api.LabelInstanceCategory).Any() != api.InstanceCategoryGeneral
+}
+
+// isRequestedExplicitly returns whether the requirements list the instance type, its family or its category.
+func isRequestedExplicitly(requirements scheduling.Requirements, instanceType *cloudprovider.InstanceType) bool {
+	for _, key := range []string{corev1.LabelInstanceTypeStable, api.LabelInstanceFamily, api.LabelInstanceCategory} {
+		if !requirements.Has(key) || requirements.Get(key).Operator() != corev1.NodeSelectorOpIn {
+			continue
+		}
+		if instanceType.Requirements.Has(key) && requirements.Get(key).Has(instanceType.Requirements.Get(key).Any()) {
+			return true
+		}
+	}
+	return false
+}
+
 func filterUnwantedSpot(instanceTypes []*cloudprovider.InstanceType) []*cloudprovider.InstanceType {
 	cheapestOnDemand := math.MaxFloat64
 	for _, it := range instanceTypes {
@@ -557,3 +763,10 @@ func orderInstanceTypesByPrice(instanceTypes []*cloudprovider.InstanceType, requ
 	})
 	return instanceTypes
 }
+
+// registerTaints returns the unregistered taint, the taints and the startup taints of the NodeClaim without duplicates.
+func registerTaints(nodeClaim *v1.NodeClaim) []corev1.Taint {
+	taints := append([]corev1.Taint{v1.UnregisteredNoExecuteTaint}, nodeClaim.Spec.Taints...)
+	taints = append(taints, nodeClaim.Spec.StartupTaints...)
+	return lo.UniqBy(taints, func(t corev1.Taint) string { return t.Key + ":" + string(t.Effect) })
+}
@@ -20,10 +20,14 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"reflect"
+	"strings"
+	"sync"
 	"testing"
 
 	"github.com/samber/lo"
 	api "github.com/tencentcloud/karpenter-provider-tke/pkg/apis/v1beta1"
+	tkeoptions "github.com/tencentcloud/karpenter-provider-tke/pkg/operator/options"
 	capiv1beta1 "github.com/tencentcloud/karpenter-provider-tke/staging/nativenode/v1beta1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/meta"
@@ -32,6 +36,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/watch"
+	kubernetesfake "k8s.io/client-go/kubernetes/fake"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
 	"sigs.k8s.io/karpenter/pkg/cloudprovider"
@@ -422,7 +427,7 @@ func TestCreate_BasicSuccess(t *testing.T) {
 	mockZoneProvider := &mockZoneProvider{}
 	fakeClient := createFakeClient(scheme, nodeClass, nodeClaim)
 
-	provider := NewDefaultProvider(ctx, fakeClient, mockZoneProvider, "test-cluster")
+	provider := NewDefaultProvider(ctx, nil, fakeClient, mockZoneProvider, "test-cluster")
 
 	machine, providerSpec, err := provider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
 
@@ -498,7 +503,7 @@ func TestCreate_MultipleInstanceTypes(t *testing.T) {
 	mockZoneProvider := &mockZoneProvider{}
 	fakeClient := createFakeClient(scheme, nodeClass, nodeClaim)
 
-	provider := NewDefaultProvider(ctx, fakeClient, mockZoneProvider, "test-cluster")
+	provider := NewDefaultProvider(ctx, nil, fakeClient, mockZoneProvider, "test-cluster")
 
 	machine, providerSpec, err := provider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
 
@@ -532,7 +537,7 @@ func TestCreate_WithAnnotations(t *testing.T) {
 	mockZoneProvider := &mockZoneProvider{}
 	fakeClient := createFakeClient(scheme, nodeClass, nodeClaim)
 
-	provider := NewDefaultProvider(ctx, fakeClient, mockZoneProvider, "test-cluster")
+	provider := NewDefaultProvider(ctx, nil, fakeClient, mockZoneProvider, "test-cluster")
 
 	machine, providerSpec, err := provider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
 
@@ -606,7 +611,7 @@ func TestCreate_DifferentCapacityTypes(t *testing.T) {
 			mockZoneProvider := &mockZoneProvider{}
 			fakeClient := createFakeClient(scheme, nodeClass, nodeClaim)
 
-			provider := NewDefaultProvider(ctx, fakeClient, mockZoneProvider, "test-cluster")
+			provider := NewDefaultProvider(ctx, nil, fakeClient, mockZoneProvider, "test-cluster")
 
 			machine, providerSpec, err := provider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
 
@@ -629,6 +634,85 @@ func TestCreate_DifferentCapacityTypes(t *testing.T) {
 	}
 }
 
+func newPrepaidInstanceType(name string, price float64) *cloudprovider.InstanceType {
+	instanceType := createInstanceType(name, 4, 8, 0.5, "ap-guangzhou-1", v1.CapacityTypeOnDemand)
+	instanceType.Offerings = append(instanceType.Offerings, &cloudprovider.Offering{
+		Requirements: scheduling.NewRequirements(
+			scheduling.NewRequirement(corev1.LabelTopologyZone, corev1.NodeSelectorOpIn, "ap-guangzhou-1"),
+			scheduling.NewRequirement(v1.CapacityTypeLabelKey, corev1.NodeSelectorOpIn, api.CapacityTypePrepaid),
+		),
+		Price:     price,
+		Available: true,
+	})
+	return instanceType
+}
+
+func TestCreate_Prepaid(t *testing.T) {
+	scheme := createScheme()
+	ctx := context.Background()
+
+	nodeClass := createDefaultNodeClass()
+	nodeClass.Spec.Prepaid = &api.Prepaid{ChargeType: api.PrepaidChargeTypeUnderwrite, Period: 12}
+	nodeClaim := createDefaultNodeClaim()
+	nodeClaim.Spec.Requirements[1].Values = []string{api.CapacityTypePrepaid}
+
+	fakeClient := createFakeClient(scheme, nodeClass, nodeClaim)
+	provider := NewDefaultProvider(ctx, nil, fakeClient, &mockZoneProvider{}, "test-cluster")
+
+	machine, providerSpec, err := provider.Create(ctx, nodeClass, nodeClaim, []*cloudprovider.InstanceType{newPrepaidInstanceType("S3.MEDIUM4", 0.8)})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if machine.Spec.ProviderSpec.Type != capiv1beta1.MachineTypeNative {
+		t.Errorf("Expected MachineType %s, got %s", capiv1beta1.MachineTypeNative, machine.Spec.ProviderSpec.Type)
+	}
+	if providerSpec.InstanceChargeType != capiv1beta1.UnderwriteChargeType {
+		t.Errorf("Expected InstanceChargeType %s, got %s", capiv1beta1.UnderwriteChargeType, providerSpec.InstanceChargeType)
+	}
+	expected := &capiv1beta1.InstanceChargePrepaid{Period: 12, RenewFlag: capiv1beta1.NotifyAndManualRenew}
+	if !reflect.DeepEqual(providerSpec.InstanceChargePrepaid, expected) {
+		t.Errorf("Expected InstanceChargePrepaid %v, got %v", expected, providerSpec.InstanceChargePrepaid)
+	}
+	if machine.Labels[v1.CapacityTypeLabelKey] != api.CapacityTypePrepaid {
+		t.Errorf("Expected CapacityType label %s, got %s", api.CapacityTypePrepaid, machine.Labels[v1.CapacityTypeLabelKey])
+	}
+	// the expiration is read from the instance once it exists
+	if _, ok := machine.Annotations[api.AnnotationPrepaidExpiration]; ok {
+		t.Error("Expected no prepaid expiration before the instance exists")
+	}
+}
+
+func TestCreate_PrepaidByPrice(t *testing.T) {
+	scheme := createScheme()
+	ctx := context.Background()
+
+	nodeClass := createDefaultNodeClass()
+	nodeClass.Spec.Prepaid = &api.Prepaid{}
+	nodeClaim := createDefaultNodeClaim()
+	nodeClaim.Spec.Requirements[1].Values = []string{v1.CapacityTypeOnDemand, api.CapacityTypePrepaid}
+
+	fakeClient := createFakeClient(scheme, nodeClass, nodeClaim)
+	provider := NewDefaultProvider(ctx, nil, fakeClient, &mockZoneProvider{}, "test-cluster")
+
+	// the prepaid offering is launched when it is the cheapest allowed offering
+	machine, providerSpec, err := provider.Create(ctx, nodeClass, nodeClaim, []*cloudprovider.InstanceType{newPrepaidInstanceType("S3.MEDIUM4", 0.1)})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if machine.Labels[v1.CapacityTypeLabelKey] != api.CapacityTypePrepaid || providerSpec.InstanceChargePrepaid == nil {
+		t.Errorf("Expected a prepaid machine, got %s", machine.Labels[v1.CapacityTypeLabelKey])
+	}
+
+	// the on-demand offering is launched when it is cheaper
+	machine, providerSpec, err = provider.Create(ctx, nodeClass, nodeClaim, []*cloudprovider.InstanceType{newPrepaidInstanceType("S3.MEDIUM4", 0.8)})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if machine.Labels[v1.CapacityTypeLabelKey] != v1.CapacityTypeOnDemand || providerSpec.InstanceChargePrepaid != nil {
+		t.Errorf("Expected an on-demand machine, got %s", machine.Labels[v1.CapacityTypeLabelKey])
+	}
+}
+
 func TestCreate_FullConfiguration(t *testing.T) {
 	scheme := createScheme()
 	ctx := context.Background()
@@ -665,7 +749,7 @@ func TestCreate_FullConfiguration(t *testing.T) {
 	mockZoneProvider := &mockZoneProvider{}
 	fakeClient := createFakeClient(scheme, nodeClass, nodeClaim)
 
-	provider := NewDefaultProvider(ctx, fakeClient, mockZoneProvider, "test-cluster")
+	provider := NewDefaultProvider(ctx, nil, fakeClient, mockZoneProvider, "test-cluster")
 
 	machine, providerSpec, err := provider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
 
@@ -733,7 +817,7 @@ func TestCreate_ZoneFromIDFailure(t *testing.T) {
 	}
 	fakeClient := createFakeClient(scheme, nodeClass, nodeClaim)
 
-	provider := NewDefaultProvider(ctx, fakeClient, mockZoneProvider, "test-cluster")
+	provider := NewDefaultProvider(ctx, nil, fakeClient, mockZoneProvider, "test-cluster")
 
 	machine, providerSpec, err := provider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
 
@@ -775,7 +859,7 @@ func TestCreate_SubnetNotFound(t *testing.T) {
 	mockZoneProvider := &mockZoneProvider{}
 	fakeClient := createFakeClient(scheme, nodeClass, nodeClaim)
 
-	provider := NewDefaultProvider(ctx, fakeClient, mockZoneProvider, "test-cluster")
+	provider := NewDefaultProvider(ctx, nil, fakeClient, mockZoneProvider, "test-cluster")
 
 	machine, providerSpec, err := provider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
 
@@ -808,7 +892,7 @@ func TestCreate_EmptyInstanceTypes(t *testing.T) {
 	mockZoneProvider := &mockZoneProvider{}
 	fakeClient := createFakeClient(scheme, nodeClass, nodeClaim)
 
-	provider := NewDefaultProvider(ctx, fakeClient, mockZoneProvider, "test-cluster")
+	provider := NewDefaultProvider(ctx, nil, fakeClient, mockZoneProvider, "test-cluster")
 
 	machine, providerSpec, err := provider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
 
@@ -840,7 +924,7 @@ func TestCreate_KubeClientCreateFailure(t *testing.T) {
 	mockZoneProvider := &mockZoneProvider{}
 	fakeClient := createFakeClient(scheme)
 
-	provider := NewDefaultProvider(ctx, fakeClient, mockZoneProvider, "test-cluster")
+	provider := NewDefaultProvider(ctx, nil, fakeClient, mockZoneProvider, "test-cluster")
 
 	_, providerSpec, err := provider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
 
@@ -870,7 +954,7 @@ func TestCreate_InvalidNodeClassConfig(t *testing.T) {
 	mockZoneProvider := &mockZoneProvider{}
 	fakeClient := createFakeClient(scheme, nodeClass, nodeClaim)
 
-	provider := NewDefaultProvider(ctx, fakeClient, mockZoneProvider, "test-cluster")
+	provider := NewDefaultProvider(ctx, nil, fakeClient, mockZoneProvider, "test-cluster")
 
 	machine, providerSpec, err := provider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
 
@@ -910,7 +994,7 @@ func TestCreate_InstanceTypeTruncation(t *testing.T) {
 	mockZoneProvider := &mockZoneProvider{}
 	fakeClient := createFakeClient(scheme, nodeClass, nodeClaim)
 
-	provider := NewDefaultProvider(ctx, fakeClient, mockZoneProvider, "test-cluster")
+	provider := NewDefaultProvider(ctx, nil, fakeClient, mockZoneProvider, "test-cluster")
 
 	machine, providerSpec, err := provider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
 
@@ -948,7 +1032,7 @@ func TestCreate_MinimalConfiguration(t *testing.T) {
 	mockZoneProvider := &mockZoneProvider{}
 	fakeClient := createFakeClient(scheme, nodeClass, nodeClaim)
 
-	provider := NewDefaultProvider(ctx, fakeClient, mockZoneProvider, "test-cluster")
+	provider := NewDefaultProvider(ctx, nil, fakeClient, mockZoneProvider, "test-cluster")
 
 	_, providerSpec, err := provider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
 
@@ -995,7 +1079,7 @@ func TestCreate_NullAndEmptyValues(t *testing.T) {
 	mockZoneProvider := &mockZoneProvider{}
 	fakeClient := createFakeClient(scheme, nodeClass, nodeClaim)
 
-	provider := NewDefaultProvider(ctx, fakeClient, mockZoneProvider, "test-cluster")
+	provider := NewDefaultProvider(ctx, nil, fakeClient, mockZoneProvider, "test-cluster")
 
 	machine, providerSpec, err := provider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
 
@@ -1032,7 +1116,7 @@ func TestCreate_ResourceBoundaryValues(t *testing.T) {
 	mockZoneProvider := &mockZoneProvider{}
 	fakeClient := createFakeClient(scheme, nodeClass, nodeClaim)
 
-	provider := NewDefaultProvider(ctx, fakeClient, mockZoneProvider, "test-cluster")
+	provider := NewDefaultProvider(ctx, nil, fakeClient, mockZoneProvider, "test-cluster")
 
 	machine, providerSpec, err := provider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
 
@@ -1087,13 +1171,17 @@ func TestCreate_WithGPUResources(t *testing.T) {
 	// Create instance type with GPU
 	instanceType := createInstanceType("GN7.2XLARGE32", 8, 32, 2.5, "ap-guangzhou-1", v1.CapacityTypeOnDemand)
 	instanceType.Capacity[corev1.ResourceName(api.ResourceNVIDIAGPU)] = resource.MustParse("1")
+	instanceType.Requirements.Add(
+		scheduling.NewRequirement(api.LabelInstanceGPUCount, corev1.NodeSelectorOpIn, "1"),
+		scheduling.NewRequirement(api.LabelInstanceGPUName, corev1.NodeSelectorOpIn, "t4"),
+	)
 
 	instanceTypes := []*cloudprovider.InstanceType{instanceType}
 
 	mockZoneProvider := &mockZoneProvider{}
 	fakeClient := createFakeClient(scheme, nodeClass, nodeClaim)
 
-	provider := NewDefaultProvider(ctx, fakeClient, mockZoneProvider, "test-cluster")
+	provider := NewDefaultProvider(ctx, nil, fakeClient, mockZoneProvider, "test-cluster")
 
 	machine, providerSpec, err := provider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
 
@@ -1131,6 +1219,14 @@ func TestCreate_WithGPUResources(t *testing.T) {
 		t.Errorf("Expected GPU count annotation to be 1, got %s", machine.Annotations[api.CapacityGroup+api.AnnotationGPUCount])
 	}
 
+	// Verify GPU labels and type annotation
+	if machine.Labels[api.LabelInstanceGPUCount] != "1" || machine.Labels[api.LabelInstanceGPUName] != "t4" {
+		t.Errorf("Expected GPU labels 1/t4, got %s/%s", machine.Labels[api.LabelInstanceGPUCount], machine.Labels[api.LabelInstanceGPUName])
+	}
+	if machine.Annotations[api.CapacityGroup+api.AnnotationGPUType] != "t4" {
+		t.Errorf("Expected GPU type annotation to be t4, got %s", machine.Annotations[api.CapacityGroup+api.AnnotationGPUType])
+	}
+
 	if providerSpec == nil {
 		t.Fatal("Expected providerSpec to be created, got nil")
 	}
@@ -1157,7 +1253,7 @@ func TestCreate_WithGPUResourcesPartialConfig(t *testing.T) {
 	mockZoneProvider := &mockZoneProvider{}
 	fakeClient := createFakeClient(scheme, nodeClass, nodeClaim)
 
-	provider := NewDefaultProvider(ctx, fakeClient, mockZoneProvider, "test-cluster")
+	provider := NewDefaultProvider(ctx, nil, fakeClient, mockZoneProvider, "test-cluster")
 
 	machine, _, err := provider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
 
@@ -1211,7 +1307,7 @@ func TestCreate_WithoutGPUResources(t *testing.T) {
 	mockZoneProvider := &mockZoneProvider{}
 	fakeClient := createFakeClient(scheme, nodeClass, nodeClaim)
 
-	provider := NewDefaultProvider(ctx, fakeClient, mockZoneProvider, "test-cluster")
+	provider := NewDefaultProvider(ctx, nil, fakeClient, mockZoneProvider, "test-cluster")
 
 	machine, _, err := provider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
 
@@ -1254,7 +1350,7 @@ func TestCreate_WithGPUMIGEnableFalse(t *testing.T) {
 	mockZoneProvider := &mockZoneProvider{}
 	fakeClient := createFakeClient(scheme, nodeClass, nodeClaim)
 
-	provider := NewDefaultProvider(ctx, fakeClient, mockZoneProvider, "test-cluster")
+	provider := NewDefaultProvider(ctx, nil, fakeClient, mockZoneProvider, "test-cluster")
 
 	machine, _, err := provider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
 
@@ -1288,7 +1384,7 @@ func TestCreate_WithGPUFabricFalse(t *testing.T) {
 	mockZoneProvider := &mockZoneProvider{}
 	fakeClient := createFakeClient(scheme, nodeClass, nodeClaim)
 
-	provider := NewDefaultProvider(ctx, fakeClient, mockZoneProvider, "test-cluster")
+	provider := NewDefaultProvider(ctx, nil, fakeClient, mockZoneProvider, "test-cluster")
 
 	machine, _, err := provider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
 
@@ -1321,7 +1417,7 @@ func TestCreate_WithMultipleGPUs(t *testing.T) {
 	mockZoneProvider := &mockZoneProvider{}
 	fakeClient := createFakeClient(scheme, nodeClass, nodeClaim)
 
-	provider := NewDefaultProvider(ctx, fakeClient, mockZoneProvider, "test-cluster")
+	provider := NewDefaultProvider(ctx, nil, fakeClient, mockZoneProvider, "test-cluster")
 
 	machine, _, err := provider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
 
@@ -1361,7 +1457,7 @@ func TestCreate_WithGPUEmptyAnnotations(t *testing.T) {
 	mockZoneProvider := &mockZoneProvider{}
 	fakeClient := createFakeClient(scheme, nodeClass, nodeClaim)
 
-	provider := NewDefaultProvider(ctx, fakeClient, mockZoneProvider, "test-cluster")
+	provider := NewDefaultProvider(ctx, nil, fakeClient, mockZoneProvider, "test-cluster")
 
 	machine, _, err := provider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
 
@@ -1403,7 +1499,7 @@ func TestGet_Success(t *testing.T) {
 	mockZoneProvider := &mockZoneProvider{}
 	fakeClient := createFakeClient(scheme, nodeClass, nodeClaim)
 
-	provider := NewDefaultProvider(ctx, fakeClient, mockZoneProvider, "test-cluster")
+	provider := NewDefaultProvider(ctx, nil, fakeClient, mockZoneProvider, "test-cluster")
 
 	// Create a machine first
 	machine, _, err := provider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
@@ -1438,7 +1534,7 @@ func TestGet_NotFound(t *testing.T) {
 	mockZoneProvider := &mockZoneProvider{}
 	fakeClient := createFakeClient(scheme, nodeClass, nodeClaim)
 
-	provider := NewDefaultProvider(ctx, fakeClient, mockZoneProvider, "test-cluster")
+	provider := NewDefaultProvider(ctx, nil, fakeClient, mockZoneProvider, "test-cluster")
 
 	// Test Get with non-existent providerID
 	_, err := provider.Get(ctx, "non-existent-provider-id")
@@ -1491,7 +1587,7 @@ func TestList_Success(t *testing.T) {
 		}
 	}
 
-	provider := NewDefaultProvider(ctx, fakeClient, mockZoneProvider, "test-cluster")
+	provider := NewDefaultProvider(ctx, nil, fakeClient, mockZoneProvider, "test-cluster")
 
 	// Test List
 	machines, err := provider.List(ctx)
@@ -1526,7 +1622,7 @@ func TestList_Empty(t *testing.T) {
 	mockZoneProvider := &mockZoneProvider{}
 	fakeClient := createFakeClient(scheme, nodeClass, nodeClaim)
 
-	provider := NewDefaultProvider(ctx, fakeClient, mockZoneProvider, "test-cluster")
+	provider := NewDefaultProvider(ctx, nil, fakeClient, mockZoneProvider, "test-cluster")
 
 	// Test List without creating any machines
 	machines, err := provider.List(ctx)
@@ -1584,7 +1680,7 @@ func TestDelete_WithProviderID(t *testing.T) {
 		t.Fatalf("Failed to update nodeClaim: %v", err)
 	}
 
-	provider := NewDefaultProvider(ctx, fakeClient, mockZoneProvider, "test-cluster")
+	provider := NewDefaultProvider(ctx, nil, fakeClient, mockZoneProvider, "test-cluster")
 
 	// Test Delete
 	err = provider.Delete(ctx, nodeClaim)
@@ -1634,7 +1730,7 @@ func TestDelete_WithoutProviderID(t *testing.T) {
 		t.Fatalf("Failed to get nodeClaim: %v", err)
 	}
 
-	provider := NewDefaultProvider(ctx, fakeClient, mockZoneProvider, "test-cluster")
+	provider := NewDefaultProvider(ctx, nil, fakeClient, mockZoneProvider, "test-cluster")
 
 	// Test Delete without providerID (should search by owner reference)
 	nodeClaim.Status.ProviderID = ""
@@ -1667,7 +1763,7 @@ func TestDelete_NotFound(t *testing.T) {
 	mockZoneProvider := &mockZoneProvider{}
 	fakeClient := createFakeClient(scheme, nodeClass, nodeClaim)
 
-	provider := NewDefaultProvider(ctx, fakeClient, mockZoneProvider, "test-cluster")
+	provider := NewDefaultProvider(ctx, nil, fakeClient, mockZoneProvider, "test-cluster")
 
 	// Test Delete without creating any machines
 	nodeClaim.Status.ProviderID = ""
@@ -1686,7 +1782,7 @@ func TestGetTargetAnnotations_ValidFormat(t *testing.T) {
 	nodeClaim := createDefaultNodeClaim()
 	fakeClient := createFakeClient(scheme, nodeClass, nodeClaim)
 
-	provider := NewDefaultProvider(ctx, fakeClient, mockZoneProvider, "test-cluster")
+	provider := NewDefaultProvider(ctx, nil, fakeClient, mockZoneProvider, "test-cluster")
 
 	annotations := map[string]string{
 		"test-key": "key1=value1,key2=value2,key3=value3",
@@ -1719,7 +1815,7 @@ func TestGetTargetAnnotations_InvalidFormat(t *testing.T) {
 	nodeClaim := createDefaultNodeClaim()
 	fakeClient := createFakeClient(scheme, nodeClass, nodeClaim)
 
-	provider := NewDefaultProvider(ctx, fakeClient, mockZoneProvider, "test-cluster")
+	provider := NewDefaultProvider(ctx, nil, fakeClient, mockZoneProvider, "test-cluster")
 
 	annotations := map[string]string{
 		"test-key": "invalid,format,without,equals",
@@ -1744,7 +1840,7 @@ func TestGetTargetAnnotations_NotFound(t *testing.T) {
 	nodeClaim := createDefaultNodeClaim()
 	fakeClient := createFakeClient(scheme, nodeClass, nodeClaim)
 
-	provider := NewDefaultProvider(ctx, fakeClient, mockZoneProvider, "test-cluster")
+	provider := NewDefaultProvider(ctx, nil, fakeClient, mockZoneProvider, "test-cluster")
 
 	annotations := map[string]string{
 		"other-key": "key1=value1",
@@ -1765,7 +1861,7 @@ func TestGetTargetAnnotations_MixedFormat(t *testing.T) {
 	nodeClaim := createDefaultNodeClaim()
 	fakeClient := createFakeClient(scheme, nodeClass, nodeClaim)
 
-	provider := NewDefaultProvider(ctx, fakeClient, mockZoneProvider, "test-cluster")
+	provider := NewDefaultProvider(ctx, nil, fakeClient, mockZoneProvider, "test-cluster")
 
 	annotations := map[string]string{
 		"test-key": "key1=value1,invalid,key2=value2",
@@ -1799,7 +1895,7 @@ func TestIsMixedCapacityLaunch_True(t *testing.T) {
 	nodeClaim := createDefaultNodeClaim()
 	fakeClient := createFakeClient(scheme, nodeClass, nodeClaim)
 
-	provider := NewDefaultProvider(ctx, fakeClient, mockZoneProvider, "test-cluster")
+	provider := NewDefaultProvider(ctx, nil, fakeClient, mockZoneProvider, "test-cluster")
 
 	// Create nodeClaim with both spot and on-demand requirements
 	nodeClaim.Spec.Requirements = []v1.NodeSelectorRequirementWithMinValues{
@@ -1831,7 +1927,7 @@ func TestIsMixedCapacityLaunch_False_OnlySpot(t *testing.T) {
 	nodeClaim := createDefaultNodeClaim()
 	fakeClient := createFakeClient(scheme, nodeClass, nodeClaim)
 
-	provider := NewDefaultProvider(ctx, fakeClient, mockZoneProvider, "test-cluster")
+	provider := NewDefaultProvider(ctx, nil, fakeClient, mockZoneProvider, "test-cluster")
 
 	// Create nodeClaim with only spot requirement
 	nodeClaim.Spec.Requirements = []v1.NodeSelectorRequirementWithMinValues{
@@ -1860,7 +1956,7 @@ func TestIsMixedCapacityLaunch_False_OnlyOnDemand(t *testing.T) {
 	nodeClaim := createDefaultNodeClaim()
 	fakeClient := createFakeClient(scheme, nodeClass, nodeClaim)
 
-	provider := NewDefaultProvider(ctx, fakeClient, mockZoneProvider, "test-cluster")
+	provider := NewDefaultProvider(ctx, nil, fakeClient, mockZoneProvider, "test-cluster")
 
 	// Create nodeClaim with only on-demand requirement
 	nodeClaim.Spec.Requirements = []v1.NodeSelectorRequirementWithMinValues{
@@ -1956,7 +2052,7 @@ func TestCreate_WithMinValues(t *testing.T) {
 	mockZoneProvider := &mockZoneProvider{}
 	fakeClient := createFakeClient(scheme, nodeClass, nodeClaim)
 
-	provider := NewDefaultProvider(ctx, fakeClient, mockZoneProvider, "test-cluster")
+	provider := NewDefaultProvider(ctx, nil, fakeClient, mockZoneProvider, "test-cluster")
 
 	machine, _, err := provider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
 
@@ -1978,7 +2074,7 @@ func TestFilterInstanceTypes_MixedCapacity(t *testing.T) {
 	nodeClaim := createDefaultNodeClaim()
 	fakeClient := createFakeClient(scheme, nodeClass, nodeClaim)
 
-	provider := NewDefaultProvider(ctx, fakeClient, mockZoneProvider, "test-cluster")
+	provider := NewDefaultProvider(ctx, nil, fakeClient, mockZoneProvider, "test-cluster")
 
 	// Create nodeClaim with both spot and on-demand requirements
 	nodeClaim.Spec.Requirements = []v1.NodeSelectorRequirementWithMinValues{
@@ -2038,7 +2134,7 @@ func TestCreate_WithInternetAccessible(t *testing.T) {
 	mockZoneProvider := &mockZoneProvider{}
 	fakeClient := createFakeClient(scheme, nodeClass, nodeClaim)
 
-	provider := NewDefaultProvider(ctx, fakeClient, mockZoneProvider, "test-cluster")
+	provider := NewDefaultProvider(ctx, nil, fakeClient, mockZoneProvider, "test-cluster")
 
 	machine, providerSpec, err := provider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
 
@@ -2088,7 +2184,7 @@ func TestCreate_WithAnnotations_KubeletArgs(t *testing.T) {
 	mockZoneProvider := &mockZoneProvider{}
 	fakeClient := createFakeClient(scheme, nodeClass, nodeClaim)
 
-	provider := NewDefaultProvider(ctx, fakeClient, mockZoneProvider, "test-cluster")
+	provider := NewDefaultProvider(ctx, nil, fakeClient, mockZoneProvider, "test-cluster")
 
 	_, providerSpec, err := provider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
 
@@ -2132,7 +2228,7 @@ func TestCreate_WithAnnotations_KernelArgs(t *testing.T) {
 	mockZoneProvider := &mockZoneProvider{}
 	fakeClient := createFakeClient(scheme, nodeClass, nodeClaim)
 
-	provider := NewDefaultProvider(ctx, fakeClient, mockZoneProvider, "test-cluster")
+	provider := NewDefaultProvider(ctx, nil, fakeClient, mockZoneProvider, "test-cluster")
 
 	_, providerSpec, err := provider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
 
@@ -2165,7 +2261,7 @@ func TestCreate_WithAnnotations_Hosts(t *testing.T) {
 	mockZoneProvider := &mockZoneProvider{}
 	fakeClient := createFakeClient(scheme, nodeClass, nodeClaim)
 
-	provider := NewDefaultProvider(ctx, fakeClient, mockZoneProvider, "test-cluster")
+	provider := NewDefaultProvider(ctx, nil, fakeClient, mockZoneProvider, "test-cluster")
 
 	_, providerSpec, err := provider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
 
@@ -2202,7 +2298,7 @@ func TestCreate_WithAnnotations_InvalidHosts(t *testing.T) {
 	mockZoneProvider := &mockZoneProvider{}
 	fakeClient := createFakeClient(scheme, nodeClass, nodeClaim)
 
-	provider := NewDefaultProvider(ctx, fakeClient, mockZoneProvider, "test-cluster")
+	provider := NewDefaultProvider(ctx, nil, fakeClient, mockZoneProvider, "test-cluster")
 
 	_, providerSpec, err := provider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
 
@@ -2236,7 +2332,7 @@ func TestCreate_WithAnnotations_Nameservers(t *testing.T) {
 	mockZoneProvider := &mockZoneProvider{}
 	fakeClient := createFakeClient(scheme, nodeClass, nodeClaim)
 
-	provider := NewDefaultProvider(ctx, fakeClient, mockZoneProvider, "test-cluster")
+	provider := NewDefaultProvider(ctx, nil, fakeClient, mockZoneProvider, "test-cluster")
 
 	_, providerSpec, err := provider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
 
@@ -2273,7 +2369,7 @@ func TestCreate_WithAnnotations_Hostname(t *testing.T) {
 	mockZoneProvider := &mockZoneProvider{}
 	fakeClient := createFakeClient(scheme, nodeClass, nodeClaim)
 
-	provider := NewDefaultProvider(ctx, fakeClient, mockZoneProvider, "test-cluster")
+	provider := NewDefaultProvider(ctx, nil, fakeClient, mockZoneProvider, "test-cluster")
 
 	_, providerSpec, err := provider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
 
@@ -2306,7 +2402,7 @@ func TestCreate_WithAnnotations_RuntimeRoot(t *testing.T) {
 	mockZoneProvider := &mockZoneProvider{}
 	fakeClient := createFakeClient(scheme, nodeClass, nodeClaim)
 
-	provider := NewDefaultProvider(ctx, fakeClient, mockZoneProvider, "test-cluster")
+	provider := NewDefaultProvider(ctx, nil, fakeClient, mockZoneProvider, "test-cluster")
 
 	machine, _, err := provider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
 
@@ -2319,6 +2415,202 @@ func TestCreate_WithAnnotations_RuntimeRoot(t *testing.T) {
 	}
 }
 
+// Test Create with typed management settings on the nodeClass
+func TestCreate_WithManagement(t *testing.T) {
+	scheme := createScheme()
+	ctx := context.Background()
+
+	nodeClass := createDefaultNodeClass()
+	nodeClass.Spec.Management = &api.ManagementConfig{
+		KubeletArgs: map[string]string{"max-pods": "100", "image-gc-high-threshold": "80"},
+		KernelArgs:  map[string]string{"net.ipv4.ip_forward": "1"},
+		Hosts: []api.HostAlias{
+			{IP: "192.168.1.2", Hostnames: []string{"b.example.com"}},
+			{IP: "192.168.1.1", Hostnames: []string{"a.example.com", "c.example.com"}},
+		},
+		Nameservers:    []api.Nameserver{"8.8.8.8", "8.8.4.4"},
+		HostName:       lo.ToPtr("node-hostname"),
+		RuntimeRootDir: lo.ToPtr("/data/containerd"),
+	}
+	nodeClaim := createDefaultNodeClaim()
+
+	instanceTypes := []*cloudprovider.InstanceType{
+		createInstanceType("S3.MEDIUM4", 4, 8, 0.5, "ap-guangzhou-1", v1.CapacityTypeOnDemand),
+	}
+
+	fakeClient := createFakeClient(scheme, nodeClass, nodeClaim)
+	provider := NewDefaultProvider(ctx, nil, fakeClient, &mockZoneProvider{}, "test-cluster")
+
+	machine, providerSpec, err := provider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	expectedKubeletArgs := []string{
+		"image-gc-high-threshold=80",
+		"max-pods=100",
+		fmt.Sprintf("register-with-taints=%s", v1.UnregisteredNoExecuteTaint.ToString()),
+	}
+	if !reflect.DeepEqual(providerSpec.Management.KubeletArgs, expectedKubeletArgs) {
+		t.Errorf("Expected KubeletArgs %v, got %v", expectedKubeletArgs, providerSpec.Management.KubeletArgs)
+	}
+	if !reflect.DeepEqual(providerSpec.Management.KernelArgs, []string{"net.ipv4.ip_forward=1"}) {
+		t.Errorf("Expected KernelArgs [net.ipv4.ip_forward=1], got %v", providerSpec.Management.KernelArgs)
+	}
+	if len(providerSpec.Management.Hosts) != 2 || providerSpec.Management.Hosts[0].IP != "192.168.1.1" ||
+		len(providerSpec.Management.Hosts[0].Hostnames) != 2 {
+		t.Errorf("Expected hosts ordered by IP, got %v", providerSpec.Management.Hosts)
+	}
+	if !reflect.DeepEqual(providerSpec.Management.Nameservers, []string{"8.8.8.8", "8.8.4.4"}) {
+		t.Errorf("Expected Nameservers [8.8.8.8 8.8.4.4], got %v", providerSpec.Management.Nameservers)
+	}
+	if providerSpec.HostName != "node-hostname" {
+		t.Errorf("Expected hostname node-hostname, got %s", providerSpec.HostName)
+	}
+	if machine.Spec.RuntimeRootDir != "/data/containerd" {
+		t.Errorf("Expected RuntimeRootDir /data/containerd, got %s", machine.Spec.RuntimeRootDir)
+	}
+}
+
+// Test the deprecated annotations override the typed management settings
+func TestCreate_WithManagement_AnnotationsOverride(t *testing.T) {
+	scheme := createScheme()
+	ctx := context.Background()
+
+	nodeClass := createDefaultNodeClass()
+	nodeClass.Spec.Management = &api.ManagementConfig{
+		KubeletArgs:    map[string]string{"max-pods": "100"},
+		Hosts:          []api.HostAlias{{IP: "192.168.1.1", Hostnames: []string{"a.example.com"}}},
+		Nameservers:    []api.Nameserver{"8.8.8.8"},
+		RuntimeRootDir: lo.ToPtr("/data/containerd"),
+	}
+	nodeClaim := createDefaultNodeClaim()
+	nodeClaim.Annotations = map[string]string{
+		api.AnnotationKubeletArgPrefix + "max-pods": "200",
+		api.AnnotationHostsPrefix + "192.168.1.1":  "b.example.com",
+		api.AnnotationNameserversKey:               "1.1.1.1",
+		api.AnnotationRuntimeRootKey:               "/var/lib/containerd",
+	}
+
+	instanceTypes := []*cloudprovider.InstanceType{
+		createInstanceType("S3.MEDIUM4", 4, 8, 0.5, "ap-guangzhou-1", v1.CapacityTypeOnDemand),
+	}
+
+	fakeClient := createFakeClient(scheme, nodeClass, nodeClaim)
+	provider := NewDefaultProvider(ctx, nil, fakeClient, &mockZoneProvider{}, "test-cluster")
+
+	machine, providerSpec, err := provider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if !lo.Contains(providerSpec.Management.KubeletArgs, "max-pods=200") || lo.Contains(providerSpec.Management.KubeletArgs, "max-pods=100") {
+		t.Errorf("Expected annotation to override max-pods, got %v", providerSpec.Management.KubeletArgs)
+	}
+	if len(providerSpec.Management.Hosts) != 1 || !reflect.DeepEqual(providerSpec.Management.Hosts[0].Hostnames, []string{"b.example.com"}) {
+		t.Errorf("Expected annotation to override hosts, got %v", providerSpec.Management.Hosts)
+	}
+	if !reflect.DeepEqual(providerSpec.Management.Nameservers, []string{"1.1.1.1"}) {
+		t.Errorf("Expected annotation to override nameservers, got %v", providerSpec.Management.Nameservers)
+	}
+	if machine.Spec.RuntimeRootDir != "/var/lib/containerd" {
+		t.Errorf("Expected RuntimeRootDir /var/lib/containerd, got %s", machine.Spec.RuntimeRootDir)
+	}
+}
+
+// Test Create renders the kubelet configuration into kubelet args
+func TestCreate_WithKubeletConfiguration(t *testing.T) {
+	scheme := createScheme()
+	ctx := context.Background()
+
+	nodeClass := createDefaultNodeClass()
+	nodeClass.Spec.Kubelet = &api.KubeletConfiguration{
+		MaxPods:        lo.ToPtr(int32(110)),
+		KubeReserved:   map[string]string{"memory": "1Gi", "cpu": "200m"},
+		SystemReserved: map[string]string{"cpu": "100m"},
+		EvictionHard:   map[string]string{"memory.available": "5%", "nodefs.available": "10%"},
+	}
+	nodeClass.Spec.Management = &api.ManagementConfig{
+		KubeletArgs: map[string]string{"max-pods": "50"},
+	}
+	nodeClaim := createDefaultNodeClaim()
+	nodeClaim.Annotations = map[string]string{
+		api.AnnotationKubeletArgPrefix + "kube-reserved": "cpu=1",
+	}
+
+	instanceTypes := []*cloudprovider.InstanceType{
+		createInstanceType("S3.MEDIUM4", 4, 8, 0.5, "ap-guangzhou-1", v1.CapacityTypeOnDemand),
+	}
+
+	fakeClient := createFakeClient(scheme, nodeClass, nodeClaim)
+	provider := NewDefaultProvider(ctx, nil, fakeClient, &mockZoneProvider{}, "test-cluster")
+
+	_, providerSpec, err := provider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	expectedKubeletArgs := []string{
+		"eviction-hard=memory.available<5%,nodefs.available<10%",
+		"kube-reserved=cpu=200m,memory=1Gi",
+		"max-pods=110",
+		"system-reserved=cpu=100m",
+		fmt.Sprintf("register-with-taints=%s", v1.UnregisteredNoExecuteTaint.ToString()),
+	}
+	if !reflect.DeepEqual(providerSpec.Management.KubeletArgs, expectedKubeletArgs) {
+		t.Errorf("Expected KubeletArgs %v, got %v", expectedKubeletArgs, providerSpec.Management.KubeletArgs)
+	}
+}
+
+// Test Create launches the resolved image of the instance type architecture
+func TestCreate_WithImages(t *testing.T) {
+	scheme := createScheme()
+	ctx := context.Background()
+
+	nodeClass := createDefaultNodeClass()
+	nodeClass.Spec.ImageSelectorTerms = []api.ImageSelectorTerm{{Tags: map[string]string{"env": "prod"}}}
+	nodeClass.Status.Images = []api.Image{
+		{ID: "img-amd64", Architecture: v1.ArchitectureAmd64},
+		{ID: "img-arm64", Architecture: v1.ArchitectureArm64},
+	}
+	nodeClaim := createDefaultNodeClaim()
+
+	armType := createInstanceType("SR1.MEDIUM4", 4, 8, 0.4, "ap-guangzhou-1", v1.CapacityTypeOnDemand)
+	armType.Requirements.Add(scheduling.NewRequirement(corev1.LabelArchStable, corev1.NodeSelectorOpIn, v1.ArchitectureArm64))
+	amdType := createInstanceType("S3.MEDIUM4", 4, 8, 0.5, "ap-guangzhou-1", v1.CapacityTypeOnDemand)
+	amdType.Requirements.Add(scheduling.NewRequirement(corev1.LabelArchStable, corev1.NodeSelectorOpIn, v1.ArchitectureAmd64))
+
+	fakeClient := createFakeClient(scheme, nodeClass, nodeClaim)
+	provider := NewDefaultProvider(ctx, nil, fakeClient, &mockZoneProvider{}, "test-cluster")
+
+	machine, _, err := provider.Create(ctx, nodeClass, nodeClaim, []*cloudprovider.InstanceType{armType, amdType})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if machine.Annotations[capiv1beta1.BetaImageAnnotation] != "img-arm64" {
+		t.Errorf("Expected image img-arm64, got %s", machine.Annotations[capiv1beta1.BetaImageAnnotation])
+	}
+
+	// the cheaper arm64 instance type can't be launched without an arm64 image
+	nodeClass.Status.Images = nodeClass.Status.Images[:1]
+	machine, providerSpec, err := provider.Create(ctx, nodeClass, nodeClaim, []*cloudprovider.InstanceType{armType, amdType})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if providerSpec.InstanceType != "S3.MEDIUM4" {
+		t.Errorf("Expected instance type S3.MEDIUM4, got %s", providerSpec.InstanceType)
+	}
+	if machine.Annotations[capiv1beta1.BetaImageAnnotation] != "img-amd64" {
+		t.Errorf("Expected image img-amd64, got %s", machine.Annotations[capiv1beta1.BetaImageAnnotation])
+	}
+
+	// no instance type can be launched before the images are resolved
+	nodeClass.Status.Images = nil
+	if _, _, err := provider.Create(ctx, nodeClass, nodeClaim, []*cloudprovider.InstanceType{armType, amdType}); err == nil {
+		t.Error("Expected error when images are not resolved")
+	}
+}
+
 // Test Create with data disk annotations
 func TestCreate_WithDataDiskAnnotations(t *testing.T) {
 	scheme := createScheme()
@@ -2352,7 +2644,7 @@ func TestCreate_WithDataDiskAnnotations(t *testing.T) {
 	mockZoneProvider := &mockZoneProvider{}
 	fakeClient := createFakeClient(scheme, nodeClass, nodeClaim)
 
-	provider := NewDefaultProvider(ctx, fakeClient, mockZoneProvider, "test-cluster")
+	provider := NewDefaultProvider(ctx, nil, fakeClient, mockZoneProvider, "test-cluster")
 
 	_, providerSpec, err := provider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
 
@@ -2398,125 +2690,661 @@ func TestCreate_WithDataDiskAnnotations(t *testing.T) {
 	}
 }
 
-// Helper function to create ARM architecture instance type
-func createARMInstanceType(name string, cpu int64, memory int64, price float64, zone string, capacityType string) *cloudprovider.InstanceType {
-	requirements := scheduling.NewRequirements(
-		scheduling.NewRequirement(corev1.LabelTopologyZone, corev1.NodeSelectorOpIn, zone),
-		scheduling.NewRequirement(v1.CapacityTypeLabelKey, corev1.NodeSelectorOpIn, capacityType),
-		scheduling.NewRequirement(corev1.LabelArchStable, corev1.NodeSelectorOpIn, "arm64"),
-		scheduling.NewRequirement(api.LabelInstanceFamily, corev1.NodeSelectorOpIn, "SR1"),
-		scheduling.NewRequirement(api.LabelInstanceCPU, corev1.NodeSelectorOpIn, fmt.Sprintf("%d", cpu)),
-		scheduling.NewRequirement(api.LabelInstanceMemoryGB, corev1.NodeSelectorOpIn, fmt.Sprintf("%d", memory)),
-	)
+func TestCreate_WithTypedDiskSettings(t *testing.T) {
+	scheme := createScheme()
+	ctx := context.Background()
 
-	capacity := corev1.ResourceList{
-		corev1.ResourceCPU:              resource.MustParse(fmt.Sprintf("%d", cpu)),
-		corev1.ResourceMemory:           resource.MustParse(fmt.Sprintf("%dGi", memory)),
-		corev1.ResourcePods:             resource.MustParse("110"),
-		corev1.ResourceEphemeralStorage: resource.MustParse("100Gi"),
+	nodeClass := createDefaultNodeClass()
+	nodeClass.Spec.SystemDisk = &api.SystemDisk{
+		Size:      60,
+		Type:      api.DiskTypeCloudSSD,
+		Encrypted: lo.ToPtr(true),
+		KMSKeyID:  lo.ToPtr("kms-system"),
 	}
-
-	overhead := &cloudprovider.InstanceTypeOverhead{
-		KubeReserved: corev1.ResourceList{
-			corev1.ResourceCPU:    resource.MustParse("100m"),
-			corev1.ResourceMemory: resource.MustParse("100Mi"),
-		},
-		SystemReserved: corev1.ResourceList{
-			corev1.ResourceCPU:    resource.MustParse("100m"),
-			corev1.ResourceMemory: resource.MustParse("100Mi"),
+	nodeClass.Spec.DataDisks = []api.DataDisk{
+		{
+			Type:                  api.DiskTypeCloudHSSD,
+			Size:                  100,
+			MountTarget:           lo.ToPtr("/data"),
+			Encrypted:             lo.ToPtr(true),
+			KMSKeyID:              lo.ToPtr("kms-456"),
+			SnapshotID:            lo.ToPtr("snap-456"),
+			ThroughputPerformance: lo.ToPtr(int32(200)),
+			ImageCacheID:          lo.ToPtr("imc-456"),
 		},
-		EvictionThreshold: corev1.ResourceList{
-			corev1.ResourceMemory:           resource.MustParse("100Mi"),
-			corev1.ResourceEphemeralStorage: resource.MustParse("10Gi"),
+		{
+			Type:      api.DiskTypeCloudPremium,
+			Size:      100,
+			Encrypted: lo.ToPtr(false),
 		},
 	}
+	// the typed fields take precedence over the deprecated annotations
+	nodeClass.Annotations = map[string]string{
+		api.AnnotationSystemDiskKMSID:        "kms-123",
+		api.AnnotationDataDisksThroughputKey: "0=100",
+		api.AnnotationDataDisksSnapshotID:    "0=snap-123",
+		api.AnnotationDataDisksEncryptKey:    "1=ENCRYPT",
+	}
+	nodeClaim := createDefaultNodeClaim()
 
-	offering := &cloudprovider.Offering{
-		Requirements: requirements,
-		Price:        price,
-		Available:    true,
+	fakeClient := createFakeClient(scheme, nodeClass, nodeClaim)
+	provider := NewDefaultProvider(ctx, nil, fakeClient, &mockZoneProvider{}, "test-cluster")
+
+	_, providerSpec, err := provider.Create(ctx, nodeClass, nodeClaim, []*cloudprovider.InstanceType{
+		createInstanceType("S3.MEDIUM4", 4, 8, 0.5, "ap-guangzhou-1", v1.CapacityTypeOnDemand),
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
 	}
 
-	return &cloudprovider.InstanceType{
-		Name:         name,
-		Requirements: requirements,
-		Capacity:     capacity,
-		Overhead:     overhead,
-		Offerings:    []*cloudprovider.Offering{offering},
+	if providerSpec.SystemDisk.Encrypt != "ENCRYPT" || providerSpec.SystemDisk.KmsKeyId != "kms-system" {
+		t.Errorf("Expected encrypted system disk with kms-system, got %s %s", providerSpec.SystemDisk.Encrypt, providerSpec.SystemDisk.KmsKeyId)
+	}
+	if len(providerSpec.DataDisks) != 2 {
+		t.Fatalf("Expected 2 data disks, got %d", len(providerSpec.DataDisks))
+	}
+	disk := providerSpec.DataDisks[0]
+	if disk.Encrypt != "ENCRYPT" || disk.KmsKeyId != "kms-456" {
+		t.Errorf("Expected encrypted data disk with kms-456, got %s %s", disk.Encrypt, disk.KmsKeyId)
+	}
+	if disk.SnapshotId != "snap-456" {
+		t.Errorf("Expected SnapshotId snap-456, got %s", disk.SnapshotId)
+	}
+	if disk.ThroughputPerformance != 200 {
+		t.Errorf("Expected ThroughputPerformance 200, got %d", disk.ThroughputPerformance)
+	}
+	if disk.ImageCacheId != "imc-456" {
+		t.Errorf("Expected ImageCacheId imc-456, got %s", disk.ImageCacheId)
+	}
+	if providerSpec.DataDisks[1].Encrypt != "" {
+		t.Errorf("Expected unencrypted data disk, got %s", providerSpec.DataDisks[1].Encrypt)
 	}
 }
 
-// Test Create with ARM architecture instance type
-func TestCreate_ARMArchitecture(t *testing.T) {
+func TestCreate_WithInstanceStorePolicy(t *testing.T) {
 	scheme := createScheme()
 	ctx := context.Background()
 
 	nodeClass := createDefaultNodeClass()
+	nodeClass.Spec.InstanceStorePolicy = lo.ToPtr(api.InstanceStorePolicyRAID0)
+	nodeClass.Spec.Management = &api.ManagementConfig{RuntimeRootDir: lo.ToPtr("/data/containerd")}
+	nodeClass.Spec.LifecycleScript = &api.LifecycleScript{PreInitScript: lo.ToPtr("#!/bin/sh\necho pre-init")}
 	nodeClaim := createDefaultNodeClaim()
 
-	// Add ARM architecture requirement
-	nodeClaim.Spec.Requirements = []v1.NodeSelectorRequirementWithMinValues{
-		{
-			
-				Key:      corev1.LabelArchStable,
-				Operator: corev1.NodeSelectorOpIn,
-				Values:   []string{"arm64"},},
-	}
-
-	instanceTypes := []*cloudprovider.InstanceType{
-		createARMInstanceType("SR1.MEDIUM4", 4, 8, 0.4, "ap-guangzhou-1", v1.CapacityTypeOnDemand),
-	}
-
-	mockZoneProvider := &mockZoneProvider{}
 	fakeClient := createFakeClient(scheme, nodeClass, nodeClaim)
+	provider := NewDefaultProvider(ctx, nil, fakeClient, &mockZoneProvider{}, "test-cluster")
 
-	provider := NewDefaultProvider(ctx, fakeClient, mockZoneProvider, "test-cluster")
-
-	machine, providerSpec, err := provider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
-
+	_, providerSpec, err := provider.Create(ctx, nodeClass, nodeClaim, []*cloudprovider.InstanceType{
+		createInstanceType("IT5.8XLARGE128", 32, 128, 0.5, "ap-guangzhou-1", v1.CapacityTypeOnDemand),
+	})
 	if err != nil {
 		t.Fatalf("Expected no error, got %v", err)
 	}
-
-	if machine == nil {
-		t.Fatal("Expected machine to be created, got nil")
+	preInit := providerSpec.Lifecycle.PreInit
+	if !strings.HasPrefix(preInit, "#!/bin/sh\n") {
+		t.Errorf("Expected the interpreter line of the user script first, got %s", preInit)
 	}
-
-	if providerSpec.InstanceType != "SR1.MEDIUM4" {
-		t.Errorf("Expected instance type SR1.MEDIUM4, got %s", providerSpec.InstanceType)
+	if !strings.Contains(preInit, "\nbash <<'KARPENTER_INSTANCE_STORE'\n") {
+		t.Errorf("Expected the instance store script to run by bash whatever the interpreter is, got %s", preInit)
 	}
-
-	if machine.Labels[corev1.LabelArchStable] != "arm64" {
-		t.Errorf("Expected architecture label arm64, got %s", machine.Labels[corev1.LabelArchStable])
+	if !strings.HasSuffix(preInit, "setup_instance_store\nKARPENTER_INSTANCE_STORE\necho pre-init") {
+		t.Errorf("Expected the user script after the instance store script, got %s", preInit)
 	}
-
-	if machine.Labels[api.LabelInstanceFamily] != "SR1" {
-		t.Errorf("Expected instance family SR1, got %s", machine.Labels[api.LabelInstanceFamily])
+	if !strings.Contains(preInit, "for dir in /data/containerd /var/lib/kubelet; do") {
+		t.Errorf("Expected runtime and kubelet root directories to be bind mounted, got %s", preInit)
 	}
 
-	if machine.Labels[api.LabelInstanceCPU] != "4" {
-		t.Errorf("Expected CPU label 4, got %s", machine.Labels[api.LabelInstanceCPU])
+	// the local disks are left untouched without the policy
+	nodeClass.Spec.InstanceStorePolicy = nil
+	_, providerSpec, err = provider.Create(ctx, nodeClass, nodeClaim, []*cloudprovider.InstanceType{
+		createInstanceType("IT5.8XLARGE128", 32, 128, 0.5, "ap-guangzhou-1", v1.CapacityTypeOnDemand),
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
 	}
-
-	if machine.Labels[api.LabelInstanceMemoryGB] != "8" {
-		t.Errorf("Expected Memory label 8, got %s", machine.Labels[api.LabelInstanceMemoryGB])
+	if providerSpec.Lifecycle.PreInit != "#!/bin/sh\necho pre-init" {
+		t.Errorf("Expected the user script only, got %s", providerSpec.Lifecycle.PreInit)
 	}
 }
 
-// Test Create with multiple ARM instance types
-func TestCreate_MultipleARMInstanceTypes(t *testing.T) {
+func TestCreate_WithHPCCluster(t *testing.T) {
 	scheme := createScheme()
 	ctx := context.Background()
 
 	nodeClass := createDefaultNodeClass()
+	nodeClass.Spec.HPCClusterSelectorTerms = []api.HPCClusterSelectorTerm{{Tags: map[string]string{"env": "prod"}}}
 	nodeClaim := createDefaultNodeClaim()
 
-	// Add ARM architecture requirement
-	nodeClaim.Spec.Requirements = []v1.NodeSelectorRequirementWithMinValues{
-		{
-			
-				Key:      corev1.LabelArchStable,
-				Operator: corev1.NodeSelectorOpIn,
+	fakeClient := createFakeClient(scheme, nodeClass, nodeClaim)
+	provider := NewDefaultProvider(ctx, nil, fakeClient, &mockZoneProvider{}, "test-cluster")
+	instanceTypes := []*cloudprovider.InstanceType{
+		createInstanceType("HCCPNV4h.48XLARGE1024", 192, 1024, 0.5, "ap-guangzhou-1", v1.CapacityTypeOnDemand),
+	}
+
+	// no hpc cluster in the zone of the instance type
+	nodeClass.Status.HPCClusters = []api.HPCCluster{{ID: "hpc-other", Zone: "ap-guangzhou-6"}}
+	if _, _, err := provider.Create(ctx, nodeClass, nodeClaim, instanceTypes); err == nil {
+		t.Error("Expected error when no hpc cluster is in the zone")
+	}
+
+	nodeClass.Status.HPCClusters = append(nodeClass.Status.HPCClusters, api.HPCCluster{ID: "hpc-123", Zone: "ap-guangzhou-1"})
+	machine, providerSpec, err := provider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if providerSpec.HpcClusterId != "hpc-123" {
+		t.Errorf("Expected HpcClusterId hpc-123, got %s", providerSpec.HpcClusterId)
+	}
+	if machine.Labels[api.LabelRDMA] != "true" {
+		t.Errorf("Expected rdma label true, got %s", machine.Labels[api.LabelRDMA])
+	}
+}
+
+func TestCreate_WithAddressType(t *testing.T) {
+	scheme := createScheme()
+	ctx := context.Background()
+
+	nodeClass := createDefaultNodeClass()
+	nodeClass.Spec.InternetAccessible = &api.InternetAccessible{AddressType: lo.ToPtr(api.AddressTypeHighQualityEIP)}
+	nodeClaim := createDefaultNodeClaim()
+	nodeClaim.Spec.Requirements = []v1.NodeSelectorRequirementWithMinValues{
+		{Key: v1.CapacityTypeLabelKey, Operator: corev1.NodeSelectorOpIn, Values: []string{v1.CapacityTypeSpot}},
+	}
+
+	fakeClient := createFakeClient(scheme, nodeClass, nodeClaim)
+	provider := NewDefaultProvider(ctx, nil, fakeClient, &mockZoneProvider{}, "test-cluster")
+	instanceTypes := []*cloudprovider.InstanceType{
+		createInstanceType("S5.MEDIUM4", 2, 4, 0.5, "ap-guangzhou-1", v1.CapacityTypeSpot),
+	}
+
+	_, providerSpec, err := provider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if providerSpec.InternetAccessible.AddressType != capiv1beta1.HighQualityEIPAddressType {
+		t.Errorf("Expected address type HighQualityEIP for spot, got %s", providerSpec.InternetAccessible.AddressType)
+	}
+	if providerSpec.InternetAccessible.PublicIPAssigned {
+		t.Error("Expected no public IP assigned with an EIP")
+	}
+	if providerSpec.InternetAccessible.AddressID != nil {
+		t.Errorf("Expected no address ID without an address pool, got %s", *providerSpec.InternetAccessible.AddressID)
+	}
+}
+
+func TestCreate_WithAddressPool(t *testing.T) {
+	scheme := createScheme()
+	ctx := context.Background()
+
+	nodeClass := createDefaultNodeClass()
+	nodeClass.Spec.InternetAccessible = &api.InternetAccessible{
+		AddressType:     lo.ToPtr(api.AddressTypeEIP),
+		AddressPoolTags: map[string]string{"pool": "egress"},
+	}
+	nodeClass.Status.Addresses = []api.Address{{ID: "eip-a"}, {ID: "eip-b"}}
+	nodeClaim := createDefaultNodeClaim()
+
+	fakeClient := createFakeClient(scheme, nodeClass, nodeClaim)
+	provider := NewDefaultProvider(ctx, nil, fakeClient, &mockZoneProvider{}, "test-cluster")
+	instanceTypes := []*cloudprovider.InstanceType{
+		createInstanceType("S5.MEDIUM4", 2, 4, 0.5, "ap-guangzhou-1", v1.CapacityTypeOnDemand),
+	}
+
+	// the addresses taken by the created machines aren't picked again
+	for _, expected := range []string{"eip-a", "eip-b"} {
+		_, providerSpec, err := provider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if lo.FromPtr(providerSpec.InternetAccessible.AddressID) != expected {
+			t.Errorf("Expected address %s, got %s", expected, lo.FromPtr(providerSpec.InternetAccessible.AddressID))
+		}
+	}
+	_, _, err := provider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
+	if !cloudprovider.IsInsufficientCapacityError(err) {
+		t.Errorf("Expected insufficient capacity error when the address pool is exhausted, got %v", err)
+	}
+}
+
+// staleCacheClient is a simpleFakeClient safe for concurrent use whose cache never shows the created machines.
+type staleCacheClient struct {
+	*simpleFakeClient
+	mu sync.Mutex
+}
+
+func (c *staleCacheClient) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	return nil
+}
+
+func (c *staleCacheClient) Create(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.simpleFakeClient.Create(ctx, obj, opts...)
+}
+
+func TestCreate_WithAddressPool_Concurrent(t *testing.T) {
+	scheme := createScheme()
+	ctx := context.Background()
+
+	nodeClass := createDefaultNodeClass()
+	nodeClass.Spec.InternetAccessible = &api.InternetAccessible{
+		AddressType:     lo.ToPtr(api.AddressTypeEIP),
+		AddressPoolTags: map[string]string{"pool": "egress"},
+	}
+	nodeClass.Status.Addresses = []api.Address{{ID: "eip-a"}, {ID: "eip-b"}}
+	nodeClaim := createDefaultNodeClaim()
+
+	staleClient := &staleCacheClient{simpleFakeClient: createFakeClient(scheme, nodeClass, nodeClaim)}
+	provider := NewDefaultProvider(ctx, nil, staleClient, &mockZoneProvider{}, "test-cluster")
+	instanceTypes := []*cloudprovider.InstanceType{
+		createInstanceType("S5.MEDIUM4", 2, 4, 0.5, "ap-guangzhou-1", v1.CapacityTypeOnDemand),
+	}
+
+	// the addresses picked by the parallel launches are reserved while the machines are missing from the cache
+	var wg sync.WaitGroup
+	addressIDs := make([]string, 4)
+	errs := make([]error, 4)
+	for i := range addressIDs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, providerSpec, err := provider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
+			if errs[i] = err; err == nil {
+				addressIDs[i] = lo.FromPtr(providerSpec.InternetAccessible.AddressID)
+			}
+		}(i)
+	}
+	wg.Wait()
+	picked := lo.Compact(addressIDs)
+	if len(picked) != 2 || len(lo.Uniq(picked)) != 2 {
+		t.Errorf("Expected eip-a and eip-b to be picked once each, got %v", picked)
+	}
+	for _, err := range errs {
+		if err != nil && !cloudprovider.IsInsufficientCapacityError(err) {
+			t.Errorf("Expected insufficient capacity error when the address pool is exhausted, got %v", err)
+		}
+	}
+
+	// the reservations are released once the machines show up in the cache
+	provider.kubeClient = staleClient.simpleFakeClient
+	if _, _, err := provider.Create(ctx, nodeClass, nodeClaim, instanceTypes); !cloudprovider.IsInsufficientCapacityError(err) {
+		t.Errorf("Expected insufficient capacity error when the address pool is exhausted, got %v", err)
+	}
+	if len(provider.inflightAddresses.reserved) != 0 {
+		t.Errorf("Expected no reservation once the machines are cached, got %v", provider.inflightAddresses.reserved)
+	}
+}
+
+func TestCreate_WithAddressPool_CreateFailed(t *testing.T) {
+	scheme := createScheme()
+	ctx := context.Background()
+
+	nodeClass := createDefaultNodeClass()
+	nodeClass.Spec.InternetAccessible = &api.InternetAccessible{
+		AddressType:     lo.ToPtr(api.AddressTypeEIP),
+		AddressPoolTags: map[string]string{"pool": "egress"},
+	}
+	nodeClass.Status.Addresses = []api.Address{{ID: "eip-a"}}
+	nodeClaim := createDefaultNodeClaim()
+
+	fakeClient := createFakeClient(scheme, nodeClass, nodeClaim)
+	fakeClient.createErr = fmt.Errorf("create failed")
+	provider := NewDefaultProvider(ctx, nil, fakeClient, &mockZoneProvider{}, "test-cluster")
+	instanceTypes := []*cloudprovider.InstanceType{
+		createInstanceType("S5.MEDIUM4", 2, 4, 0.5, "ap-guangzhou-1", v1.CapacityTypeOnDemand),
+	}
+
+	if _, _, err := provider.Create(ctx, nodeClass, nodeClaim, instanceTypes); err == nil {
+		t.Fatal("Expected error when creating the machine fails")
+	}
+	// the address of the machine which failed to be created is picked again
+	fakeClient.createErr = nil
+	_, providerSpec, err := provider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if lo.FromPtr(providerSpec.InternetAccessible.AddressID) != "eip-a" {
+		t.Errorf("Expected address eip-a, got %s", lo.FromPtr(providerSpec.InternetAccessible.AddressID))
+	}
+}
+
+func TestCreate_WithLifecycleScriptFragments(t *testing.T) {
+	scheme := createScheme()
+	ctx := tkeoptions.ToContext(context.Background(), &tkeoptions.Options{SystemNamespace: "karpenter"})
+
+	nodeClass := createDefaultNodeClass()
+	nodeClass.Spec.LifecycleScript = &api.LifecycleScript{
+		PreInitScript: lo.ToPtr("#!/bin/bash\necho {{ .NodePool }}"),
+		PreInitScripts: []api.ScriptSource{
+			{SecretKeyRef: &api.ScriptKeySelector{Name: "scripts", Key: "login.sh"}},
+			{Inline: lo.ToPtr("echo {{ .ClusterID }} {{ .NodePool }} {{ .Zone }} {{ .InstanceType }}")},
+		},
+		PostInitScripts: []api.ScriptSource{
+			{ConfigMapKeyRef: &api.ScriptKeySelector{Name: "scripts", Key: "post.sh"}},
+		},
+	}
+	nodeClaim := createDefaultNodeClaim()
+	nodeClaim.Labels[v1.NodePoolLabelKey] = "default"
+
+	kubernetesInterface := kubernetesfake.NewSimpleClientset(
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "scripts", Namespace: "karpenter", Labels: map[string]string{api.LabelLifecycleScript: "true"}},
+			Data:       map[string][]byte{"login.sh": []byte("docker login -p secret")},
+		},
+		&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "scripts", Namespace: "karpenter", Labels: map[string]string{api.LabelLifecycleScript: "true"}},
+			Data:       map[string]string{"post.sh": "echo {{ .CapacityType }}"},
+		},
+	)
+	fakeClient := createFakeClient(scheme, nodeClass, nodeClaim)
+	provider := NewDefaultProvider(ctx, kubernetesInterface, fakeClient, &mockZoneProvider{}, "test-cluster")
+	instanceTypes := []*cloudprovider.InstanceType{
+		createInstanceType("S5.MEDIUM4", 2, 4, 0.5, "ap-guangzhou-1", v1.CapacityTypeOnDemand),
+	}
+
+	_, providerSpec, err := provider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	// the inline script isn't rendered as a template to stay compatible
+	expected := "#!/bin/bash\necho {{ .NodePool }}\ndocker login -p secret\necho test-cluster default ap-guangzhou-1 S5.MEDIUM4"
+	if providerSpec.Lifecycle.PreInit != expected {
+		t.Errorf("Expected pre-init script %q, got %q", expected, providerSpec.Lifecycle.PreInit)
+	}
+	if providerSpec.Lifecycle.PostInit != "echo on-demand" {
+		t.Errorf("Expected post-init script %q, got %q", "echo on-demand", providerSpec.Lifecycle.PostInit)
+	}
+
+	nodeClass.Spec.LifecycleScript.PostInitScripts = []api.ScriptSource{
+		{SecretKeyRef: &api.ScriptKeySelector{Name: "scripts", Key: "missing.sh"}},
+	}
+	if _, _, err = provider.Create(ctx, nodeClass, nodeClaim, instanceTypes); err == nil {
+		t.Error("Expected error with a missing secret key")
+	}
+
+	nodeClass.Spec.LifecycleScript.PostInitScripts = []api.ScriptSource{{Inline: lo.ToPtr("echo {{ .Unknown }}")}}
+	if _, _, err = provider.Create(ctx, nodeClass, nodeClaim, instanceTypes); err == nil {
+		t.Error("Expected error with an unknown template variable")
+	}
+}
+
+func TestResolveScriptSource_RestrictedSecrets(t *testing.T) {
+	ctx := tkeoptions.ToContext(context.Background(), &tkeoptions.Options{SystemNamespace: "karpenter", APIKeySecretName: "apisecret"})
+	kubernetesInterface := kubernetesfake.NewSimpleClientset(
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "apisecret", Namespace: "karpenter", Labels: map[string]string{api.LabelLifecycleScript: "true"}},
+			Data:       map[string][]byte{"secretKey": []byte("xxx")},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "unlabeled", Namespace: "karpenter"},
+			Data:       map[string][]byte{"token": []byte("xxx")},
+		},
+		&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "unlabeled", Namespace: "karpenter"},
+			Data:       map[string]string{"post.sh": "echo done"},
+		},
+	)
+	provider := NewDefaultProvider(ctx, kubernetesInterface, nil, &mockZoneProvider{}, "test-cluster")
+
+	tests := []struct {
+		name    string
+		source  api.ScriptSource
+		wantErr string
+	}{
+		{"api key secret", api.ScriptSource{SecretKeyRef: &api.ScriptKeySelector{Name: "apisecret", Key: "secretKey"}}, "holds the credentials"},
+		{"unlabeled secret", api.ScriptSource{SecretKeyRef: &api.ScriptKeySelector{Name: "unlabeled", Key: "token"}}, "isn't labeled"},
+		{"unlabeled configmap", api.ScriptSource{ConfigMapKeyRef: &api.ScriptKeySelector{Name: "unlabeled", Key: "post.sh"}}, "isn't labeled"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content, err := provider.resolveScriptSource(ctx, tt.source)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+			}
+			if content != "" {
+				t.Errorf("Expected no content, got %q", content)
+			}
+		})
+	}
+}
+
+func TestCreate_LifecycleScriptTooLarge(t *testing.T) {
+	scheme := createScheme()
+	ctx := context.Background()
+
+	nodeClass := createDefaultNodeClass()
+	nodeClass.Spec.LifecycleScript = &api.LifecycleScript{
+		PostInitScripts: []api.ScriptSource{
+			{Inline: lo.ToPtr(strings.Repeat("#", maxLifecycleScriptSize))},
+			{Inline: lo.ToPtr("echo done")},
+		},
+	}
+	nodeClaim := createDefaultNodeClaim()
+
+	fakeClient := createFakeClient(scheme, nodeClass, nodeClaim)
+	provider := NewDefaultProvider(ctx, nil, fakeClient, &mockZoneProvider{}, "test-cluster")
+	instanceTypes := []*cloudprovider.InstanceType{
+		createInstanceType("S5.MEDIUM4", 2, 4, 0.5, "ap-guangzhou-1", v1.CapacityTypeOnDemand),
+	}
+
+	_, _, err := provider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
+	if err == nil || !strings.Contains(err.Error(), "exceeding the limit") {
+		t.Errorf("Expected size limit error, got %v", err)
+	}
+}
+
+func TestValidateLifecycleScript(t *testing.T) {
+	ctx := tkeoptions.ToContext(context.Background(), &tkeoptions.Options{SystemNamespace: "karpenter"})
+	kubernetesInterface := kubernetesfake.NewSimpleClientset(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "scripts", Namespace: "karpenter", Labels: map[string]string{api.LabelLifecycleScript: "true"}},
+		Data:       map[string]string{"post.sh": "echo {{ .NodeClaim }}"},
+	})
+	provider := NewDefaultProvider(ctx, kubernetesInterface, nil, &mockZoneProvider{}, "test-cluster")
+
+	nodeClass := createDefaultNodeClass()
+	if err := provider.ValidateLifecycleScript(ctx, nodeClass); err != nil {
+		t.Errorf("Expected no error without lifecycle script, got %v", err)
+	}
+	nodeClass.Spec.LifecycleScript = &api.LifecycleScript{
+		PostInitScripts: []api.ScriptSource{{ConfigMapKeyRef: &api.ScriptKeySelector{Name: "scripts", Key: "post.sh"}}},
+	}
+	if err := provider.ValidateLifecycleScript(ctx, nodeClass); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	nodeClass.Spec.LifecycleScript.PreInitScripts = []api.ScriptSource{{SecretKeyRef: &api.ScriptKeySelector{Name: "missing", Key: "pre.sh"}}}
+	if err := provider.ValidateLifecycleScript(ctx, nodeClass); err == nil || !strings.Contains(err.Error(), "getting secret missing") {
+		t.Errorf("Expected missing secret error, got %v", err)
+	}
+	nodeClass.Spec.LifecycleScript.PreInitScripts = []api.ScriptSource{{Inline: lo.ToPtr(strings.Repeat("#", maxLifecycleScriptSize+1))}}
+	if err := provider.ValidateLifecycleScript(ctx, nodeClass); err == nil || !strings.Contains(err.Error(), "exceeding the limit") {
+		t.Errorf("Expected size limit error, got %v", err)
+	}
+}
+
+func TestCreate_WithTaintsAndLabels(t *testing.T) {
+	scheme := createScheme()
+	ctx := context.Background()
+
+	nodeClass := createDefaultNodeClass()
+	nodeClaim := createDefaultNodeClaim()
+	nodeClaim.Labels["team"] = "batch"
+	nodeClaim.Spec.Taints = []corev1.Taint{{Key: "dedicated", Value: "batch", Effect: corev1.TaintEffectNoSchedule}}
+	nodeClaim.Spec.StartupTaints = []corev1.Taint{
+		{Key: "node.cilium.io/agent-not-ready", Value: "true", Effect: corev1.TaintEffectNoExecute},
+		{Key: "dedicated", Value: "batch", Effect: corev1.TaintEffectNoSchedule},
+	}
+
+	fakeClient := createFakeClient(scheme, nodeClass, nodeClaim)
+	provider := NewDefaultProvider(ctx, nil, fakeClient, &mockZoneProvider{}, "test-cluster")
+	instanceTypes := []*cloudprovider.InstanceType{
+		createInstanceType("S5.MEDIUM4", 2, 4, 0.5, "ap-guangzhou-1", v1.CapacityTypeOnDemand),
+	}
+
+	machine, providerSpec, err := provider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	expectedTaintArg := "register-with-taints=karpenter.sh/unregistered:NoExecute,dedicated=batch:NoSchedule,node.cilium.io/agent-not-ready=true:NoExecute"
+	if !lo.Contains(providerSpec.Management.KubeletArgs, expectedTaintArg) {
+		t.Errorf("Expected taint arg %s in %v", expectedTaintArg, providerSpec.Management.KubeletArgs)
+	}
+	if !reflect.DeepEqual(machine.Spec.Taints, nodeClaim.Spec.Taints) {
+		t.Errorf("Expected machine taints %v, got %v", nodeClaim.Spec.Taints, machine.Spec.Taints)
+	}
+	if machine.Spec.ObjectMeta.Labels["team"] != "batch" {
+		t.Errorf("Expected node label team=batch, got %v", machine.Spec.ObjectMeta.Labels)
+	}
+	if machine.Spec.ObjectMeta.Labels[v1.NodePoolLabelKey] != nodeClaim.Labels[v1.NodePoolLabelKey] {
+		t.Errorf("Expected node label %s, got %v", v1.NodePoolLabelKey, machine.Spec.ObjectMeta.Labels)
+	}
+}
+
+func TestCreate_WithPinnedVersions(t *testing.T) {
+	scheme := createScheme()
+	ctx := context.Background()
+
+	nodeClass := createDefaultNodeClass()
+	nodeClass.Spec.KubeletVersion = lo.ToPtr("1.30.0")
+	nodeClass.Spec.RuntimeVersion = lo.ToPtr("1.6.9")
+	nodeClaim := createDefaultNodeClaim()
+
+	fakeClient := createFakeClient(scheme, nodeClass, nodeClaim)
+	provider := NewDefaultProvider(ctx, nil, fakeClient, &mockZoneProvider{}, "test-cluster")
+	instanceTypes := []*cloudprovider.InstanceType{
+		createInstanceType("S5.MEDIUM4", 2, 4, 0.5, "ap-guangzhou-1", v1.CapacityTypeOnDemand),
+	}
+
+	machine, _, err := provider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if lo.FromPtr(machine.Spec.KubeletVersion) != "1.30.0" {
+		t.Errorf("Expected kubelet version 1.30.0, got %v", machine.Spec.KubeletVersion)
+	}
+	if lo.FromPtr(machine.Spec.RuntimeVersion) != "1.6.9" {
+		t.Errorf("Expected runtime version 1.6.9, got %v", machine.Spec.RuntimeVersion)
+	}
+}
+
+// Helper function to create ARM architecture instance type
+func createARMInstanceType(name string, cpu int64, memory int64, price float64, zone string, capacityType string) *cloudprovider.InstanceType {
+	requirements := scheduling.NewRequirements(
+		scheduling.NewRequirement(corev1.LabelTopologyZone, corev1.NodeSelectorOpIn, zone),
+		scheduling.NewRequirement(v1.CapacityTypeLabelKey, corev1.NodeSelectorOpIn, capacityType),
+		scheduling.NewRequirement(corev1.LabelArchStable, corev1.NodeSelectorOpIn, "arm64"),
+		scheduling.NewRequirement(api.LabelInstanceFamily, corev1.NodeSelectorOpIn, "SR1"),
+		scheduling.NewRequirement(api.LabelInstanceCPU, corev1.NodeSelectorOpIn, fmt.Sprintf("%d", cpu)),
+		scheduling.NewRequirement(api.LabelInstanceMemoryGB, corev1.NodeSelectorOpIn, fmt.Sprintf("%d", memory)),
+	)
+
+	capacity := corev1.ResourceList{
+		corev1.ResourceCPU:              resource.MustParse(fmt.Sprintf("%d", cpu)),
+		corev1.ResourceMemory:           resource.MustParse(fmt.Sprintf("%dGi", memory)),
+		corev1.ResourcePods:             resource.MustParse("110"),
+		corev1.ResourceEphemeralStorage: resource.MustParse("100Gi"),
+	}
+
+	overhead := &cloudprovider.InstanceTypeOverhead{
+		KubeReserved: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("100m"),
+			corev1.ResourceMemory: resource.MustParse("100Mi"),
+		},
+		SystemReserved: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("100m"),
+			corev1.ResourceMemory: resource.MustParse("100Mi"),
+		},
+		EvictionThreshold: corev1.ResourceList{
+			corev1.ResourceMemory:           resource.MustParse("100Mi"),
+			corev1.ResourceEphemeralStorage: resource.MustParse("10Gi"),
+		},
+	}
+
+	offering := &cloudprovider.Offering{
+		Requirements: requirements,
+		Price:        price,
+		Available:    true,
+	}
+
+	return &cloudprovider.InstanceType{
+		Name:         name,
+		Requirements: requirements,
+		Capacity:     capacity,
+		Overhead:     overhead,
+		Offerings:    []*cloudprovider.Offering{offering},
+	}
+}
+
+// Test Create with ARM architecture instance type
+func TestCreate_ARMArchitecture(t *testing.T) {
+	scheme := createScheme()
+	ctx := context.Background()
+
+	nodeClass := createDefaultNodeClass()
+	nodeClaim := createDefaultNodeClaim()
+
+	// Add ARM architecture requirement
+	nodeClaim.Spec.Requirements = []v1.NodeSelectorRequirementWithMinValues{
+		{
+			
+				Key:      corev1.LabelArchStable,
+				Operator: corev1.NodeSelectorOpIn,
+				Values:   []string{"arm64"},},
+	}
+
+	instanceTypes := []*cloudprovider.InstanceType{
+		createARMInstanceType("SR1.MEDIUM4", 4, 8, 0.4, "ap-guangzhou-1", v1.CapacityTypeOnDemand),
+	}
+
+	mockZoneProvider := &mockZoneProvider{}
+	fakeClient := createFakeClient(scheme, nodeClass, nodeClaim)
+
+	provider := NewDefaultProvider(ctx, nil, fakeClient, mockZoneProvider, "test-cluster")
+
+	machine, providerSpec, err := provider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
+
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if machine == nil {
+		t.Fatal("Expected machine to be created, got nil")
+	}
+
+	if providerSpec.InstanceType != "SR1.MEDIUM4" {
+		t.Errorf("Expected instance type SR1.MEDIUM4, got %s", providerSpec.InstanceType)
+	}
+
+	if machine.Labels[corev1.LabelArchStable] != "arm64" {
+		t.Errorf("Expected architecture label arm64, got %s", machine.Labels[corev1.LabelArchStable])
+	}
+
+	if machine.Labels[api.LabelInstanceFamily] != "SR1" {
+		t.Errorf("Expected instance family SR1, got %s", machine.Labels[api.LabelInstanceFamily])
+	}
+
+	if machine.Labels[api.LabelInstanceCPU] != "4" {
+		t.Errorf("Expected CPU label 4, got %s", machine.Labels[api.LabelInstanceCPU])
+	}
+
+	if machine.Labels[api.LabelInstanceMemoryGB] != "8" {
+		t.Errorf("Expected Memory label 8, got %s", machine.Labels[api.LabelInstanceMemoryGB])
+	}
+}
+
+// Test Create with multiple ARM instance types
+func TestCreate_MultipleARMInstanceTypes(t *testing.T) {
+	scheme := createScheme()
+	ctx := context.Background()
+
+	nodeClass := createDefaultNodeClass()
+	nodeClaim := createDefaultNodeClaim()
+
+	// Add ARM architecture requirement
+	nodeClaim.Spec.Requirements = []v1.NodeSelectorRequirementWithMinValues{
+		{
+			
+				Key:      corev1.LabelArchStable,
+				Operator: corev1.NodeSelectorOpIn,
 				Values:   []string{"arm64"},},
 	}
 
@@ -2529,7 +3357,7 @@ func TestCreate_MultipleARMInstanceTypes(t *testing.T) {
 	mockZoneProvider := &mockZoneProvider{}
 	fakeClient := createFakeClient(scheme, nodeClass, nodeClaim)
 
-	provider := NewDefaultProvider(ctx, fakeClient, mockZoneProvider, "test-cluster")
+	provider := NewDefaultProvider(ctx, nil, fakeClient, mockZoneProvider, "test-cluster")
 
 	machine, providerSpec, err := provider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
 
@@ -2573,7 +3401,7 @@ func TestCreate_MixedArchitectures(t *testing.T) {
 	mockZoneProvider := &mockZoneProvider{}
 	fakeClient := createFakeClient(scheme, nodeClass, nodeClaim)
 
-	provider := NewDefaultProvider(ctx, fakeClient, mockZoneProvider, "test-cluster")
+	provider := NewDefaultProvider(ctx, nil, fakeClient, mockZoneProvider, "test-cluster")
 
 	machine, providerSpec, err := provider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
 
@@ -2620,7 +3448,7 @@ func TestCreate_ARMSpotInstances(t *testing.T) {
 	mockZoneProvider := &mockZoneProvider{}
 	fakeClient := createFakeClient(scheme, nodeClass, nodeClaim)
 
-	provider := NewDefaultProvider(ctx, fakeClient, mockZoneProvider, "test-cluster")
+	provider := NewDefaultProvider(ctx, nil, fakeClient, mockZoneProvider, "test-cluster")
 
 	machine, providerSpec, err := provider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
 
@@ -2694,7 +3522,7 @@ func TestCreate_ARMDifferentCapacityTypes(t *testing.T) {
 			mockZoneProvider := &mockZoneProvider{}
 			fakeClient := createFakeClient(scheme, nodeClass, nodeClaim)
 
-			provider := NewDefaultProvider(ctx, fakeClient, mockZoneProvider, "test-cluster")
+			provider := NewDefaultProvider(ctx, nil, fakeClient, mockZoneProvider, "test-cluster")
 
 			machine, providerSpec, err := provider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
 
@@ -2746,7 +3574,7 @@ func TestCreate_ARMResourceBoundaryValues(t *testing.T) {
 	mockZoneProvider := &mockZoneProvider{}
 	fakeClient := createFakeClient(scheme, nodeClass, nodeClaim)
 
-	provider := NewDefaultProvider(ctx, fakeClient, mockZoneProvider, "test-cluster")
+	provider := NewDefaultProvider(ctx, nil, fakeClient, mockZoneProvider, "test-cluster")
 
 	machine, providerSpec, err := provider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
 
@@ -2807,7 +3635,7 @@ func TestCreate_ARMWithResourceRequirements(t *testing.T) {
 	mockZoneProvider := &mockZoneProvider{}
 	fakeClient := createFakeClient(scheme, nodeClass, nodeClaim)
 
-	provider := NewDefaultProvider(ctx, fakeClient, mockZoneProvider, "test-cluster")
+	provider := NewDefaultProvider(ctx, nil, fakeClient, mockZoneProvider, "test-cluster")
 
 	machine, providerSpec, err := provider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
 
@@ -2838,7 +3666,7 @@ func TestGet_ListError(t *testing.T) {
 	fakeClient := createFakeClient(scheme)
 	fakeClient.listErr = fmt.Errorf("list error")
 
-	provider := NewDefaultProvider(ctx, fakeClient, &mockZoneProvider{}, "test-cluster")
+	provider := NewDefaultProvider(ctx, nil, fakeClient, &mockZoneProvider{}, "test-cluster")
 
 	_, err := provider.Get(ctx, "some-provider-id")
 	if err == nil {
@@ -2856,7 +3684,7 @@ func TestList_ListError(t *testing.T) {
 	fakeClient := createFakeClient(scheme)
 	fakeClient.listErr = fmt.Errorf("list error")
 
-	provider := NewDefaultProvider(ctx, fakeClient, &mockZoneProvider{}, "test-cluster")
+	provider := NewDefaultProvider(ctx, nil, fakeClient, &mockZoneProvider{}, "test-cluster")
 
 	_, err := provider.List(ctx)
 	if err == nil {
@@ -2874,7 +3702,7 @@ func TestDelete_GetReturnsUnexpectedError(t *testing.T) {
 	fakeClient := createFakeClient(scheme)
 	fakeClient.listErr = fmt.Errorf("unexpected list error")
 
-	provider := NewDefaultProvider(ctx, fakeClient, &mockZoneProvider{}, "test-cluster")
+	provider := NewDefaultProvider(ctx, nil, fakeClient, &mockZoneProvider{}, "test-cluster")
 
 	nodeClaim := createDefaultNodeClaim()
 	nodeClaim.Status.ProviderID = "some-provider-id"
@@ -2893,7 +3721,7 @@ func TestDelete_FallbackListError(t *testing.T) {
 	ctx := context.Background()
 
 	fakeClient := createFakeClient(scheme)
-	provider := NewDefaultProvider(ctx, fakeClient, &mockZoneProvider{}, "test-cluster")
+	provider := NewDefaultProvider(ctx, nil, fakeClient, &mockZoneProvider{}, "test-cluster")
 
 	nodeClaim := createDefaultNodeClaim()
 	nodeClaim.Status.ProviderID = ""
@@ -2930,7 +3758,7 @@ func TestDelete_FallbackDeleteError(t *testing.T) {
 	fakeClient := createFakeClient(scheme, machine)
 	fakeClient.deleteErr = fmt.Errorf("delete failed")
 
-	provider := NewDefaultProvider(ctx, fakeClient, &mockZoneProvider{}, "test-cluster")
+	provider := NewDefaultProvider(ctx, nil, fakeClient, &mockZoneProvider{}, "test-cluster")
 
 	err := provider.Delete(ctx, nodeClaim)
 	if err == nil {
@@ -2960,7 +3788,7 @@ func TestDelete_FallbackNoMatchingOwner(t *testing.T) {
 	}
 
 	fakeClient := createFakeClient(scheme, machine)
-	provider := NewDefaultProvider(ctx, fakeClient, &mockZoneProvider{}, "test-cluster")
+	provider := NewDefaultProvider(ctx, nil, fakeClient, &mockZoneProvider{}, "test-cluster")
 
 	err := provider.Delete(ctx, nodeClaim)
 	if err != nil {
@@ -3056,7 +3884,7 @@ func TestCreate_SecurityGroupTruncation(t *testing.T) {
 	}
 
 	fakeClient := createFakeClient(scheme, nodeClass, nodeClaim)
-	provider := NewDefaultProvider(ctx, fakeClient, &mockZoneProvider{}, "test-cluster")
+	provider := NewDefaultProvider(ctx, nil, fakeClient, &mockZoneProvider{}, "test-cluster")
 
 	_, providerSpec, err := provider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
 	if err != nil {
@@ -3083,7 +3911,7 @@ func TestCreate_InternetAccessibleBandwidthPackage(t *testing.T) {
 	}
 
 	fakeClient := createFakeClient(scheme, nodeClass, nodeClaim)
-	provider := NewDefaultProvider(ctx, fakeClient, &mockZoneProvider{}, "test-cluster")
+	provider := NewDefaultProvider(ctx, nil, fakeClient, &mockZoneProvider{}, "test-cluster")
 
 	_, providerSpec, err := provider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
 	if err != nil {
@@ -3118,7 +3946,7 @@ func TestCreate_DataDiskFileSystem(t *testing.T) {
 	}
 
 	fakeClient := createFakeClient(scheme, nodeClass, nodeClaim)
-	provider := NewDefaultProvider(ctx, fakeClient, &mockZoneProvider{}, "test-cluster")
+	provider := NewDefaultProvider(ctx, nil, fakeClient, &mockZoneProvider{}, "test-cluster")
 
 	_, providerSpec, err := provider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
 	if err != nil {
@@ -3152,7 +3980,7 @@ func TestCreate_DataDiskMountTarget(t *testing.T) {
 	}
 
 	fakeClient := createFakeClient(scheme, nodeClass, nodeClaim)
-	provider := NewDefaultProvider(ctx, fakeClient, &mockZoneProvider{}, "test-cluster")
+	provider := NewDefaultProvider(ctx, nil, fakeClient, &mockZoneProvider{}, "test-cluster")
 
 	_, providerSpec, err := provider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
 	if err != nil {
@@ -3182,7 +4010,7 @@ func TestCreate_GPUFabricTrue(t *testing.T) {
 	instanceType.Capacity[corev1.ResourceName(api.ResourceNVIDIAGPU)] = resource.MustParse("1")
 
 	fakeClient := createFakeClient(scheme, nodeClass, nodeClaim)
-	provider := NewDefaultProvider(ctx, fakeClient, &mockZoneProvider{}, "test-cluster")
+	provider := NewDefaultProvider(ctx, nil, fakeClient, &mockZoneProvider{}, "test-cluster")
 
 	machine, _, err := provider.Create(ctx, nodeClass, nodeClaim, []*cloudprovider.InstanceType{instanceType})
 	if err != nil {
@@ -3221,7 +4049,7 @@ func TestCreate_SpotWithInternetAccessible(t *testing.T) {
 	}
 
 	fakeClient := createFakeClient(scheme, nodeClass, nodeClaim)
-	provider := NewDefaultProvider(ctx, fakeClient, &mockZoneProvider{}, "test-cluster")
+	provider := NewDefaultProvider(ctx, nil, fakeClient, &mockZoneProvider{}, "test-cluster")
 
 	_, providerSpec, err := provider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
 	if err != nil {
@@ -3255,7 +4083,7 @@ func TestCreate_InvalidHostIP(t *testing.T) {
 	}
 
 	fakeClient := createFakeClient(scheme, nodeClass, nodeClaim)
-	provider := NewDefaultProvider(ctx, fakeClient, &mockZoneProvider{}, "test-cluster")
+	provider := NewDefaultProvider(ctx, nil, fakeClient, &mockZoneProvider{}, "test-cluster")
 
 	_, providerSpec, err := provider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
 	if err != nil {
@@ -3283,7 +4111,7 @@ func TestCreate_HostnameAnnotation(t *testing.T) {
 	}
 
 	fakeClient := createFakeClient(scheme, nodeClass, nodeClaim)
-	provider := NewDefaultProvider(ctx, fakeClient, &mockZoneProvider{}, "test-cluster")
+	provider := NewDefaultProvider(ctx, nil, fakeClient, &mockZoneProvider{}, "test-cluster")
 
 	_, providerSpec, err := provider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
 	if err != nil {
@@ -3310,7 +4138,7 @@ func TestCreate_RuntimeRootAnnotation(t *testing.T) {
 	}
 
 	fakeClient := createFakeClient(scheme, nodeClass, nodeClaim)
-	provider := NewDefaultProvider(ctx, fakeClient, &mockZoneProvider{}, "test-cluster")
+	provider := NewDefaultProvider(ctx, nil, fakeClient, &mockZoneProvider{}, "test-cluster")
 
 	machine, _, err := provider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
 	if err != nil {
@@ -3335,7 +4163,7 @@ func TestCreate_KubeClientCreateError(t *testing.T) {
 	fakeClient := createFakeClient(scheme, nodeClass, nodeClaim)
 	fakeClient.createErr = fmt.Errorf("create failed")
 
-	provider := NewDefaultProvider(ctx, fakeClient, &mockZoneProvider{}, "test-cluster")
+	provider := NewDefaultProvider(ctx, nil, fakeClient, &mockZoneProvider{}, "test-cluster")
 
 	_, _, err := provider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
 	if err == nil {
@@ -3368,7 +4196,7 @@ func TestCreate_ENICapacityAnnotations(t *testing.T) {
 	instanceTypes := []*cloudprovider.InstanceType{instanceType}
 
 	fakeClient := createFakeClient(scheme, nodeClass, nodeClaim)
-	provider := NewDefaultProvider(ctx, fakeClient, &mockZoneProvider{}, "test-cluster")
+	provider := NewDefaultProvider(ctx, nil, fakeClient, &mockZoneProvider{}, "test-cluster")
 
 	machine, _, err := provider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
 	if err != nil {
@@ -3416,7 +4244,7 @@ func TestCreate_SystemReservedAnnotations(t *testing.T) {
 	instanceTypes := []*cloudprovider.InstanceType{instanceType}
 
 	fakeClient := createFakeClient(scheme, nodeClass, nodeClaim)
-	provider := NewDefaultProvider(ctx, fakeClient, &mockZoneProvider{}, "test-cluster")
+	provider := NewDefaultProvider(ctx, nil, fakeClient, &mockZoneProvider{}, "test-cluster")
 
 	machine, _, err := provider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
 	if err != nil {
@@ -3461,7 +4289,7 @@ func TestCreate_KubeReservedStorageAnnotation(t *testing.T) {
 	instanceTypes := []*cloudprovider.InstanceType{instanceType}
 
 	fakeClient := createFakeClient(scheme, nodeClass, nodeClaim)
-	provider := NewDefaultProvider(ctx, fakeClient, &mockZoneProvider{}, "test-cluster")
+	provider := NewDefaultProvider(ctx, nil, fakeClient, &mockZoneProvider{}, "test-cluster")
 
 	machine, _, err := provider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
 	if err != nil {
@@ -3496,7 +4324,7 @@ func TestCreate_WithTags(t *testing.T) {
 	}
 
 	fakeClient := createFakeClient(scheme, nodeClass, nodeClaim)
-	provider := NewDefaultProvider(ctx, fakeClient, &mockZoneProvider{}, "test-cluster")
+	provider := NewDefaultProvider(ctx, nil, fakeClient, &mockZoneProvider{}, "test-cluster")
 
 	machine, _, err := provider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
 	if err != nil {
@@ -3514,8 +4342,9 @@ func TestCreate_WithTags(t *testing.T) {
 		t.Fatalf("Failed to unmarshal tag annotation: %v", err)
 	}
 
-	if len(tags) != 2 {
-		t.Errorf("Expected 2 tags, got %d", len(tags))
+	// the tags of the nodeClass and the ownership tags
+	if len(tags) != 6 {
+		t.Errorf("Expected 6 tags, got %d", len(tags))
 	}
 
 	tagMap := make(map[string]string)
@@ -3529,9 +4358,20 @@ func TestCreate_WithTags(t *testing.T) {
 	if tagMap["owner"] != "team-karpenter" {
 		t.Errorf("Expected tag owner=team-karpenter, got %s", tagMap["owner"])
 	}
+	expectedOwnership := map[string]string{
+		api.TagClusterID: "test-cluster",
+		api.TagNodePool:  "test-nodepool",
+		api.TagNodeClaim: "test-nodeclaim",
+		api.TagNodeClass: "test-nodeclass",
+	}
+	for k, v := range expectedOwnership {
+		if tagMap[k] != v {
+			t.Errorf("Expected tag %s=%s, got %s", k, v, tagMap[k])
+		}
+	}
 }
 
-// TestCreate_WithTagsEmpty verifies that empty tags do NOT produce the cloud tag annotation.
+// TestCreate_WithTagsEmpty verifies that only the ownership tags are applied without the tags of the nodeClass.
 func TestCreate_WithTagsEmpty(t *testing.T) {
 	scheme := createScheme()
 	ctx := context.Background()
@@ -3545,15 +4385,45 @@ func TestCreate_WithTagsEmpty(t *testing.T) {
 	}
 
 	fakeClient := createFakeClient(scheme, nodeClass, nodeClaim)
-	provider := NewDefaultProvider(ctx, fakeClient, &mockZoneProvider{}, "test-cluster")
+	provider := NewDefaultProvider(ctx, nil, fakeClient, &mockZoneProvider{}, "test-cluster")
 
 	machine, _, err := provider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
 	if err != nil {
 		t.Fatalf("Expected no error, got %v", err)
 	}
 
-	if _, exists := machine.Annotations[capiv1beta1.AnnotationMachineCloudTag]; exists {
-		t.Error("Expected cloud tag annotation to NOT exist for empty tags")
+	tags, err := UnmarshalCloudTags(machine.Annotations[capiv1beta1.AnnotationMachineCloudTag])
+	if err != nil {
+		t.Fatalf("Failed to unmarshal tag annotation: %v", err)
+	}
+	if !reflect.DeepEqual(tags, map[string]string{
+		api.TagClusterID: "test-cluster",
+		api.TagNodePool:  "test-nodepool",
+		api.TagNodeClaim: "test-nodeclaim",
+		api.TagNodeClass: "test-nodeclass",
+	}) {
+		t.Errorf("Expected only the ownership tags, got %v", tags)
+	}
+}
+
+func TestMarshalCloudTags(t *testing.T) {
+	annotation, err := MarshalCloudTags(map[string]string{"b": "2", "a": "1"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	// sorted by key to be comparable
+	if annotation != `[{"tagKey":"a","tagValue":"1"},{"tagKey":"b","tagValue":"2"}]` {
+		t.Errorf("Unexpected annotation %s", annotation)
+	}
+	tags, err := UnmarshalCloudTags(annotation)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !reflect.DeepEqual(tags, map[string]string{"a": "1", "b": "2"}) {
+		t.Errorf("Unexpected tags %v", tags)
+	}
+	if _, err = UnmarshalCloudTags("invalid"); err == nil {
+		t.Error("Expected error for an invalid annotation")
 	}
 }
 
@@ -3574,7 +4444,7 @@ func TestCreate_MachineMetaAnnotations(t *testing.T) {
 	}
 
 	fakeClient := createFakeClient(scheme, nodeClass, nodeClaim)
-	provider := NewDefaultProvider(ctx, fakeClient, &mockZoneProvider{}, "test-cluster")
+	provider := NewDefaultProvider(ctx, nil, fakeClient, &mockZoneProvider{}, "test-cluster")
 
 	machine, _, err := provider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
 	if err != nil {
@@ -3618,7 +4488,7 @@ func TestCreate_InvalidThroughput(t *testing.T) {
 	}
 
 	fakeClient := createFakeClient(scheme, nodeClass, nodeClaim)
-	provider := NewDefaultProvider(ctx, fakeClient, &mockZoneProvider{}, "test-cluster")
+	provider := NewDefaultProvider(ctx, nil, fakeClient, &mockZoneProvider{}, "test-cluster")
 
 	// Should not panic and should not return an error
 	_, providerSpec, err := provider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
@@ -3634,3 +4504,171 @@ func TestCreate_InvalidThroughput(t *testing.T) {
 		t.Errorf("Expected ThroughputPerformance to be 0 for invalid value, got %d", providerSpec.DataDisks[0].ThroughputPerformance)
 	}
 }
+
+func TestCreate_NodeClassHashAnnotations(t *testing.T) {
+	scheme := createScheme()
+	ctx := context.Background()
+
+	nodeClass := createDefaultNodeClass()
+	nodeClaim := createDefaultNodeClaim()
+	instanceTypes := []*cloudprovider.InstanceType{
+		createInstanceType("S3.MEDIUM4", 4, 8, 0.5, "ap-guangzhou-1", v1.CapacityTypeOnDemand),
+	}
+
+	fakeClient := createFakeClient(scheme, nodeClass, nodeClaim)
+	provider := NewDefaultProvider(ctx, nil, fakeClient, &mockZoneProvider{}, "test-cluster")
+
+	machine, _, err := provider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if machine.Annotations[api.AnnotationTKEMachineNodeClassHash] != nodeClass.Hash() {
+		t.Errorf("Expected hash annotation %s, got %s", nodeClass.Hash(), machine.Annotations[api.AnnotationTKEMachineNodeClassHash])
+	}
+	if machine.Annotations[api.AnnotationTKEMachineNodeClassHashVersion] != api.TKEMachineNodeClassHashVersion {
+		t.Errorf("Expected hash version annotation %s, got %s", api.TKEMachineNodeClassHashVersion, machine.Annotations[api.AnnotationTKEMachineNodeClassHashVersion])
+	}
+}
+
+func TestInflightIPs(t *testing.T) {
+	ips := newInflightIPs()
+	subnet := api.Subnet{ID: "subnet-1", Zone: "ap-guangzhou-1", AvailableIPAddressCount: lo.ToPtr(int64(2))}
+	if got := ips.available(subnet); got != 2 {
+		t.Errorf("expected 2 available IPs, got %d", got)
+	}
+	ips.add(subnet)
+	ips.add(subnet)
+	if got := ips.available(subnet); got != 0 {
+		t.Errorf("expected 0 available IPs after two launches, got %d", got)
+	}
+	// the refreshed status already includes the launches
+	subnet.AvailableIPAddressCount = lo.ToPtr(int64(1))
+	if got := ips.available(subnet); got != 1 {
+		t.Errorf("expected 1 available IP after the status refreshed, got %d", got)
+	}
+	ips.add(subnet)
+	if got := ips.available(subnet); got != 0 {
+		t.Errorf("expected 0 available IPs, got %d", got)
+	}
+	// unresolved subnets are not restricted
+	ips.add(api.Subnet{ID: "subnet-2"})
+	if got := ips.available(api.Subnet{ID: "subnet-2"}); got <= 0 {
+		t.Errorf("expected unresolved subnet to be available, got %d", got)
+	}
+}
+
+func TestCreate_SpreadAcrossSubnets(t *testing.T) {
+	scheme := createScheme()
+	ctx := context.Background()
+
+	nodeClass := createDefaultNodeClass()
+	nodeClass.Status.Subnets = []api.Subnet{
+		{ID: "subnet-a", Zone: "ap-guangzhou-1", AvailableIPAddressCount: lo.ToPtr(int64(2))},
+		{ID: "subnet-b", Zone: "ap-guangzhou-1", AvailableIPAddressCount: lo.ToPtr(int64(2))},
+		{ID: "subnet-c", Zone: "ap-guangzhou-2", AvailableIPAddressCount: lo.ToPtr(int64(100))},
+	}
+	nodeClaim := createDefaultNodeClaim()
+	fakeClient := createFakeClient(scheme, nodeClass, nodeClaim)
+	provider := NewDefaultProvider(ctx, nil, fakeClient, &mockZoneProvider{}, "test-cluster")
+	instanceTypes := []*cloudprovider.InstanceType{
+		createInstanceType("S5.MEDIUM4", 2, 4, 0.5, "ap-guangzhou-1", v1.CapacityTypeOnDemand),
+	}
+
+	var subnetIDs []string
+	for range 4 {
+		machine, _, err := provider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		subnetIDs = append(subnetIDs, machine.Spec.SubnetID)
+	}
+	if lo.Count(subnetIDs, "subnet-a") != 2 || lo.Count(subnetIDs, "subnet-b") != 2 {
+		t.Errorf("Expected the launches to be spread across subnet-a and subnet-b, got %v", subnetIDs)
+	}
+
+	_, _, err := provider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
+	if !cloudprovider.IsInsufficientCapacityError(err) {
+		t.Errorf("Expected insufficient capacity error once the subnets are out of IPs, got %v", err)
+	}
+}
+
+func TestFilterExoticInstanceTypes(t *testing.T) {
+	withCategory := func(name, family, category string) *cloudprovider.InstanceType {
+		it := createInstanceType(name, 8, 32, 1.0, "ap-guangzhou-1", v1.CapacityTypeOnDemand)
+		it.Requirements = scheduling.NewRequirements(lo.Reject(it.Requirements.Values(), func(r *scheduling.Requirement, _ int) bool {
+			return r.Key == api.LabelInstanceFamily
+		})...)
+		it.Requirements.Add(
+			scheduling.NewRequirement(corev1.LabelInstanceTypeStable, corev1.NodeSelectorOpIn, name),
+			scheduling.NewRequirement(api.LabelInstanceFamily, corev1.NodeSelectorOpIn, family),
+			scheduling.NewRequirement(api.LabelInstanceCategory, corev1.NodeSelectorOpIn, category),
+		)
+		return it
+	}
+	standard := withCategory("S5.2XLARGE32", "S5", api.InstanceCategoryGeneral)
+	gpu := withCategory("GN7.2XLARGE32", "GN7", api.InstanceCategoryGPU)
+	bareMetal := withCategory("BMS5.24XLARGE384", "BMS5", api.InstanceCategoryBareMetal)
+	unclassified := createInstanceType("S3.MEDIUM4", 2, 4, 0.5, "ap-guangzhou-1", v1.CapacityTypeOnDemand)
+
+	names := func(its []*cloudprovider.InstanceType) []string {
+		return lo.Map(its, func(it *cloudprovider.InstanceType, _ int) string { return it.Name })
+	}
+	tests := []struct {
+		name          string
+		requirements  []v1.NodeSelectorRequirementWithMinValues
+		instanceTypes []*cloudprovider.InstanceType
+		want          []string
+	}{
+		{
+			name:          "exotic types are removed",
+			instanceTypes: []*cloudprovider.InstanceType{standard, gpu, bareMetal, unclassified},
+			want:          []string{"S5.2XLARGE32", "S3.MEDIUM4"},
+		},
+		{
+			name:          "only exotic types fit",
+			instanceTypes: []*cloudprovider.InstanceType{gpu, bareMetal},
+			want:          []string{"GN7.2XLARGE32", "BMS5.24XLARGE384"},
+		},
+		{
+			name: "family asked for",
+			requirements: []v1.NodeSelectorRequirementWithMinValues{
+				{Key: api.LabelInstanceFamily, Operator: corev1.NodeSelectorOpIn, Values: []string{"S5", "GN7"}},
+			},
+			instanceTypes: []*cloudprovider.InstanceType{standard, gpu, bareMetal},
+			want:          []string{"S5.2XLARGE32", "GN7.2XLARGE32"},
+		},
+		{
+			name: "category asked for",
+			requirements: []v1.NodeSelectorRequirementWithMinValues{
+				{Key: api.LabelInstanceCategory, Operator: corev1.NodeSelectorOpIn, Values: []string{api.InstanceCategoryGeneral, api.InstanceCategoryBareMetal}},
+			},
+			instanceTypes: []*cloudprovider.InstanceType{standard, gpu, bareMetal},
+			want:          []string{"S5.2XLARGE32", "BMS5.24XLARGE384"},
+		},
+		{
+			name: "category not excluded is not asked for",
+			requirements: []v1.NodeSelectorRequirementWithMinValues{
+				{Key: api.LabelInstanceCategory, Operator: corev1.NodeSelectorOpNotIn, Values: []string{api.InstanceCategoryFPGA}},
+			},
+			instanceTypes: []*cloudprovider.InstanceType{standard, gpu},
+			want:          []string{"S5.2XLARGE32"},
+		},
+		{
+			name: "instance type asked for",
+			requirements: []v1.NodeSelectorRequirementWithMinValues{
+				{Key: corev1.LabelInstanceTypeStable, Operator: corev1.NodeSelectorOpIn, Values: []string{"S5.2XLARGE32", "GN7.2XLARGE32"}},
+			},
+			instanceTypes: []*cloudprovider.InstanceType{standard, gpu},
+			want:          []string{"S5.2XLARGE32", "GN7.2XLARGE32"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			nodeClaim := &v1.NodeClaim{Spec: v1.NodeClaimSpec{Requirements: tt.requirements}}
+			if got := names(filterExoticInstanceTypes(nodeClaim, tt.instanceTypes)); fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("filterExoticInstanceTypes() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,89 @@
+/*
+Copyright (C) 2012-2025 Tencent. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package machine
+
+import (
+	"fmt"
+	"math"
+	"sync"
+
+	"github.com/samber/lo"
+	api "github.com/tencentcloud/karpenter-provider-tke/pkg/apis/v1beta1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+)
+
+// inflightIPs counts the IPs taken by the machines launched since the subnets were resolved, as the available IPs in
+// the status of the nodeClass are only refreshed once a minute.
+type inflightIPs struct {
+	mu      sync.Mutex
+	subnets map[string]inflightSubnet
+}
+
+type inflightSubnet struct {
+	// observed is the available IPs in the status when the launches started to be counted
+	observed int64
+	launched int64
+}
+
+func newInflightIPs() *inflightIPs {
+	return &inflightIPs{subnets: map[string]inflightSubnet{}}
+}
+
+// available returns the available IPs of the subnet minus the in-flight launches. The launches are forgotten once the
+// status reports another count, since the new count already includes them.
+func (i *inflightIPs) available(subnet api.Subnet) int64 {
+	if subnet.AvailableIPAddressCount == nil {
+		// the subnet hasn't been resolved with its available IPs yet
+		return math.MaxInt64
+	}
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	inflight, ok := i.subnets[subnet.ID]
+	if !ok || inflight.observed != *subnet.AvailableIPAddressCount {
+		return *subnet.AvailableIPAddressCount
+	}
+	return inflight.observed - inflight.launched
+}
+
+// add counts a machine launched in the subnet
+func (i *inflightIPs) add(subnet api.Subnet) {
+	if subnet.AvailableIPAddressCount == nil {
+		return
+	}
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	inflight, ok := i.subnets[subnet.ID]
+	if !ok || inflight.observed != *subnet.AvailableIPAddressCount {
+		inflight = inflightSubnet{observed: *subnet.AvailableIPAddressCount}
+	}
+	inflight.launched++
+	i.subnets[subnet.ID] = inflight
+}
+
+// pickSubnet returns the subnet of the zone with the most available IPs, so that the launches are spread across the
+// subnets of the zone. The subnets are ordered by their available IPs in the status, which breaks the ties.
+func (p *DefaultProvider) pickSubnet(nodeClass *api.TKEMachineNodeClass, zone string) (api.Subnet, error) {
+	subnets := lo.Filter(nodeClass.Status.Subnets, func(s api.Subnet, _ int) bool { return s.Zone == zone })
+	if len(subnets) == 0 {
+		return api.Subnet{}, fmt.Errorf("subnet for %s not found", zone)
+	}
+	subnet := lo.MaxBy(subnets, func(a, b api.Subnet) bool { return p.inflightIPs.available(a) > p.inflightIPs.available(b) })
+	if p.inflightIPs.available(subnet) <= 0 {
+		return api.Subnet{}, cloudprovider.NewInsufficientCapacityError(fmt.Errorf("all subnets in %s are out of IPs", zone))
+	}
+	return subnet, nil
+}
@@ -0,0 +1,66 @@
+/*
+Copyright (C) 2012-2025 Tencent. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package machine
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/samber/lo"
+	api "github.com/tencentcloud/karpenter-provider-tke/pkg/apis/v1beta1"
+	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+)
+
+type Tag struct {
+	TagKey   string `json:"tagKey"`
+	TagValue string `json:"tagValue"`
+}
+
+// CloudTags returns the tags of the instance of the machine with the labels, which are the tags of the nodeClass
+// and the ownership tags.
+func CloudTags(clusterID string, nodeClass *api.TKEMachineNodeClass, machineLabels map[string]string) map[string]string {
+	return lo.Assign(nodeClass.Spec.Tags, lo.OmitByValues(map[string]string{
+		api.TagClusterID: clusterID,
+		api.TagNodePool:  machineLabels[v1.NodePoolLabelKey],
+		api.TagNodeClaim: machineLabels[api.LabelNodeClaim],
+		api.TagNodeClass: nodeClass.Name,
+	}, []string{""}))
+}
+
+// MarshalCloudTags marshals the tags into the value of the cloud tag annotation of the machine, sorted by key.
+func MarshalCloudTags(tags map[string]string) (string, error) {
+	cloudTags := lo.MapToSlice(tags, func(k string, v string) Tag { return Tag{TagKey: k, TagValue: v} })
+	sort.Slice(cloudTags, func(i, j int) bool { return cloudTags[i].TagKey < cloudTags[j].TagKey })
+	tagsByte, err := json.Marshal(cloudTags)
+	if err != nil {
+		return "", fmt.Errorf("marshalling tags failed, %w", err)
+	}
+	return string(tagsByte), nil
+}
+
+// UnmarshalCloudTags parses the value of the cloud tag annotation of the machine.
+func UnmarshalCloudTags(annotation string) (map[string]string, error) {
+	if annotation == "" {
+		return map[string]string{}, nil
+	}
+	var cloudTags []Tag
+	if err := json.Unmarshal([]byte(annotation), &cloudTags); err != nil {
+		return nil, fmt.Errorf("unmarshalling tags failed, %w", err)
+	}
+	return lo.SliceToMap(cloudTags, func(t Tag) (string, string) { return t.TagKey, t.TagValue }), nil
+}
@@ -18,9 +18,12 @@ package sshkey
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"sort"
 
 	"github.com/samber/lo"
+	"github.com/tencentcloud/karpenter-provider-tke/pkg/apicache"
 	api "github.com/tencentcloud/karpenter-provider-tke/pkg/apis/v1beta1"
 	cvm2017 "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/cvm/v20170312"
 	"sigs.k8s.io/controller-runtime/pkg/log"
@@ -30,13 +33,20 @@ type Provider interface {
 	List(context.Context, *api.TKEMachineNodeClass) ([]*cvm2017.KeyPair, error)
 }
 
+const (
+	// describeLimit is the max page size of DescribeKeyPairs
+	describeLimit = 100
+)
+
 type DefaultProvider struct {
 	client *cvm2017.Client
+	cache  *apicache.Cache
 }
 
-func NewDefaultProvider(_ context.Context, client *cvm2017.Client) *DefaultProvider {
+func NewDefaultProvider(_ context.Context, client *cvm2017.Client, cache *apicache.Cache) *DefaultProvider {
 	return &DefaultProvider{
 		client: client,
+		cache:  cache,
 	}
 }
 
@@ -48,43 +58,81 @@ func (p *DefaultProvider) List(ctx context.Context, nodeClass *api.TKEMachineNod
 
 	keypairs := map[string]*cvm2017.KeyPair{}
 	if len(ids) != 0 {
-		req := cvm2017.NewDescribeKeyPairsRequest()
-		req.KeyIds = ids
-		resp, err := p.client.DescribeKeyPairs(req)
+		key, _ := json.Marshal(ids)
+		described, err := apicache.GetOrLoad(p.cache, "DescribeKeyPairs", "ids/"+string(key), func() ([]*cvm2017.KeyPair, error) {
+			return p.describeKeyPairs(ctx, "listsshkeyID", func(req *cvm2017.DescribeKeyPairsRequest) {
+				req.KeyIds = ids
+			})
+		})
 		if err != nil {
-			return nil, fmt.Errorf("describe key pairs failed: %v", err)
+			return nil, err
 		}
-		log.FromContext(ctx).WithValues("process", "listsshkeyID").V(1).Info("tencent cloud request", "action", req.GetAction(), "requestID", resp.Response.RequestId)
+		for _, keypair := range described {
+			keypairs[lo.FromPtr(keypair.KeyId)] = keypair
+		}
+	}
 
-		for _, keypair := range resp.Response.KeyPairSet {
+	for _, filterSet := range filterSets {
+		key, _ := json.Marshal(filterSet.filters)
+		described, err := apicache.GetOrLoad(p.cache, "DescribeKeyPairs", "filters/"+string(key), func() ([]*cvm2017.KeyPair, error) {
+			return p.describeKeyPairs(ctx, "listsshkeyTag", func(req *cvm2017.DescribeKeyPairsRequest) {
+				req.Filters = filterSet.filters
+			})
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, keypair := range described {
+			if !matches(filterSet.term, keypair) {
+				continue
+			}
 			keypairs[lo.FromPtr(keypair.KeyId)] = keypair
 		}
 	}
 
-	for _, filter := range filterSets {
+	return lo.Values(keypairs), nil
+}
+
+// describeKeyPairs returns all the pages of the key pairs of the request set by query.
+func (p *DefaultProvider) describeKeyPairs(ctx context.Context, process string, query func(*cvm2017.DescribeKeyPairsRequest)) ([]*cvm2017.KeyPair, error) {
+	var keypairs []*cvm2017.KeyPair
+	for offset := int64(0); ; offset += describeLimit {
 		req := cvm2017.NewDescribeKeyPairsRequest()
-		req.Filters = append(req.Filters, filter...)
+		query(req)
+		req.Offset = lo.ToPtr(offset)
+		req.Limit = lo.ToPtr(int64(describeLimit))
+		apicache.RecordAPICall(req.GetAction())
 		resp, err := p.client.DescribeKeyPairs(req)
 		if err != nil {
 			return nil, fmt.Errorf("describe key pairs failed: %v", err)
 		}
-		log.FromContext(ctx).WithValues("process", "listsshkeyTag").V(1).Info("tencent cloud request", "action", req.GetAction(), "requestID", resp.Response.RequestId)
-		for _, keypair := range resp.Response.KeyPairSet {
-			keypairs[lo.FromPtr(keypair.KeyId)] = keypair
+		log.FromContext(ctx).WithValues("process", process).V(1).Info("tencent cloud request", "action", req.GetAction(), "requestID", resp.Response.RequestId)
+		keypairs = append(keypairs, resp.Response.KeyPairSet...)
+		if len(resp.Response.KeyPairSet) < describeLimit || offset+describeLimit >= lo.FromPtr(resp.Response.TotalCount) {
+			return keypairs, nil
 		}
 	}
+}
 
-	return lo.Values(keypairs), nil
+// filterSet is a DescribeKeyPairs query, term is the selector term the query is built from and
+// is checked against the key pairs returned.
+type filterSet struct {
+	filters []*cvm2017.Filter
+	term    *api.SSHKeySelectorTerm
 }
 
-func getFilterSets(terms []api.SSHKeySelectorTerm) (ids []*string, res [][]*cvm2017.Filter) {
-	for _, term := range terms {
+func getFilterSets(terms []api.SSHKeySelectorTerm) (ids []*string, res []filterSet) {
+	for i, term := range terms {
 		switch {
 		case term.ID != "":
 			ids = append(ids, lo.ToPtr(term.ID))
 		default:
 			var filters []*cvm2017.Filter
-			for k, v := range term.Tags {
+			// the filters are sorted by the tag key, so the filters of the same tags are cached once
+			keys := lo.Keys(term.Tags)
+			sort.Strings(keys)
+			for _, k := range keys {
+				v := term.Tags[k]
 				if v == "*" {
 					filters = append(filters, &cvm2017.Filter{
 						Name:   lo.ToPtr("tag-key"),
@@ -97,8 +145,26 @@ func getFilterSets(terms []api.SSHKeySelectorTerm) (ids []*string, res [][]*cvm2
 					})
 				}
 			}
-			res = append(res, filters)
+			if term.Name != "" {
+				filters = append(filters, &cvm2017.Filter{
+					Name:   lo.ToPtr("key-name"),
+					Values: []*string{lo.ToPtr(term.Name)},
+				})
+			}
+			res = append(res, filterSet{filters: filters, term: &terms[i]})
 		}
 	}
 	return ids, res
 }
+
+// matches checks the conditions of the term which DescribeKeyPairs can't filter by,
+// the key-name filter matches the name fuzzily.
+func matches(term *api.SSHKeySelectorTerm, keypair *cvm2017.KeyPair) bool {
+	if term.Name != "" && lo.FromPtr(keypair.KeyName) != term.Name {
+		return false
+	}
+	return !lo.SomeBy(keypair.Tags, func(tag *cvm2017.Tag) bool {
+		v, ok := term.ExcludeTags[lo.FromPtr(tag.Key)]
+		return ok && (v == "*" || v == lo.FromPtr(tag.Value))
+	})
+}
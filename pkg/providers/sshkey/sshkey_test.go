@@ -5,6 +5,7 @@ import (
 
 	"github.com/samber/lo"
 	api "github.com/tencentcloud/karpenter-provider-tke/pkg/apis/v1beta1"
+	cvm2017 "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/cvm/v20170312"
 )
 
 func TestGetFilterSets_IDFilter(t *testing.T) {
@@ -38,10 +39,10 @@ func TestGetFilterSets_TagFilter(t *testing.T) {
 	if len(filterSets) != 1 {
 		t.Fatalf("expected 1 filter set, got %d", len(filterSets))
 	}
-	if len(filterSets[0]) != 1 {
-		t.Fatalf("expected 1 filter in set, got %d", len(filterSets[0]))
+	if len(filterSets[0].filters) != 1 {
+		t.Fatalf("expected 1 filter in set, got %d", len(filterSets[0].filters))
 	}
-	filter := filterSets[0][0]
+	filter := filterSets[0].filters[0]
 	if lo.FromPtr(filter.Name) != "tag:env" {
 		t.Errorf("expected filter name 'tag:env', got %q", lo.FromPtr(filter.Name))
 	}
@@ -61,7 +62,7 @@ func TestGetFilterSets_TagWildcard(t *testing.T) {
 	if len(filterSets) != 1 {
 		t.Fatalf("expected 1 filter set, got %d", len(filterSets))
 	}
-	filter := filterSets[0][0]
+	filter := filterSets[0].filters[0]
 	if lo.FromPtr(filter.Name) != "tag-key" {
 		t.Errorf("expected filter name 'tag-key', got %q", lo.FromPtr(filter.Name))
 	}
@@ -106,7 +107,40 @@ func TestGetFilterSets_MultipleTags(t *testing.T) {
 	if len(filterSets) != 1 {
 		t.Fatalf("expected 1 filter set, got %d", len(filterSets))
 	}
-	if len(filterSets[0]) != 2 {
-		t.Errorf("expected 2 filters for 2 tags, got %d", len(filterSets[0]))
+	if len(filterSets[0].filters) != 2 {
+		t.Errorf("expected 2 filters for 2 tags, got %d", len(filterSets[0].filters))
+	}
+}
+
+func TestGetFilterSets_Name(t *testing.T) {
+	terms := []api.SSHKeySelectorTerm{
+		{Name: "ops"},
+	}
+	_, filterSets := getFilterSets(terms)
+	if len(filterSets) != 1 {
+		t.Fatalf("expected 1 filter set, got %d", len(filterSets))
+	}
+	filter := filterSets[0].filters[0]
+	if lo.FromPtr(filter.Name) != "key-name" || lo.FromPtr(filter.Values[0]) != "ops" {
+		t.Errorf("expected filter key-name=ops, got %s=%s", lo.FromPtr(filter.Name), lo.FromPtr(filter.Values[0]))
+	}
+}
+
+func TestMatches(t *testing.T) {
+	keypair := &cvm2017.KeyPair{
+		KeyName: lo.ToPtr("ops"),
+		Tags:    []*cvm2017.Tag{{Key: lo.ToPtr("owner"), Value: lo.ToPtr("alice")}},
+	}
+	if !matches(&api.SSHKeySelectorTerm{Name: "ops"}, keypair) {
+		t.Errorf("expected the key pair to match its name")
+	}
+	if matches(&api.SSHKeySelectorTerm{Name: "op"}, keypair) {
+		t.Errorf("expected the key pair not to match a part of its name")
+	}
+	if matches(&api.SSHKeySelectorTerm{Tags: map[string]string{"team": "*"}, ExcludeTags: map[string]string{"owner": "alice"}}, keypair) {
+		t.Errorf("expected the key pair to be excluded by its tag")
+	}
+	if !matches(&api.SSHKeySelectorTerm{Tags: map[string]string{"team": "*"}, ExcludeTags: map[string]string{"owner": "bob"}}, keypair) {
+		t.Errorf("expected the key pair not to be excluded by another tag value")
 	}
 }
@@ -0,0 +1,336 @@
+/*
+Copyright (C) 2012-2025 Tencent. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/patrickmn/go-cache"
+	"github.com/samber/lo"
+	api "github.com/tencentcloud/karpenter-provider-tke/pkg/apis/v1beta1"
+	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common"
+	tcerr "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common/errors"
+	tchttp "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common/http"
+	vpc2017 "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/vpc/v20170312"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	// inquiryCBSConfig inquires the disk types sold in the zones
+	inquiryCBSConfig       = "INQUIRY_CBS_CONFIG"
+	diskChargeTypePostpaid = "POSTPAID_BY_HOUR"
+	diskChargeTypePrepaid  = "PREPAID"
+	diskUsageSystemDisk    = "SYSTEM_DISK"
+	diskUsageDataDisk      = "DATA_DISK"
+
+	kmsKeyStateEnabled = "Enabled"
+	kmsKeyNotFoundCode = "ResourceUnavailable.CmkNotFound"
+)
+
+// cbsDiskTypes maps the disk types of the nodeClass to the disk types of CBS
+var cbsDiskTypes = map[api.DiskType]string{
+	api.DiskTypeCloudPremium: "CLOUD_PREMIUM",
+	api.DiskTypeCloudSSD:     "CLOUD_SSD",
+	api.DiskTypeCloudHSSD:    "CLOUD_HSSD",
+	api.DiskTypeTSSD:         "CLOUD_TSSD",
+	api.DiskTypeBSSD:         "CLOUD_BSSD",
+}
+
+// Provider checks the launch parameters of the nodeClass against the cloud APIs. Each method returns the reason why
+// the parameters can't be used to launch nodes, or an empty string if they can. An error is returned only when the
+// check itself failed.
+type Provider interface {
+	ValidateDiskTypes(context.Context, *api.TKEMachineNodeClass) (string, error)
+	ValidateKMSKeys(context.Context, *api.TKEMachineNodeClass) (string, error)
+	ValidateBandwidthPackage(context.Context, *api.TKEMachineNodeClass) (string, error)
+}
+
+type DefaultProvider struct {
+	vpcClient    *vpc2017.Client
+	commonClient *common.Client
+	cache        *cache.Cache
+}
+
+func NewDefaultProvider(_ context.Context, vpcClient *vpc2017.Client, commonClient *common.Client, cache *cache.Cache) *DefaultProvider {
+	return &DefaultProvider{
+		vpcClient:    vpcClient,
+		commonClient: commonClient,
+		cache:        cache,
+	}
+}
+
+// diskRequirement is a disk type which must be sold in a zone
+type diskRequirement struct {
+	Zone      string
+	DiskType  string
+	DiskUsage string
+}
+
+func (d diskRequirement) String() string {
+	return fmt.Sprintf("%s %s in %s", d.DiskType, strings.ToLower(strings.ReplaceAll(d.DiskUsage, "_", " ")), d.Zone)
+}
+
+// diskRequirements returns the disks of the nodeClass in every zone of its subnets
+func diskRequirements(nodeClass *api.TKEMachineNodeClass) []diskRequirement {
+	systemDiskType := api.DiskTypeCloudPremium
+	if nodeClass.Spec.SystemDisk != nil && nodeClass.Spec.SystemDisk.Type != "" {
+		systemDiskType = nodeClass.Spec.SystemDisk.Type
+	}
+	disks := []lo.Tuple2[api.DiskType, string]{lo.T2(systemDiskType, diskUsageSystemDisk)}
+	for _, d := range nodeClass.Spec.DataDisks {
+		disks = append(disks, lo.T2(d.Type, diskUsageDataDisk))
+	}
+	var res []diskRequirement
+	for _, zone := range lo.Uniq(lo.Map(nodeClass.Status.Subnets, func(s api.Subnet, _ int) string { return s.Zone })) {
+		for _, d := range disks {
+			if diskType, ok := cbsDiskTypes[d.A]; ok {
+				res = append(res, diskRequirement{Zone: zone, DiskType: diskType, DiskUsage: d.B})
+			}
+		}
+	}
+	return lo.Uniq(res)
+}
+
+// diskChargeType returns the charge type the disks of the nodeClass are bought with
+func diskChargeType(nodeClass *api.TKEMachineNodeClass) string {
+	return lo.Ternary(nodeClass.Spec.Prepaid != nil, diskChargeTypePrepaid, diskChargeTypePostpaid)
+}
+
+type DescribeDiskConfigQuotaRequest struct {
+	InquiryType    string   `json:"InquiryType"`
+	Zones          []string `json:"Zones"`
+	DiskChargeType string   `json:"DiskChargeType"`
+	DiskTypes      []string `json:"DiskTypes"`
+	DiskUsage      string   `json:"DiskUsage"`
+}
+
+type DiskConfig struct {
+	Available bool   `json:"Available"`
+	Zone      string `json:"Zone"`
+	DiskType  string `json:"DiskType"`
+	DiskUsage string `json:"DiskUsage"`
+}
+
+type DescribeDiskConfigQuotaResponse struct {
+	Response *struct {
+		DiskConfigSet []DiskConfig `json:"DiskConfigSet"`
+		RequestId     *string      `json:"RequestId"`
+	} `json:"Response"`
+}
+
+// ValidateDiskTypes checks that the system disk and data disk types are sold in all the zones of the subnets.
+func (p *DefaultProvider) ValidateDiskTypes(ctx context.Context, nodeClass *api.TKEMachineNodeClass) (string, error) {
+	requirements := diskRequirements(nodeClass)
+	if len(requirements) == 0 {
+		return "", nil
+	}
+	chargeType := diskChargeType(nodeClass)
+	key := fmt.Sprintf("disk-types:%s:%s", chargeType, strings.Join(lo.Map(requirements, func(d diskRequirement, _ int) string { return d.String() }), ","))
+	if reason, ok := p.cache.Get(key); ok {
+		return reason.(string), nil
+	}
+	var configs []DiskConfig
+	for usage, reqs := range lo.GroupBy(requirements, func(d diskRequirement) string { return d.DiskUsage }) {
+		resp, err := p.describeDiskConfigQuota(ctx, DescribeDiskConfigQuotaRequest{
+			InquiryType:    inquiryCBSConfig,
+			Zones:          lo.Uniq(lo.Map(reqs, func(d diskRequirement, _ int) string { return d.Zone })),
+			DiskChargeType: chargeType,
+			DiskTypes:      lo.Uniq(lo.Map(reqs, func(d diskRequirement, _ int) string { return d.DiskType })),
+			DiskUsage:      usage,
+		})
+		if err != nil {
+			return "", err
+		}
+		configs = append(configs, resp...)
+	}
+	reason := ""
+	if unavailable := unavailableDisks(requirements, configs); len(unavailable) != 0 {
+		reason = fmt.Sprintf("disk types are not sold: %s", strings.Join(lo.Map(unavailable, func(d diskRequirement, _ int) string { return d.String() }), ", "))
+	}
+	p.cache.SetDefault(key, reason)
+	return reason, nil
+}
+
+// unavailableDisks returns the required disks which have no available config
+func unavailableDisks(requirements []diskRequirement, configs []DiskConfig) []diskRequirement {
+	available := lo.SliceToMap(lo.Filter(configs, func(c DiskConfig, _ int) bool { return c.Available }), func(c DiskConfig) (diskRequirement, struct{}) {
+		return diskRequirement{Zone: c.Zone, DiskType: c.DiskType, DiskUsage: c.DiskUsage}, struct{}{}
+	})
+	res := lo.Filter(requirements, func(d diskRequirement, _ int) bool {
+		_, ok := available[d]
+		return !ok
+	})
+	sort.Slice(res, func(i, j int) bool { return res[i].String() < res[j].String() })
+	return res
+}
+
+func (p *DefaultProvider) describeDiskConfigQuota(ctx context.Context, request DescribeDiskConfigQuotaRequest) ([]DiskConfig, error) {
+	commonRequest := tchttp.NewCommonRequest("cbs", "2017-03-12", "DescribeDiskConfigQuota")
+	params, _ := json.Marshal(request)
+	if err := commonRequest.SetActionParameters(string(params)); err != nil {
+		return nil, fmt.Errorf("set parameters failed: %v", err)
+	}
+	commonResponse := tchttp.NewCommonResponse()
+	if err := p.commonClient.Send(commonRequest, commonResponse); err != nil {
+		return nil, fmt.Errorf("describe disk config quota failed: %v", err)
+	}
+	if err := commonResponse.ParseErrorFromHTTPResponse(commonResponse.GetBody()); err != nil {
+		return nil, fmt.Errorf("describe disk config quota failed: %v", err)
+	}
+	response := DescribeDiskConfigQuotaResponse{}
+	if err := json.Unmarshal(commonResponse.GetBody(), &response); err != nil {
+		return nil, fmt.Errorf("unmarshal disk configs failed: %v", err)
+	}
+	if response.Response == nil {
+		return nil, fmt.Errorf("invaild response: %s", commonResponse.GetBody())
+	}
+	log.FromContext(ctx).WithValues("process", "validatedisktypes").V(1).Info("tencent cloud request", "action", "DescribeDiskConfigQuota", "requestID", response.Response.RequestId)
+	return response.Response.DiskConfigSet, nil
+}
+
+type DescribeKeyRequest struct {
+	KeyId string `json:"KeyId"`
+}
+
+type DescribeKeyResponse struct {
+	Response *struct {
+		KeyMetadata *struct {
+			KeyId    string `json:"KeyId"`
+			KeyState string `json:"KeyState"`
+		} `json:"KeyMetadata"`
+		RequestId *string `json:"RequestId"`
+	} `json:"Response"`
+}
+
+// kmsKeyIDs returns the KMS keys used to encrypt the disks of the nodeClass, including the ones set with the
+// deprecated disk annotations
+func kmsKeyIDs(nodeClass *api.TKEMachineNodeClass) []string {
+	annotations := nodeClass.GetAnnotations()
+	systemDiskID := annotations[api.AnnotationSystemDiskKMSID]
+	if nodeClass.Spec.SystemDisk != nil {
+		systemDiskID = diskKMSKeyID(systemDiskID, nodeClass.Spec.SystemDisk.Encrypted, nodeClass.Spec.SystemDisk.KMSKeyID)
+	}
+	ids := []string{systemDiskID}
+	dataDisksIDs := indexedAnnotation(annotations[api.AnnotationDataDisksKMSID])
+	for i, d := range nodeClass.Spec.DataDisks {
+		ids = append(ids, diskKMSKeyID(dataDisksIDs[strconv.Itoa(i)], d.Encrypted, d.KMSKeyID))
+	}
+	return lo.Compact(lo.Uniq(ids))
+}
+
+// diskKMSKeyID returns the KMS key of a disk, the typed fields take precedence over the deprecated annotation
+func diskKMSKeyID(annotation string, encrypted *bool, kmsKeyID *string) string {
+	if encrypted != nil && !lo.FromPtr(encrypted) {
+		return ""
+	}
+	if lo.FromPtr(kmsKeyID) != "" {
+		return lo.FromPtr(kmsKeyID)
+	}
+	return annotation
+}
+
+// indexedAnnotation parses the annotation value of the form "0=value0,1=value1" by disk index
+func indexedAnnotation(value string) map[string]string {
+	res := map[string]string{}
+	for _, kv := range strings.Split(value, ",") {
+		if split := strings.SplitN(kv, "=", 2); len(split) == 2 {
+			res[split[0]] = split[1]
+		}
+	}
+	return res
+}
+
+// ValidateKMSKeys checks that the KMS keys used to encrypt the disks exist and are enabled.
+func (p *DefaultProvider) ValidateKMSKeys(ctx context.Context, nodeClass *api.TKEMachineNodeClass) (string, error) {
+	for _, id := range kmsKeyIDs(nodeClass) {
+		key := fmt.Sprintf("kms-key:%s", id)
+		reason, ok := p.cache.Get(key)
+		if !ok {
+			state, err := p.describeKeyState(ctx, id)
+			if err != nil {
+				return "", err
+			}
+			reason = lo.Ternary(state == kmsKeyStateEnabled, "", fmt.Sprintf("kms key %s is %s", id, strings.ToLower(state)))
+			p.cache.SetDefault(key, reason)
+		}
+		if reason.(string) != "" {
+			return reason.(string), nil
+		}
+	}
+	return "", nil
+}
+
+// describeKeyState returns the state of the KMS key, NotFound if the key doesn't exist
+func (p *DefaultProvider) describeKeyState(ctx context.Context, id string) (string, error) {
+	commonRequest := tchttp.NewCommonRequest("kms", "2019-01-18", "DescribeKey")
+	params, _ := json.Marshal(DescribeKeyRequest{KeyId: id})
+	if err := commonRequest.SetActionParameters(string(params)); err != nil {
+		return "", fmt.Errorf("set parameters failed: %v", err)
+	}
+	commonResponse := tchttp.NewCommonResponse()
+	if err := p.commonClient.Send(commonRequest, commonResponse); err != nil {
+		return "", fmt.Errorf("describe key failed: %v", err)
+	}
+	if err := commonResponse.ParseErrorFromHTTPResponse(commonResponse.GetBody()); err != nil {
+		var sdkErr *tcerr.TencentCloudSDKError
+		if errors.As(err, &sdkErr) && sdkErr.GetCode() == kmsKeyNotFoundCode {
+			return "NotFound", nil
+		}
+		return "", fmt.Errorf("describe key failed: %v", err)
+	}
+	response := DescribeKeyResponse{}
+	if err := json.Unmarshal(commonResponse.GetBody(), &response); err != nil {
+		return "", fmt.Errorf("unmarshal key failed: %v", err)
+	}
+	if response.Response == nil || response.Response.KeyMetadata == nil {
+		return "", fmt.Errorf("invaild response: %s", commonResponse.GetBody())
+	}
+	log.FromContext(ctx).WithValues("process", "validatekmskeys").V(1).Info("tencent cloud request", "action", "DescribeKey", "requestID", response.Response.RequestId)
+	return response.Response.KeyMetadata.KeyState, nil
+}
+
+// ValidateBandwidthPackage checks that the bandwidth package of the public network exists.
+func (p *DefaultProvider) ValidateBandwidthPackage(ctx context.Context, nodeClass *api.TKEMachineNodeClass) (string, error) {
+	if nodeClass.Spec.InternetAccessible == nil || lo.FromPtr(nodeClass.Spec.InternetAccessible.BandwidthPackageID) == "" {
+		return "", nil
+	}
+	id := lo.FromPtr(nodeClass.Spec.InternetAccessible.BandwidthPackageID)
+	key := fmt.Sprintf("bandwidth-package:%s", id)
+	if reason, ok := p.cache.Get(key); ok {
+		return reason.(string), nil
+	}
+	req := vpc2017.NewDescribeBandwidthPackagesRequest()
+	req.BandwidthPackageIds = []*string{lo.ToPtr(id)}
+	resp, err := p.vpcClient.DescribeBandwidthPackages(req)
+	var sdkErr *tcerr.TencentCloudSDKError
+	if err != nil && !(errors.As(err, &sdkErr) && strings.HasSuffix(sdkErr.GetCode(), "NotFound")) {
+		return "", fmt.Errorf("describe bandwidth packages failed: %v", err)
+	}
+	reason := fmt.Sprintf("bandwidth package %s is not found", id)
+	if err == nil {
+		log.FromContext(ctx).WithValues("process", "validatebandwidthpackage").V(1).Info("tencent cloud request", "action", req.GetAction(), "requestID", resp.Response.RequestId)
+		reason = lo.Ternary(len(resp.Response.BandwidthPackageSet) == 0, reason, "")
+	}
+	p.cache.SetDefault(key, reason)
+	return reason, nil
+}
@@ -0,0 +1,158 @@
+package validation
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/patrickmn/go-cache"
+	"github.com/samber/lo"
+	api "github.com/tencentcloud/karpenter-provider-tke/pkg/apis/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestDiskRequirements(t *testing.T) {
+	nodeClass := &api.TKEMachineNodeClass{
+		Spec: api.TKEMachineNodeClassSpec{
+			DataDisks: []api.DataDisk{{Type: api.DiskTypeCloudSSD}, {Type: api.DiskTypeCloudSSD}},
+		},
+		Status: api.TKEMachineNodeClassStatus{
+			Subnets: []api.Subnet{
+				{ID: "subnet-1", Zone: "ap-guangzhou-3"},
+				{ID: "subnet-2", Zone: "ap-guangzhou-3"},
+				{ID: "subnet-3", Zone: "ap-guangzhou-4"},
+			},
+		},
+	}
+	got := diskRequirements(nodeClass)
+	want := []diskRequirement{
+		{Zone: "ap-guangzhou-3", DiskType: "CLOUD_PREMIUM", DiskUsage: diskUsageSystemDisk},
+		{Zone: "ap-guangzhou-3", DiskType: "CLOUD_SSD", DiskUsage: diskUsageDataDisk},
+		{Zone: "ap-guangzhou-4", DiskType: "CLOUD_PREMIUM", DiskUsage: diskUsageSystemDisk},
+		{Zone: "ap-guangzhou-4", DiskType: "CLOUD_SSD", DiskUsage: diskUsageDataDisk},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	for _, w := range want {
+		if !lo.Contains(got, w) {
+			t.Errorf("expected %v in %v", w, got)
+		}
+	}
+}
+
+func TestDiskRequirements_SystemDiskType(t *testing.T) {
+	nodeClass := &api.TKEMachineNodeClass{
+		Spec: api.TKEMachineNodeClassSpec{
+			SystemDisk: &api.SystemDisk{Type: api.DiskTypeCloudHSSD},
+		},
+		Status: api.TKEMachineNodeClassStatus{
+			Subnets: []api.Subnet{{ID: "subnet-1", Zone: "ap-guangzhou-3"}},
+		},
+	}
+	got := diskRequirements(nodeClass)
+	if len(got) != 1 || got[0].DiskType != "CLOUD_HSSD" {
+		t.Errorf("expected the CLOUD_HSSD system disk, got %v", got)
+	}
+}
+
+func TestUnavailableDisks(t *testing.T) {
+	requirements := []diskRequirement{
+		{Zone: "ap-guangzhou-3", DiskType: "CLOUD_SSD", DiskUsage: diskUsageDataDisk},
+		{Zone: "ap-guangzhou-4", DiskType: "CLOUD_SSD", DiskUsage: diskUsageDataDisk},
+		{Zone: "ap-guangzhou-6", DiskType: "CLOUD_SSD", DiskUsage: diskUsageDataDisk},
+	}
+	configs := []DiskConfig{
+		{Available: true, Zone: "ap-guangzhou-3", DiskType: "CLOUD_SSD", DiskUsage: diskUsageDataDisk},
+		{Available: false, Zone: "ap-guangzhou-4", DiskType: "CLOUD_SSD", DiskUsage: diskUsageDataDisk},
+	}
+	got := unavailableDisks(requirements, configs)
+	if len(got) != 2 || got[0].Zone != "ap-guangzhou-4" || got[1].Zone != "ap-guangzhou-6" {
+		t.Errorf("expected the disks in ap-guangzhou-4 and ap-guangzhou-6, got %v", got)
+	}
+	if got[0].String() != "CLOUD_SSD data disk in ap-guangzhou-4" {
+		t.Errorf("unexpected string %q", got[0].String())
+	}
+}
+
+func TestKMSKeyIDs(t *testing.T) {
+	nodeClass := &api.TKEMachineNodeClass{
+		Spec: api.TKEMachineNodeClassSpec{
+			SystemDisk: &api.SystemDisk{KMSKeyID: lo.ToPtr("key-1")},
+			DataDisks:  []api.DataDisk{{KMSKeyID: lo.ToPtr("key-1")}, {KMSKeyID: lo.ToPtr("key-2")}, {}},
+		},
+	}
+	got := kmsKeyIDs(nodeClass)
+	if len(got) != 2 || got[0] != "key-1" || got[1] != "key-2" {
+		t.Errorf("expected [key-1 key-2], got %v", got)
+	}
+}
+
+func TestKMSKeyIDs_Annotations(t *testing.T) {
+	nodeClass := &api.TKEMachineNodeClass{
+		ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{
+			api.AnnotationSystemDiskKMSID: "key-1",
+			api.AnnotationDataDisksKMSID:  "0=key-2,1=key-3,2=key-4,3=key-5",
+		}},
+		Spec: api.TKEMachineNodeClassSpec{
+			DataDisks: []api.DataDisk{{}, {KMSKeyID: lo.ToPtr("key-6")}, {Encrypted: lo.ToPtr(false)}},
+		},
+	}
+	got := kmsKeyIDs(nodeClass)
+	want := []string{"key-1", "key-2", "key-6"}
+	if len(got) != len(want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("expected %v, got %v", want, got)
+		}
+	}
+}
+
+func TestDiskChargeType(t *testing.T) {
+	nodeClass := &api.TKEMachineNodeClass{}
+	if got := diskChargeType(nodeClass); got != diskChargeTypePostpaid {
+		t.Errorf("expected %s, got %s", diskChargeTypePostpaid, got)
+	}
+	nodeClass.Spec.Prepaid = &api.Prepaid{}
+	if got := diskChargeType(nodeClass); got != diskChargeTypePrepaid {
+		t.Errorf("expected %s, got %s", diskChargeTypePrepaid, got)
+	}
+}
+
+func TestValidate_NothingToCheck(t *testing.T) {
+	p := NewDefaultProvider(context.Background(), nil, nil, cache.New(time.Minute, time.Minute))
+	nodeClass := &api.TKEMachineNodeClass{}
+	for name, validate := range map[string]func(context.Context, *api.TKEMachineNodeClass) (string, error){
+		"disk types":        p.ValidateDiskTypes,
+		"kms keys":          p.ValidateKMSKeys,
+		"bandwidth package": p.ValidateBandwidthPackage,
+	} {
+		reason, err := validate(context.Background(), nodeClass)
+		if err != nil || reason != "" {
+			t.Errorf("%s: expected no reason and no error, got %q, %v", name, reason, err)
+		}
+	}
+}
+
+func TestValidate_Cached(t *testing.T) {
+	c := cache.New(time.Minute, time.Minute)
+	c.SetDefault("kms-key:key-1", "kms key key-1 is disabled")
+	c.SetDefault("bandwidth-package:bwp-1", "")
+	p := NewDefaultProvider(context.Background(), nil, nil, c)
+	nodeClass := &api.TKEMachineNodeClass{
+		Spec: api.TKEMachineNodeClassSpec{
+			SystemDisk:         &api.SystemDisk{KMSKeyID: lo.ToPtr("key-1")},
+			InternetAccessible: &api.InternetAccessible{BandwidthPackageID: lo.ToPtr("bwp-1")},
+		},
+	}
+	reason, err := p.ValidateKMSKeys(context.Background(), nodeClass)
+	if err != nil || reason != "kms key key-1 is disabled" {
+		t.Errorf("expected the cached kms key reason, got %q, %v", reason, err)
+	}
+	reason, err = p.ValidateBandwidthPackage(context.Background(), nodeClass)
+	if err != nil || reason != "" {
+		t.Errorf("expected the cached bandwidth package result, got %q, %v", reason, err)
+	}
+}
@@ -0,0 +1,88 @@
+/*
+Copyright (C) 2012-2025 Tencent. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package version
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/blang/semver/v4"
+	"github.com/patrickmn/go-cache"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	kubernetesVersionCacheKey = "kubernetes-version"
+	// maxKubeletMinorVersionSkew is the number of minor versions the kubelet can be older than the control plane
+	maxKubeletMinorVersionSkew = 3
+)
+
+type Provider interface {
+	Get(context.Context) (semver.Version, error)
+}
+
+type DefaultProvider struct {
+	kubernetesInterface kubernetes.Interface
+	cache               *cache.Cache
+}
+
+func NewDefaultProvider(_ context.Context, kubernetesInterface kubernetes.Interface, cache *cache.Cache) *DefaultProvider {
+	return &DefaultProvider{
+		kubernetesInterface: kubernetesInterface,
+		cache:               cache,
+	}
+}
+
+// Get returns the version of the control plane.
+func (p *DefaultProvider) Get(ctx context.Context) (semver.Version, error) {
+	if version, ok := p.cache.Get(kubernetesVersionCacheKey); ok {
+		return version.(semver.Version), nil
+	}
+	serverVersion, err := p.kubernetesInterface.Discovery().ServerVersion()
+	if err != nil {
+		return semver.Version{}, fmt.Errorf("get server version failed: %v", err)
+	}
+	version, err := Parse(serverVersion.GitVersion)
+	if err != nil {
+		return semver.Version{}, fmt.Errorf("parse server version failed: %v", err)
+	}
+	p.cache.SetDefault(kubernetesVersionCacheKey, version)
+	log.FromContext(ctx).WithValues("version", version.String()).V(1).Info("discovered kubernetes version")
+	return version, nil
+}
+
+// Parse parses a kubernetes version such as v1.30.0-tke.5, the leading "v" is optional.
+func Parse(version string) (semver.Version, error) {
+	return semver.Make(strings.TrimPrefix(version, "v"))
+}
+
+// ValidateKubeletVersion checks the kubelet version against the version skew policy of kubernetes: the kubelet must
+// not be newer than the control plane, and can be at most 3 minor versions older.
+func ValidateKubeletVersion(kubelet, controlPlane semver.Version) error {
+	if kubelet.Major != controlPlane.Major {
+		return fmt.Errorf("kubelet version %s doesn't have the major version of the control plane %s", kubelet, controlPlane)
+	}
+	if kubelet.Minor > controlPlane.Minor {
+		return fmt.Errorf("kubelet version %s is newer than the control plane %s", kubelet, controlPlane)
+	}
+	if kubelet.Minor+maxKubeletMinorVersionSkew < controlPlane.Minor {
+		return fmt.Errorf("kubelet version %s is more than %d minor versions older than the control plane %s", kubelet, maxKubeletMinorVersionSkew, controlPlane)
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package version
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/blang/semver/v4"
+	"github.com/patrickmn/go-cache"
+	k8sversion "k8s.io/apimachinery/pkg/version"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestGet(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	clientset.Discovery().(*fakediscovery.FakeDiscovery).FakedServerVersion = &k8sversion.Info{GitVersion: "v1.30.0-tke.5"}
+	p := NewDefaultProvider(context.Background(), clientset, cache.New(time.Minute, time.Minute))
+	version, err := p.Get(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if version.Major != 1 || version.Minor != 30 || version.Patch != 0 {
+		t.Errorf("expected 1.30.0, got %s", version)
+	}
+
+	// the version is cached
+	clientset.Discovery().(*fakediscovery.FakeDiscovery).FakedServerVersion = &k8sversion.Info{GitVersion: "v1.31.0"}
+	version, err = p.Get(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if version.Minor != 30 {
+		t.Errorf("expected cached version 1.30.0, got %s", version)
+	}
+}
+
+func TestValidateKubeletVersion(t *testing.T) {
+	controlPlane := semver.MustParse("1.30.2-tke.1")
+	tests := []struct {
+		kubelet string
+		valid   bool
+	}{
+		{kubelet: "1.30.0", valid: true},
+		{kubelet: "1.30.5", valid: true},
+		{kubelet: "1.27.0", valid: true},
+		{kubelet: "1.26.9", valid: false},
+		{kubelet: "1.31.0", valid: false},
+		{kubelet: "2.30.0", valid: false},
+	}
+	for _, tt := range tests {
+		err := ValidateKubeletVersion(semver.MustParse(tt.kubelet), controlPlane)
+		if (err == nil) != tt.valid {
+			t.Errorf("kubelet %s: expected valid %t, got error %v", tt.kubelet, tt.valid, err)
+		}
+	}
+}
@@ -19,13 +19,18 @@ package vpc
 import (
 	"context"
 	"fmt"
+	"strconv"
 
 	"github.com/samber/lo"
+	"github.com/tencentcloud/karpenter-provider-tke/pkg/apicache"
 	api "github.com/tencentcloud/karpenter-provider-tke/pkg/apis/v1beta1"
 	vpc2017 "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/vpc/v20170312"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 )
 
+// MaxSecurityGroups is the max number of security groups bound to an instance
+const MaxSecurityGroups = 5
+
 func (p *DefaultProvider) ListSecurityGroups(ctx context.Context, nodeClass *api.TKEMachineNodeClass) ([]*vpc2017.SecurityGroup, error) {
 	filterSets := getSGFilterSets(nodeClass.Spec.SecurityGroupSelectorTerms)
 	if len(filterSets) == 0 {
@@ -33,48 +38,106 @@ func (p *DefaultProvider) ListSecurityGroups(ctx context.Context, nodeClass *api
 	}
 
 	sgs := map[string]*vpc2017.SecurityGroup{}
-	for _, filter := range filterSets {
+	for _, filterSet := range filterSets {
+		described, err := apicache.GetOrLoad(p.cache, "DescribeSecurityGroups", cacheKey(filterSet.filters), func() ([]*vpc2017.SecurityGroup, error) {
+			return p.describeSecurityGroups(ctx, filterSet.filters)
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, sg := range described {
+			if filterSet.term != nil && !sgMatches(filterSet.term, sg) {
+				continue
+			}
+			sgs[lo.FromPtr(sg.SecurityGroupId)] = sg
+		}
+	}
+
+	return lo.Values(sgs), nil
+}
+
+// describeSecurityGroups returns all the pages of the security groups matching the filters.
+func (p *DefaultProvider) describeSecurityGroups(ctx context.Context, filters []*vpc2017.Filter) ([]*vpc2017.SecurityGroup, error) {
+	var sgs []*vpc2017.SecurityGroup
+	for offset := 0; ; offset += describeLimit {
 		req := vpc2017.NewDescribeSecurityGroupsRequest()
-		req.Filters = append(req.Filters, filter...)
+		req.Filters = filters
+		req.Offset = lo.ToPtr(strconv.Itoa(offset))
+		req.Limit = lo.ToPtr(strconv.Itoa(describeLimit))
+		apicache.RecordAPICall(req.GetAction())
 		resp, err := p.client.DescribeSecurityGroups(req)
 		if err != nil {
-			return nil, fmt.Errorf("describe subnets failed: %v", err)
+			return nil, fmt.Errorf("describe security groups failed: %v", err)
 		}
 		log.FromContext(ctx).WithValues("process", "listsg").V(1).Info("tencent cloud request", "action", req.GetAction(), "requestID", resp.Response.RequestId)
-		for _, sg := range resp.Response.SecurityGroupSet {
-			sgs[lo.FromPtr(sg.SecurityGroupId)] = sg
+		sgs = append(sgs, resp.Response.SecurityGroupSet...)
+		if len(resp.Response.SecurityGroupSet) < describeLimit || uint64(offset+describeLimit) >= lo.FromPtr(resp.Response.TotalCount) {
+			return sgs, nil
 		}
 	}
+}
 
-	return lo.Values(sgs), nil
+// sgFilterSet is a DescribeSecurityGroups query, term is the selector term the query is built from and
+// is checked against the security groups returned, it's nil for the query of security group ids.
+type sgFilterSet struct {
+	filters []*vpc2017.Filter
+	term    *api.SecurityGroupSelectorTerm
 }
 
-func getSGFilterSets(terms []api.SecurityGroupSelectorTerm) (res [][]*vpc2017.Filter) {
+func getSGFilterSets(terms []api.SecurityGroupSelectorTerm) (res []sgFilterSet) {
 	idFilter := &vpc2017.Filter{Name: lo.ToPtr("security-group-id")}
-	for _, term := range terms {
+	for i, term := range terms {
 		switch {
 		case term.ID != "":
 			idFilter.Values = append(idFilter.Values, lo.ToPtr(term.ID))
 		default:
-			var filters []*vpc2017.Filter
-			for k, v := range term.Tags {
-				if v == "*" {
-					filters = append(filters, &vpc2017.Filter{
-						Name:   lo.ToPtr("tag-key"),
-						Values: []*string{lo.ToPtr(k)},
-					})
-				} else {
-					filters = append(filters, &vpc2017.Filter{
-						Name:   lo.ToPtr(fmt.Sprintf("tag:%s", k)),
-						Values: []*string{lo.ToPtr(v)},
-					})
-				}
+			filters := tagFilters(term.Tags)
+			if term.Name != "" {
+				filters = append(filters, filter("security-group-name", term.Name))
 			}
-			res = append(res, filters)
+			res = append(res, sgFilterSet{filters: filters, term: &terms[i]})
 		}
 	}
 	if len(idFilter.Values) > 0 {
-		res = append(res, []*vpc2017.Filter{idFilter})
+		res = append(res, sgFilterSet{filters: []*vpc2017.Filter{idFilter}})
 	}
 	return res
 }
+
+// sgMatches checks the conditions of the term which DescribeSecurityGroups can't filter by,
+// the security-group-name filter matches the name fuzzily.
+func sgMatches(term *api.SecurityGroupSelectorTerm, sg *vpc2017.SecurityGroup) bool {
+	if term.Name != "" && lo.FromPtr(sg.SecurityGroupName) != term.Name {
+		return false
+	}
+	return !hasAnyTag(sg.TagSet, term.ExcludeTags)
+}
+
+// SecurityGroupPriority returns the lowest priority of the terms selecting the security group,
+// it's nil if none of the terms selecting the security group has a priority.
+func SecurityGroupPriority(terms []api.SecurityGroupSelectorTerm, sg *vpc2017.SecurityGroup) *int32 {
+	var priority *int32
+	for i := range terms {
+		term := &terms[i]
+		if term.Priority == nil || (priority != nil && *priority <= *term.Priority) {
+			continue
+		}
+		if sgSelected(term, sg) {
+			priority = term.Priority
+		}
+	}
+	return priority
+}
+
+// sgSelected returns whether the term selects the security group.
+func sgSelected(term *api.SecurityGroupSelectorTerm, sg *vpc2017.SecurityGroup) bool {
+	if term.ID != "" {
+		return term.ID == lo.FromPtr(sg.SecurityGroupId)
+	}
+	for k, v := range term.Tags {
+		if !hasAnyTag(sg.TagSet, map[string]string{k: v}) {
+			return false
+		}
+	}
+	return sgMatches(term, sg)
+}
@@ -5,6 +5,7 @@ import (
 
 	"github.com/samber/lo"
 	api "github.com/tencentcloud/karpenter-provider-tke/pkg/apis/v1beta1"
+	vpc2017 "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/vpc/v20170312"
 )
 
 func TestGetSGFilterSets_IDFilter(t *testing.T) {
@@ -17,7 +18,7 @@ func TestGetSGFilterSets_IDFilter(t *testing.T) {
 		t.Fatalf("expected 1 filter set (consolidated IDs), got %d", len(filterSets))
 	}
 	// The ID filter should contain both IDs
-	idFilter := filterSets[0][0]
+	idFilter := filterSets[0].filters[0]
 	if lo.FromPtr(idFilter.Name) != "security-group-id" {
 		t.Errorf("expected filter name 'security-group-id', got %q", lo.FromPtr(idFilter.Name))
 	}
@@ -34,7 +35,7 @@ func TestGetSGFilterSets_TagFilter(t *testing.T) {
 	if len(filterSets) != 1 {
 		t.Fatalf("expected 1 filter set, got %d", len(filterSets))
 	}
-	filter := filterSets[0][0]
+	filter := filterSets[0].filters[0]
 	if lo.FromPtr(filter.Name) != "tag:env" {
 		t.Errorf("expected filter name 'tag:env', got %q", lo.FromPtr(filter.Name))
 	}
@@ -48,7 +49,7 @@ func TestGetSGFilterSets_TagWildcard(t *testing.T) {
 	if len(filterSets) != 1 {
 		t.Fatalf("expected 1 filter set, got %d", len(filterSets))
 	}
-	filter := filterSets[0][0]
+	filter := filterSets[0].filters[0]
 	if lo.FromPtr(filter.Name) != "tag-key" {
 		t.Errorf("expected filter name 'tag-key', got %q", lo.FromPtr(filter.Name))
 	}
@@ -79,3 +80,68 @@ func TestGetSGFilterSets_EmptyTerms(t *testing.T) {
 		t.Errorf("expected 0 filter sets for empty terms, got %d", len(filterSets))
 	}
 }
+
+func TestGetSGFilterSets_Name(t *testing.T) {
+	terms := []api.SecurityGroupSelectorTerm{
+		{Name: "nodes", Tags: map[string]string{"env": "prod"}},
+	}
+	filterSets := getSGFilterSets(terms)
+	if len(filterSets) != 1 {
+		t.Fatalf("expected 1 filter set, got %d", len(filterSets))
+	}
+	if len(filterSets[0].filters) != 2 {
+		t.Fatalf("expected 2 filters, got %d", len(filterSets[0].filters))
+	}
+	if lo.FromPtr(filterSets[0].filters[1].Name) != "security-group-name" {
+		t.Errorf("expected filter name 'security-group-name', got %q", lo.FromPtr(filterSets[0].filters[1].Name))
+	}
+}
+
+func TestSGMatches(t *testing.T) {
+	sg := &vpc2017.SecurityGroup{
+		SecurityGroupName: lo.ToPtr("nodes"),
+		TagSet:            []*vpc2017.Tag{{Key: lo.ToPtr("deprecated"), Value: lo.ToPtr("true")}},
+	}
+	if !sgMatches(&api.SecurityGroupSelectorTerm{Name: "nodes"}, sg) {
+		t.Errorf("expected the security group to match its name")
+	}
+	if sgMatches(&api.SecurityGroupSelectorTerm{Name: "node"}, sg) {
+		t.Errorf("expected the security group not to match a part of its name")
+	}
+	if sgMatches(&api.SecurityGroupSelectorTerm{Name: "nodes", ExcludeTags: map[string]string{"deprecated": "*"}}, sg) {
+		t.Errorf("expected the security group to be excluded by its tag")
+	}
+}
+
+func TestSecurityGroupPriority(t *testing.T) {
+	sg := &vpc2017.SecurityGroup{
+		SecurityGroupId:   lo.ToPtr("sg-1"),
+		SecurityGroupName: lo.ToPtr("nodes"),
+		TagSet:            []*vpc2017.Tag{{Key: lo.ToPtr("env"), Value: lo.ToPtr("prod")}},
+	}
+	tests := []struct {
+		name  string
+		terms []api.SecurityGroupSelectorTerm
+		want  *int32
+	}{
+		{"no priority", []api.SecurityGroupSelectorTerm{{ID: "sg-1"}}, nil},
+		{"id", []api.SecurityGroupSelectorTerm{{ID: "sg-1", Priority: lo.ToPtr[int32](3)}}, lo.ToPtr[int32](3)},
+		{"other id", []api.SecurityGroupSelectorTerm{{ID: "sg-2", Priority: lo.ToPtr[int32](3)}}, nil},
+		{"tags", []api.SecurityGroupSelectorTerm{{Tags: map[string]string{"env": "*"}, Priority: lo.ToPtr[int32](2)}}, lo.ToPtr[int32](2)},
+		{"other tags", []api.SecurityGroupSelectorTerm{{Tags: map[string]string{"env": "test"}, Priority: lo.ToPtr[int32](2)}}, nil},
+		{"excluded", []api.SecurityGroupSelectorTerm{{Name: "nodes", ExcludeTags: map[string]string{"env": "prod"}, Priority: lo.ToPtr[int32](2)}}, nil},
+		{"lowest of the terms", []api.SecurityGroupSelectorTerm{
+			{Name: "nodes", Priority: lo.ToPtr[int32](5)},
+			{ID: "sg-1", Priority: lo.ToPtr[int32](1)},
+			{Tags: map[string]string{"env": "prod"}},
+		}, lo.ToPtr[int32](1)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := SecurityGroupPriority(tt.terms, sg)
+			if (got == nil) != (tt.want == nil) || (got != nil && *got != *tt.want) {
+				t.Errorf("SecurityGroupPriority() = %v, want %v", lo.FromPtr(got), lo.FromPtr(tt.want))
+			}
+		})
+	}
+}
@@ -19,8 +19,11 @@ package vpc
 import (
 	"context"
 	"fmt"
+	"net/netip"
+	"strconv"
 
 	"github.com/samber/lo"
+	"github.com/tencentcloud/karpenter-provider-tke/pkg/apicache"
 	api "github.com/tencentcloud/karpenter-provider-tke/pkg/apis/v1beta1"
 	vpc2017 "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/vpc/v20170312"
 	"sigs.k8s.io/controller-runtime/pkg/log"
@@ -31,53 +34,76 @@ func (p *DefaultProvider) ListSubnets(ctx context.Context, nodeClass *api.TKEMac
 	if len(filterSets) == 0 {
 		return []*vpc2017.Subnet{}, nil
 	}
-	vpcFilter := []*vpc2017.Filter{
-		{
-			Name:   lo.ToPtr("vpc-id"),
-			Values: []*string{lo.ToPtr(p.vpcID)},
-		},
-	}
+	vpcFilter := filter("vpc-id", p.vpcID)
 
 	subnets := map[string]*vpc2017.Subnet{}
-	for _, filter := range filterSets {
+	for _, filterSet := range filterSets {
+		filters := append([]*vpc2017.Filter{vpcFilter}, filterSet.filters...)
+		described, err := apicache.GetOrLoad(p.cache, "DescribeSubnets", cacheKey(filters), func() ([]*vpc2017.Subnet, error) {
+			return p.describeSubnets(ctx, filters)
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, subnet := range described {
+			if filterSet.term != nil && !subnetMatches(filterSet.term, subnet) {
+				continue
+			}
+			// the nodes of a dual-stack cluster can't be assigned an IPv6 address without an IPv6 CIDR
+			if p.dualStack && lo.FromPtr(subnet.Ipv6CidrBlock) == "" {
+				log.FromContext(ctx).V(1).Info("ignoring subnet without an IPv6 CIDR in the dual-stack cluster", "subnet", lo.FromPtr(subnet.SubnetId))
+				continue
+			}
+			subnets[lo.FromPtr(subnet.SubnetId)] = subnet
+		}
+	}
+
+	return lo.Values(subnets), nil
+}
+
+// describeSubnets returns all the pages of the subnets matching the filters.
+func (p *DefaultProvider) describeSubnets(ctx context.Context, filters []*vpc2017.Filter) ([]*vpc2017.Subnet, error) {
+	var subnets []*vpc2017.Subnet
+	for offset := 0; ; offset += describeLimit {
 		req := vpc2017.NewDescribeSubnetsRequest()
-		req.Filters = append(req.Filters, vpcFilter...)
-		req.Filters = append(req.Filters, filter...)
+		req.Filters = filters
+		req.Offset = lo.ToPtr(strconv.Itoa(offset))
+		req.Limit = lo.ToPtr(strconv.Itoa(describeLimit))
+		apicache.RecordAPICall(req.GetAction())
 		resp, err := p.client.DescribeSubnets(req)
 		if err != nil {
 			return nil, fmt.Errorf("describe subnets failed: %v", err)
 		}
 		log.FromContext(ctx).WithValues("process", "listsubnet").V(1).Info("tencent cloud request", "action", req.GetAction(), "requestID", resp.Response.RequestId)
-		for _, subnet := range resp.Response.SubnetSet {
-			subnets[lo.FromPtr(subnet.SubnetId)] = subnet
+		subnets = append(subnets, resp.Response.SubnetSet...)
+		if len(resp.Response.SubnetSet) < describeLimit || uint64(offset+describeLimit) >= lo.FromPtr(resp.Response.TotalCount) {
+			return subnets, nil
 		}
 	}
+}
 
-	return lo.Values(subnets), nil
+// subnetFilterSet is a DescribeSubnets query, term is the selector term the query is built from and
+// is checked against the subnets returned, it's nil for the queries of subnet ids.
+type subnetFilterSet struct {
+	filters []*vpc2017.Filter
+	term    *api.SubnetSelectorTerm
 }
 
-func getSubnetFilterSets(terms []api.SubnetSelectorTerm) (res [][]*vpc2017.Filter) {
+func getSubnetFilterSets(terms []api.SubnetSelectorTerm) (res []subnetFilterSet) {
 	idFilter := &vpc2017.Filter{Name: lo.ToPtr("subnet-id")}
-	for _, term := range terms {
+	for i, term := range terms {
 		switch {
 		case term.ID != "":
 			idFilter.Values = append(idFilter.Values, lo.ToPtr(term.ID))
 		default:
-			var filters []*vpc2017.Filter
-			for k, v := range term.Tags {
-				if v == "*" {
-					filters = append(filters, &vpc2017.Filter{
-						Name:   lo.ToPtr("tag-key"),
-						Values: []*string{lo.ToPtr(k)},
-					})
-				} else {
-					filters = append(filters, &vpc2017.Filter{
-						Name:   lo.ToPtr(fmt.Sprintf("tag:%s", k)),
-						Values: []*string{lo.ToPtr(v)},
-					})
-				}
+			filters := tagFilters(term.Tags)
+			if term.Name != "" {
+				filters = append(filters, filter("subnet-name", term.Name))
 			}
-			res = append(res, filters)
+			if term.Zone != "" {
+				filters = append(filters, filter("zone", term.Zone))
+			}
+			res = append(res, subnetFilterSet{filters: filters, term: &terms[i]})
 		}
 	}
 
@@ -94,8 +120,33 @@ func getSubnetFilterSets(terms []api.SubnetSelectorTerm) (res [][]*vpc2017.Filte
 				Name:   lo.ToPtr("subnet-id"),
 				Values: idFilter.Values[i:end],
 			}
-			res = append(res, []*vpc2017.Filter{batchFilter})
+			res = append(res, subnetFilterSet{filters: []*vpc2017.Filter{batchFilter}})
 		}
 	}
 	return res
 }
+
+// subnetMatches checks the conditions of the term which DescribeSubnets can't filter by,
+// the subnet-name filter matches the name fuzzily.
+func subnetMatches(term *api.SubnetSelectorTerm, subnet *vpc2017.Subnet) bool {
+	if term.Name != "" && lo.FromPtr(subnet.SubnetName) != term.Name {
+		return false
+	}
+	if term.CIDR != "" && !cidrContains(term.CIDR, lo.FromPtr(subnet.CidrBlock)) {
+		return false
+	}
+	return !hasAnyTag(subnet.TagSet, term.ExcludeTags)
+}
+
+// cidrContains returns whether the subnet CIDR block is contained in the CIDR.
+func cidrContains(cidr, block string) bool {
+	outer, err := netip.ParsePrefix(cidr)
+	if err != nil {
+		return false
+	}
+	inner, err := netip.ParsePrefix(block)
+	if err != nil {
+		return false
+	}
+	return outer.Bits() <= inner.Bits() && outer.Contains(inner.Addr())
+}
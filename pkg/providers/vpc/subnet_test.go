@@ -1,10 +1,12 @@
 package vpc
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/samber/lo"
 	api "github.com/tencentcloud/karpenter-provider-tke/pkg/apis/v1beta1"
+	vpc2017 "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/vpc/v20170312"
 )
 
 func TestGetSubnetFilterSets_IDFilter(t *testing.T) {
@@ -17,7 +19,7 @@ func TestGetSubnetFilterSets_IDFilter(t *testing.T) {
 	if len(filterSets) != 1 {
 		t.Fatalf("expected 1 filter set for 2 IDs, got %d", len(filterSets))
 	}
-	idFilter := filterSets[0][0]
+	idFilter := filterSets[0].filters[0]
 	if lo.FromPtr(idFilter.Name) != "subnet-id" {
 		t.Errorf("expected filter name 'subnet-id', got %q", lo.FromPtr(idFilter.Name))
 	}
@@ -42,12 +44,12 @@ func TestGetSubnetFilterSets_IDFilter_BatchSize(t *testing.T) {
 		t.Fatalf("expected 2 batches for 7 IDs, got %d", len(filterSets))
 	}
 	// First batch: 5 IDs
-	if len(filterSets[0][0].Values) != 5 {
-		t.Errorf("expected 5 IDs in first batch, got %d", len(filterSets[0][0].Values))
+	if len(filterSets[0].filters[0].Values) != 5 {
+		t.Errorf("expected 5 IDs in first batch, got %d", len(filterSets[0].filters[0].Values))
 	}
 	// Second batch: 2 IDs
-	if len(filterSets[1][0].Values) != 2 {
-		t.Errorf("expected 2 IDs in second batch, got %d", len(filterSets[1][0].Values))
+	if len(filterSets[1].filters[0].Values) != 2 {
+		t.Errorf("expected 2 IDs in second batch, got %d", len(filterSets[1].filters[0].Values))
 	}
 }
 
@@ -64,8 +66,8 @@ func TestGetSubnetFilterSets_IDFilter_ExactBatchSize(t *testing.T) {
 	if len(filterSets) != 1 {
 		t.Fatalf("expected 1 batch for 5 IDs, got %d", len(filterSets))
 	}
-	if len(filterSets[0][0].Values) != 5 {
-		t.Errorf("expected 5 IDs in batch, got %d", len(filterSets[0][0].Values))
+	if len(filterSets[0].filters[0].Values) != 5 {
+		t.Errorf("expected 5 IDs in batch, got %d", len(filterSets[0].filters[0].Values))
 	}
 }
 
@@ -77,7 +79,7 @@ func TestGetSubnetFilterSets_TagFilter(t *testing.T) {
 	if len(filterSets) != 1 {
 		t.Fatalf("expected 1 filter set, got %d", len(filterSets))
 	}
-	filter := filterSets[0][0]
+	filter := filterSets[0].filters[0]
 	if lo.FromPtr(filter.Name) != "tag:env" {
 		t.Errorf("expected filter name 'tag:env', got %q", lo.FromPtr(filter.Name))
 	}
@@ -91,7 +93,7 @@ func TestGetSubnetFilterSets_TagWildcard(t *testing.T) {
 	if len(filterSets) != 1 {
 		t.Fatalf("expected 1 filter set, got %d", len(filterSets))
 	}
-	filter := filterSets[0][0]
+	filter := filterSets[0].filters[0]
 	if lo.FromPtr(filter.Name) != "tag-key" {
 		t.Errorf("expected filter name 'tag-key', got %q", lo.FromPtr(filter.Name))
 	}
@@ -122,3 +124,64 @@ func TestGetSubnetFilterSets_EmptyTerms(t *testing.T) {
 		t.Errorf("expected 0 filter sets for empty terms, got %d", len(filterSets))
 	}
 }
+
+func TestGetSubnetFilterSets_NameAndZone(t *testing.T) {
+	terms := []api.SubnetSelectorTerm{
+		{Name: "legacy", Zone: "ap-guangzhou-3", CIDR: "10.0.0.0/16"},
+	}
+	filterSets := getSubnetFilterSets(terms)
+	if len(filterSets) != 1 {
+		t.Fatalf("expected 1 filter set, got %d", len(filterSets))
+	}
+	names := lo.Map(filterSets[0].filters, func(f *vpc2017.Filter, _ int) string { return lo.FromPtr(f.Name) })
+	if !lo.Every(names, []string{"subnet-name", "zone"}) || len(names) != 2 {
+		t.Errorf("expected filters 'subnet-name' and 'zone', got %v", names)
+	}
+	if filterSets[0].term == nil || filterSets[0].term.CIDR != "10.0.0.0/16" {
+		t.Errorf("expected the filter set to keep its term")
+	}
+}
+
+func TestSubnetMatches(t *testing.T) {
+	subnet := &vpc2017.Subnet{
+		SubnetName: lo.ToPtr("legacy-1"),
+		CidrBlock:  lo.ToPtr("10.0.8.0/24"),
+		TagSet:     []*vpc2017.Tag{{Key: lo.ToPtr("reserved"), Value: lo.ToPtr("db")}},
+	}
+	tests := []struct {
+		name string
+		term api.SubnetSelectorTerm
+		want bool
+	}{
+		{"exact name", api.SubnetSelectorTerm{Name: "legacy-1"}, true},
+		{"fuzzy name", api.SubnetSelectorTerm{Name: "legacy"}, false},
+		{"contained in CIDR", api.SubnetSelectorTerm{CIDR: "10.0.0.0/16"}, true},
+		{"same CIDR", api.SubnetSelectorTerm{CIDR: "10.0.8.0/24"}, true},
+		{"larger than CIDR", api.SubnetSelectorTerm{CIDR: "10.0.8.0/25"}, false},
+		{"outside CIDR", api.SubnetSelectorTerm{CIDR: "10.1.0.0/16"}, false},
+		{"invalid CIDR", api.SubnetSelectorTerm{CIDR: "10.0.0.0"}, false},
+		{"excluded tag", api.SubnetSelectorTerm{CIDR: "10.0.0.0/16", ExcludeTags: map[string]string{"reserved": "db"}}, false},
+		{"excluded tag key", api.SubnetSelectorTerm{CIDR: "10.0.0.0/16", ExcludeTags: map[string]string{"reserved": "*"}}, false},
+		{"other tag value", api.SubnetSelectorTerm{CIDR: "10.0.0.0/16", ExcludeTags: map[string]string{"reserved": "cache"}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := subnetMatches(&tt.term, subnet); got != tt.want {
+				t.Errorf("subnetMatches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetSubnetFilterSets_TagsSorted(t *testing.T) {
+	terms := []api.SubnetSelectorTerm{
+		{Tags: map[string]string{"b": "2", "a": "*", "c": "3"}},
+	}
+	for i := 0; i < 10; i++ {
+		filterSets := getSubnetFilterSets(terms)
+		names := lo.Map(filterSets[0].filters, func(f *vpc2017.Filter, _ int) string { return lo.FromPtr(f.Name) })
+		if want := []string{"tag-key", "tag:b", "tag:c"}; strings.Join(names, ",") != strings.Join(want, ",") {
+			t.Fatalf("expected filters %v, got %v", want, names)
+		}
+	}
+}
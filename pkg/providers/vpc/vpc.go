@@ -18,7 +18,12 @@ package vpc
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
 
+	"github.com/samber/lo"
+	"github.com/tencentcloud/karpenter-provider-tke/pkg/apicache"
 	api "github.com/tencentcloud/karpenter-provider-tke/pkg/apis/v1beta1"
 	vpc2017 "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/vpc/v20170312"
 )
@@ -28,14 +33,59 @@ type Provider interface {
 	ListSecurityGroups(ctx context.Context, nodeClass *api.TKEMachineNodeClass) ([]*vpc2017.SecurityGroup, error)
 }
 
+const (
+	// describeLimit is the max page size of DescribeSubnets and DescribeSecurityGroups
+	describeLimit = 100
+)
+
 type DefaultProvider struct {
-	client *vpc2017.Client
-	vpcID  string
+	client    *vpc2017.Client
+	vpcID     string
+	dualStack bool
+	cache     *apicache.Cache
 }
 
-func NewDefaultProvider(_ context.Context, client *vpc2017.Client, vpcID string) *DefaultProvider {
+func NewDefaultProvider(_ context.Context, client *vpc2017.Client, vpcID string, dualStack bool, cache *apicache.Cache) *DefaultProvider {
 	return &DefaultProvider{
-		client: client,
-		vpcID:  vpcID,
+		client:    client,
+		vpcID:     vpcID,
+		dualStack: dualStack,
+		cache:     cache,
+	}
+}
+
+func filter(name, value string) *vpc2017.Filter {
+	return &vpc2017.Filter{
+		Name:   lo.ToPtr(name),
+		Values: []*string{lo.ToPtr(value)},
+	}
+}
+
+// tagFilters returns the filters of the tags sorted by the key, so the filters of the same tags are cached once.
+func tagFilters(tags map[string]string) (filters []*vpc2017.Filter) {
+	keys := lo.Keys(tags)
+	sort.Strings(keys)
+	for _, k := range keys {
+		v := tags[k]
+		if v == "*" {
+			filters = append(filters, filter("tag-key", k))
+		} else {
+			filters = append(filters, filter(fmt.Sprintf("tag:%s", k), v))
+		}
 	}
+	return filters
+}
+
+// hasAnyTag returns whether any of the tags is set, '*' matches all values of the tag key.
+func hasAnyTag(tagSet []*vpc2017.Tag, tags map[string]string) bool {
+	return lo.SomeBy(tagSet, func(tag *vpc2017.Tag) bool {
+		v, ok := tags[lo.FromPtr(tag.Key)]
+		return ok && (v == "*" || v == lo.FromPtr(tag.Value))
+	})
+}
+
+// cacheKey returns the key of the describe response of the filters.
+func cacheKey(filters []*vpc2017.Filter) string {
+	key, _ := json.Marshal(filters)
+	return string(key)
 }